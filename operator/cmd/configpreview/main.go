@@ -0,0 +1,120 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+// configpreview prints the config and the statefulset the operator builds for
+// each rack of a CassandraDatacenter manifest, without a Kubernetes cluster.
+// Given the manifest of the current datacenter, it also prints the statefulset
+// diff of each rack and whether its pods would be restarted.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/reconciliation"
+)
+
+func main() {
+	currentPath := pflag.String("current", "", "manifest of the CassandraDatacenter as it is now, to diff against")
+	output := pflag.StringP("output", "o", "text", "output format, text or json")
+	pflag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <desired CassandraDatacenter manifest>\n", os.Args[0])
+		pflag.PrintDefaults()
+	}
+	pflag.Parse()
+
+	if pflag.NArg() != 1 || (*output != "text" && *output != "json") {
+		pflag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(pflag.Arg(0), *currentPath, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(desiredPath, currentPath, output string) error {
+	desired, err := readDatacenter(desiredPath)
+	if err != nil {
+		return err
+	}
+
+	var current *api.CassandraDatacenter
+	if currentPath != "" {
+		current, err = readDatacenter(currentPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	previews, err := reconciliation.PreviewConfigChange(current, desired)
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(previews)
+	}
+
+	for _, preview := range previews {
+		printPreview(preview, current != nil)
+	}
+	return nil
+}
+
+func readDatacenter(path string) (*api.CassandraDatacenter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	dc := &api.CassandraDatacenter{}
+	if err := yaml.NewYAMLOrJSONDecoder(file, 4096).Decode(dc); err != nil {
+		return nil, fmt.Errorf("error decoding %s: %v", path, err)
+	}
+	if dc.Kind != "CassandraDatacenter" {
+		return nil, fmt.Errorf("%s is not a CassandraDatacenter manifest", path)
+	}
+	return dc, nil
+}
+
+func printPreview(preview reconciliation.RackConfigPreview, withCurrent bool) {
+	fmt.Printf("=== Rack %s\n\n", preview.RackName)
+
+	configData := bytes.Buffer{}
+	if err := json.Indent(&configData, preview.ConfigData, "", "  "); err != nil {
+		configData.Write(preview.ConfigData)
+	}
+	fmt.Printf("CONFIG_FILE_DATA:\n%s\n\n", configData.String())
+
+	if !withCurrent {
+		return
+	}
+
+	switch {
+	case preview.NewRack:
+		fmt.Printf("New rack, its statefulset is created\n\n")
+	case preview.RollingRestart:
+		fmt.Printf("Rolling restart: yes\n\n")
+	case len(preview.HotAppliedSettings) > 0:
+		fmt.Printf("Rolling restart: no, applied to the running nodes: %v\n\n", preview.HotAppliedSettings)
+	default:
+		fmt.Printf("Rolling restart: no\n\n")
+	}
+
+	if preview.StatefulSetDiff == "" {
+		fmt.Printf("StatefulSet: no changes\n\n")
+	} else {
+		fmt.Printf("StatefulSet diff:\n%s\n", preview.StatefulSetDiff)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
@@ -37,6 +38,7 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
 	"github.com/datastax/cass-operator/operator/pkg/images"
@@ -49,6 +51,10 @@ var (
 	operatorMetricsPort int32 = 8686
 	version                   = "DEV"
 )
+
+// How often the image catalog file is read for changes
+const imageCatalogPollInterval = 30 * time.Second
+
 var log = logf.Log.WithName("cmd")
 
 func printVersion() {
@@ -144,6 +150,17 @@ func main() {
 		log.Error(err, "Failed to read base OS into env")
 	}
 
+	stop := signals.SetupSignalHandler()
+
+	if catalogFile := os.Getenv(images.EnvImageCatalogFile); catalogFile != "" {
+		if err = images.LoadCatalogFile(catalogFile); err != nil {
+			log.Error(err, "Failed to load image catalog, using the default images", "path", catalogFile)
+		} else {
+			log.Info("Loaded image catalog", "path", catalogFile)
+		}
+		go images.WatchCatalogFile(catalogFile, imageCatalogPollInterval, stop)
+	}
+
 	// Set default manager options
 	options := manager.Options{
 		Namespace:          namespace,
@@ -198,6 +215,11 @@ func main() {
 			log.Error(err, "unable to create validating webhook for CassandraDatacenter")
 			os.Exit(1)
 		}
+
+		// Only called when the eviction webhook is registered with the cluster
+		mgr.GetWebhookServer().Register(webhook.EvictionWebhookPath, &admission.Webhook{
+			Handler: &webhook.EvictionValidator{Client: mgr.GetClient()},
+		})
 	}
 
 	// Add the Metrics Service
@@ -206,7 +228,7 @@ func main() {
 	log.Info("Starting the Cmd.")
 
 	// Start the Cmd
-	if err := mgr.Start(signals.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(stop); err != nil {
 		log.Error(err, "Manager exited non-zero")
 		os.Exit(1)
 	}
@@ -0,0 +1,176 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/httphelper"
+	"github.com/datastax/cass-operator/operator/pkg/oplabels"
+)
+
+// EvictionWebhookPath is where the pod eviction webhook is served
+const EvictionWebhookPath = "/validate-pod-eviction"
+
+// The webhook is registered with a timeout of 10 seconds and is ignored when
+// it times out, so the state of the ring must be known well before that. Each
+// Management API call gets a single short attempt, so that a hung node leaves
+// time to ask the others.
+var (
+	evictionCheckTimeout     = 8 * time.Second
+	evictionCheckCallTimeout = 2 * time.Second
+)
+
+// EvictionValidator rejects the eviction of a Cassandra pod while gossip
+// reports a node of another rack of its datacenter as down, as evicting it
+// would take down replicas in two racks at once. The PodDisruptionBudgets
+// only see pod readiness, and may lag behind what the ring already knows.
+type EvictionValidator struct {
+	Client crclient.Client
+
+	// httpClient replaces the Management API client in tests
+	httpClient httphelper.HttpClient
+}
+
+var _ admission.Handler = &EvictionValidator{}
+
+func (v *EvictionValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	pod := &corev1.Pod{}
+	err := v.Client.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: req.Name}, pod)
+	if err != nil {
+		// Not a pod the operator can see, so not one of ours
+		return admission.Allowed("")
+	}
+	if !oplabels.HasManagedByCassandraOperatorLabel(pod.Labels) || pod.Labels[api.DatacenterLabel] == "" {
+		return admission.Allowed("")
+	}
+
+	dc := &api.CassandraDatacenter{}
+	err = v.Client.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: pod.Labels[api.DatacenterLabel]}, dc)
+	if errors.IsNotFound(err) {
+		return admission.Allowed("")
+	} else if err != nil {
+		log.Error(err, "Could not get the datacenter of an evicted pod, rejecting the eviction", "pod", pod.Name)
+		return admission.Errored(http.StatusServiceUnavailable, err)
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, evictionCheckTimeout)
+	defer cancel()
+	downNode, err := v.findDownNodeInOtherRack(checkCtx, dc, pod)
+	if err != nil {
+		log.Error(err, "Could not get the gossip state of the datacenter, rejecting the eviction", "pod", pod.Name)
+		return admission.Errored(http.StatusServiceUnavailable, err)
+	}
+	if downNode != nil {
+		reason := fmt.Sprintf("Cassandra node %s of rack %s is down, evicting pod %s of rack %s would compromise availability",
+			downNode.GetRpcAddress(), downNode.Rack, pod.Name, pod.Labels[api.RackLabel])
+		log.Info("Rejecting pod eviction", "pod", pod.Name, "reason", reason)
+		return admission.Denied(reason)
+	}
+
+	return admission.Allowed("")
+}
+
+// findDownNodeInOtherRack returns a node of the datacenter, in another rack
+// than the pod, that gossip reports as down
+func (v *EvictionValidator) findDownNodeInOtherRack(ctx context.Context, dc *api.CassandraDatacenter, evicted *corev1.Pod) (*httphelper.EndpointState, error) {
+	mgmtClient, err := v.buildNodeMgmtClient(ctx, dc)
+	if err != nil {
+		return nil, err
+	}
+
+	podList := &corev1.PodList{}
+	err = v.Client.List(ctx, podList,
+		crclient.InNamespace(dc.Namespace),
+		crclient.MatchingLabels(dc.GetDatacenterLabels()))
+	if err != nil {
+		return nil, err
+	}
+
+	// Ask the other nodes first, the evicted one might be the one in trouble
+	pods := []*corev1.Pod{}
+	for i := range podList.Items {
+		if podList.Items[i].Name != evicted.Name {
+			pods = append(pods, &podList.Items[i])
+		}
+	}
+	pods = append(pods, evicted)
+
+	anyReady := false
+	for _, pod := range pods {
+		if !isCassandraReady(pod) {
+			continue
+		}
+		anyReady = true
+		if ctx.Err() != nil {
+			break
+		}
+		endpoints, err := mgmtClient.CallMetadataEndpointsEndpoint(pod)
+		if err != nil {
+			log.Error(err, "Could not get endpoints data", "pod", pod.Name)
+			continue
+		}
+
+		rack := evicted.Labels[api.RackLabel]
+		for i := range endpoints.Entity {
+			ep := &endpoints.Entity[i]
+			if ep.Datacenter != dc.Name || ep.Rack == rack || ep.Status == "LEFT" {
+				continue
+			}
+			if ep.IsAlive != "true" {
+				return ep, nil
+			}
+		}
+		return nil, nil
+	}
+
+	if !anyReady {
+		// Nothing is serving, an eviction cannot make it worse
+		return nil, nil
+	}
+	return nil, fmt.Errorf("no Management API of datacenter %s could be reached", dc.Name)
+}
+
+func (v *EvictionValidator) buildNodeMgmtClient(ctx context.Context, dc *api.CassandraDatacenter) (*httphelper.NodeMgmtClient, error) {
+	httpClient := v.httpClient
+	if httpClient == nil {
+		var err error
+		if httpClient, err = httphelper.BuildManagementApiHttpClient(dc, v.Client, ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	protocol, err := httphelper.GetManagementApiProtocol(dc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &httphelper.NodeMgmtClient{
+		Client:      httpClient,
+		Log:         log,
+		Protocol:    protocol,
+		RetryPolicy: &httphelper.RetryPolicy{MaxAttempts: 1},
+		Timeout:     evictionCheckCallTimeout,
+		Ctx:         ctx,
+	}, nil
+}
+
+func isCassandraReady(pod *corev1.Pod) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == "cassandra" {
+			return status.Ready
+		}
+	}
+	return false
+}
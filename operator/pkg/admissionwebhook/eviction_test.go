@@ -0,0 +1,128 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/httphelper/fakemgmtapi"
+	"github.com/datastax/cass-operator/operator/pkg/oplabels"
+)
+
+func setupEvictionTest(racks ...string) (*EvictionValidator, *fakemgmtapi.Server, []*corev1.Pod) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dc1",
+			Namespace: "default",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "cluster1",
+		},
+	}
+	scheme.Scheme.AddKnownTypes(api.SchemeGroupVersion, dc, &api.CassandraDatacenterList{})
+
+	server := fakemgmtapi.NewServer()
+	objects := []runtime.Object{dc}
+	pods := []*corev1.Pod{}
+	for i, rack := range racks {
+		labels := dc.GetRackLabels(rack)
+		oplabels.AddManagedByLabel(labels)
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("pod-%d", i),
+				Namespace: dc.Namespace,
+				Labels:    labels,
+			},
+			Status: corev1.PodStatus{
+				PodIP:             fmt.Sprintf("10.0.0.%d", i+1),
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: true}},
+			},
+		}
+		objects = append(objects, pod)
+		pods = append(pods, pod)
+
+		server.AddStartedNode(pod.Status.PodIP)
+		server.SetDatacenter(pod.Status.PodIP, dc.Name)
+		server.SetRack(pod.Status.PodIP, rack)
+	}
+
+	validator := &EvictionValidator{
+		Client:     fake.NewFakeClient(objects...),
+		httpClient: server,
+	}
+	return validator, server, pods
+}
+
+func evict(validator *EvictionValidator, podName string) admission.Response {
+	return validator.Handle(context.Background(), admission.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Name:      podName,
+			Namespace: "default",
+		},
+	})
+}
+
+func TestEvictionValidator(t *testing.T) {
+	validator, server, pods := setupEvictionTest("r1", "r1", "r2", "r2", "r3", "r3")
+
+	assert.True(t, evict(validator, "pod-0").Allowed, "the ring is healthy")
+	assert.True(t, evict(validator, "not-a-cassandra-pod").Allowed)
+
+	// A node of r2 goes down, without its pod being marked not ready yet
+	server.StopNode(pods[2].Status.PodIP)
+
+	response := evict(validator, "pod-0")
+	assert.False(t, response.Allowed, "a node of another rack is down")
+	assert.Contains(t, response.Result.Reason, "10.0.0.3")
+
+	assert.True(t, evict(validator, "pod-3").Allowed, "the down node is in the same rack")
+}
+
+func TestEvictionValidator_ManagementApiUnreachable(t *testing.T) {
+	validator, server, pods := setupEvictionTest("r1", "r2")
+	for _, pod := range pods {
+		server.StopNode(pod.Status.PodIP)
+	}
+
+	assert.False(t, evict(validator, "pod-0").Allowed)
+}
+
+// hangingHttpClient stands for Management APIs that accept connections but
+// never answer
+type hangingHttpClient struct{}
+
+func (c *hangingHttpClient) Do(req *http.Request) (*http.Response, error) {
+	<-req.Context().Done()
+	return nil, req.Context().Err()
+}
+
+func TestEvictionValidator_ManagementApiHanging(t *testing.T) {
+	defer func(timeout, callTimeout time.Duration) {
+		evictionCheckTimeout, evictionCheckCallTimeout = timeout, callTimeout
+	}(evictionCheckTimeout, evictionCheckCallTimeout)
+	evictionCheckTimeout = 300 * time.Millisecond
+	evictionCheckCallTimeout = 100 * time.Millisecond
+
+	validator, _, _ := setupEvictionTest("r1", "r2", "r3", "r3", "r3", "r3")
+	validator.httpClient = &hangingHttpClient{}
+
+	start := time.Now()
+	response := evict(validator, "pod-0")
+	assert.False(t, response.Allowed, "the state of the ring is unknown")
+	assert.True(t, time.Since(start) < time.Second, "took %v", time.Since(start))
+}
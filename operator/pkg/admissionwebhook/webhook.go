@@ -155,6 +155,9 @@ func updateWebhook(client crclient.Client, cert, namespace string) (err error) {
 			if err = unstructured.SetNestedField(webhook, base64.StdEncoding.EncodeToString([]byte(cert)), "clientConfig", "caBundle"); err == nil {
 				if webhook_slice, present, err = unstructured.NestedSlice(webhook_config.Object, "webhooks"); present && err == nil {
 					webhook_slice[webhook_index] = webhook
+					if err = updateOtherWebhooks(webhook_slice, webhook_index, cert, namespace); err != nil {
+						return err
+					}
 					if err = unstructured.SetNestedSlice(webhook_config.Object, webhook_slice, "webhooks"); err == nil {
 						err = client.Update(context.Background(), webhook_config)
 					}
@@ -165,6 +168,28 @@ func updateWebhook(client crclient.Client, cert, namespace string) (err error) {
 	return err
 }
 
+// updateOtherWebhooks points the other webhooks served by the operator, such
+// as the pod eviction one, to its namespace and certificate
+func updateOtherWebhooks(webhook_slice []interface{}, webhook_index int, cert, namespace string) (err error) {
+	for index, webhook_untypped := range webhook_slice {
+		webhook, ok := webhook_untypped.(map[string]interface{})
+		if !ok || index == webhook_index {
+			continue
+		}
+		if service_name, _, _ := unstructured.NestedString(webhook, "clientConfig", "service", "name"); service_name != "cassandradatacenter-webhook-service" {
+			continue
+		}
+		if err = unstructured.SetNestedField(webhook, namespace, "clientConfig", "service", "namespace"); err != nil {
+			return err
+		}
+		if err = unstructured.SetNestedField(webhook, base64.StdEncoding.EncodeToString([]byte(cert)), "clientConfig", "caBundle"); err != nil {
+			return err
+		}
+		webhook_slice[index] = webhook
+	}
+	return nil
+}
+
 func EnsureWebhookConfigVolume(cfg *rest.Config) (err error) {
 	var pod *v1.Pod
 	namespace, err := k8sutil.GetOperatorNamespace()
@@ -6,12 +6,14 @@ package v1beta1
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/Jeffail/gabs"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	"github.com/datastax/cass-operator/operator/pkg/images"
 	"github.com/datastax/cass-operator/operator/pkg/serverconfig"
@@ -40,6 +42,18 @@ const (
 	// CassNodeState
 	CassNodeState = "cassandra.datastax.com/node-state"
 
+	// RetainedPVCLabel marks the PVCs kept when their datacenter was deleted,
+	// for a datacenter of the same name to take over
+	RetainedPVCLabel = "cassandra.datastax.com/retained"
+
+	// ExternalAddressAnnotation holds on a pod the address of its service for
+	// clients outside the Kubernetes cluster, when external access is enabled
+	ExternalAddressAnnotation = "cassandra.datastax.com/external-address"
+
+	// PodServiceLabel is the label of the services of single pods, with the
+	// name of the pod
+	PodServiceLabel = "cassandra.datastax.com/pod-service"
+
 	// Progress states for status
 	ProgressUpdating ProgressState = "Updating"
 	ProgressReady    ProgressState = "Ready"
@@ -70,13 +84,18 @@ type CassandraDatacenterSpec struct {
 
 	// Version string for config builder,
 	// used to generate Cassandra server configuration
-	// +kubebuilder:validation:Pattern=(6\.8\.\d+)|(3\.11\.\d+)|(4\.0\.\d+)
+	// +kubebuilder:validation:Pattern=(6\.8\.\d+)|(3\.11\.\d+)|(4\.0\.\d+)|(4\.1\.\d+)|(5\.0\.\d+)
 	ServerVersion string `json:"serverVersion"`
 
 	// Cassandra server image name.
 	// More info: https://kubernetes.io/docs/concepts/containers/images
 	ServerImage string `json:"serverImage,omitempty"`
 
+	// Registry, repositories and pull secrets of the images of the datacenter,
+	// in place of the ones set for the whole operator
+	// +optional
+	ImageConfig *ImageConfig `json:"imageConfig,omitempty"`
+
 	// Server type: "cassandra" or "dse"
 	// +kubebuilder:validation:Enum=cassandra;dse
 	ServerType string `json:"serverType"`
@@ -128,6 +147,12 @@ type CassandraDatacenterSpec struct {
 	// Container image for the config builder init container.
 	ConfigBuilderImage string `json:"configBuilderImage,omitempty"`
 
+	// Render the config files in the operator instead of the config builder init
+	// container, for the config sections it supports. The operator always renders them
+	// for the Cassandra versions the config builder does not support. Setting a
+	// configBuilderImage selects the config builder.
+	UseConfigRenderer bool `json:"useConfigRenderer,omitempty"`
+
 	// Indicates that configuration and container image changes should only be pushed to
 	// the first rack of the datacenter
 	CanaryUpgrade bool `json:"canaryUpgrade,omitempty"`
@@ -157,6 +182,9 @@ type CassandraDatacenterSpec struct {
 	// More info: https://kubernetes.io/docs/concepts/configuration/assign-pod-node/#nodeselector
 	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
 
+	// PriorityClassName of the server pods, unless their rack sets one
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
 	// Rack names in this list are set to the latest StatefulSet configuration
 	// even if Cassandra nodes are down. Use this to recover from an upgrade that couldn't
 	// roll out.
@@ -174,6 +202,37 @@ type CassandraDatacenterSpec struct {
 
 	AdditionalSeeds []string `json:"additionalSeeds,omitempty"`
 
+	// AddDatacenter joins this datacenter to a cluster that is already running in
+	// other datacenters. Before this datacenter reports itself as ready, the
+	// replication of the keyspaces is extended to it and its nodes are rebuilt
+	// from the source datacenter.
+	AddDatacenter *AddDatacenterConfig `json:"addDatacenter,omitempty"`
+
+	// DecommissionOnDelete removes this datacenter from the cluster when the
+	// CassandraDatacenter is deleted. The datacenter is dropped from the
+	// replication of every keyspace and its nodes are decommissioned, before
+	// anything is torn down. Has no effect on the last datacenter of a cluster.
+	DecommissionOnDelete bool `json:"decommissionOnDelete,omitempty"`
+
+	// DeletionProtection rejects the deletion of the CassandraDatacenter while
+	// set. It has to be set back to false before the datacenter can be deleted.
+	DeletionProtection bool `json:"deletionProtection,omitempty"`
+
+	// AutoReplace, when set, replaces the nodes whose data was on a
+	// Kubernetes node that was deleted or stayed NotReady for too long
+	AutoReplace *AutoReplaceConfig `json:"autoReplace,omitempty"`
+
+	// NodeMaintenance, when set, moves the pods off of Kubernetes nodes that
+	// are cordoned or carry the maintenance taint, one rack at a time
+	NodeMaintenance *NodeMaintenanceConfig `json:"nodeMaintenance,omitempty"`
+
+	// PodDisruptionBudgetScope is Datacenter, the default, for a single
+	// PodDisruptionBudget that allows one pod of the datacenter to be down,
+	// or Rack for a PodDisruptionBudget per rack that only allows disruptions
+	// in a rack while no other rack has a node down.
+	// +kubebuilder:validation:Enum=Datacenter;Rack
+	PodDisruptionBudgetScope PodDisruptionBudgetScope `json:"podDisruptionBudgetScope,omitempty"`
+
 	Reaper *ReaperConfig `json:"reaper,omitempty"`
 
 	// Configuration for disabling the simple log tailing sidecar container. Our default is to have it enabled.
@@ -187,9 +246,83 @@ type CassandraDatacenterSpec struct {
 	AdditionalServiceConfig ServiceConfig `json:"additionalServiceConfig,omitempty"`
 }
 
+type AddDatacenterConfig struct {
+	// Name of the datacenter of the cluster that the data of this datacenter
+	// is streamed from
+	// +kubebuilder:validation:MinLength=1
+	SourceDatacenter string `json:"sourceDatacenter"`
+
+	// User keyspaces to replicate to this datacenter. The system_auth,
+	// system_distributed and system_traces keyspaces are always replicated.
+	Keyspaces []string `json:"keyspaces,omitempty"`
+
+	// Replication factor of the keyspaces in this datacenter. Defaults to 3, or
+	// to the size of the datacenter if it is smaller.
+	// +kubebuilder:validation:Minimum=1
+	ReplicationFactor int `json:"replicationFactor,omitempty"`
+
+	// Maximum number of nodes rebuilt at the same time. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	RebuildConcurrency int `json:"rebuildConcurrency,omitempty"`
+}
+
+type AutoReplaceConfig struct {
+	// How long, in seconds, the local volume of a node that is down has to be
+	// on a Kubernetes node that was deleted or is NotReady before the node is
+	// replaced. Defaults to 1800.
+	// +kubebuilder:validation:Minimum=60
+	FailedNodeTimeoutSeconds int `json:"failedNodeTimeoutSeconds,omitempty"`
+}
+
+// PodDisruptionBudgetScope says how voluntary disruptions of the pods, such
+// as evictions by kubectl drain or the cluster autoscaler, are limited
+type PodDisruptionBudgetScope string
+
+const (
+	PodDisruptionBudgetDatacenter PodDisruptionBudgetScope = "Datacenter"
+	PodDisruptionBudgetRack       PodDisruptionBudgetScope = "Rack"
+)
+
+// NodeMaintenanceMode says what is done with the data of the pods on a
+// Kubernetes node in maintenance
+type NodeMaintenanceMode string
+
+const (
+	// NodeMaintenancePlannedDowntime deletes the pods of the node and leaves
+	// their data where it is, the node is expected to come back
+	NodeMaintenancePlannedDowntime NodeMaintenanceMode = "PlannedDowntime"
+
+	// NodeMaintenanceEvacuateAllData moves the pods of the node elsewhere,
+	// rebuilding their data on new volumes if the volumes cannot follow them
+	NodeMaintenanceEvacuateAllData NodeMaintenanceMode = "EvacuateAllData"
+)
+
+type NodeMaintenanceConfig struct {
+	// What is done with the pods of cordoned nodes. Defaults to
+	// PlannedDowntime.
+	// +kubebuilder:validation:Enum=PlannedDowntime;EvacuateAllData
+	CordonMode NodeMaintenanceMode `json:"cordonMode,omitempty"`
+
+	// Key of a NoSchedule taint that puts a node in maintenance, in addition
+	// to cordons. A taint value of "drain" evacuates all the data of the node,
+	// any other value is handled as planned downtime.
+	TaintKey string `json:"taintKey,omitempty"`
+}
+
 type NetworkingConfig struct {
 	NodePort    *NodePortConfig `json:"nodePort,omitempty"`
 	HostNetwork bool            `json:"hostNetwork,omitempty"`
+	// Exposes each pod through a LoadBalancer service of its own, whose
+	// address the node gives to clients as its broadcast_rpc_address
+	ExternalAccess *ExternalAccessConfig `json:"externalAccess,omitempty"`
+}
+
+// ExternalAccessConfig exposes the nodes of a datacenter to clients outside
+// the Kubernetes cluster. As each node has its own address, drivers can route
+// the requests to the replicas of their data.
+type ExternalAccessConfig struct {
+	// IP ranges the load balancers accept clients from, any if empty
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
 }
 
 type NodePortConfig struct {
@@ -209,6 +342,12 @@ func (dc *CassandraDatacenter) IsHostNetworkEnabled() bool {
 	return networking != nil && networking.HostNetwork
 }
 
+// IsExternalAccessEnabled tells if each pod has a LoadBalancer service
+func (dc *CassandraDatacenter) IsExternalAccessEnabled() bool {
+	networking := dc.Spec.Networking
+	return networking != nil && networking.ExternalAccess != nil
+}
+
 type DseWorkloads struct {
 	AnalyticsEnabled bool `json:"analyticsEnabled,omitempty"`
 	GraphEnabled     bool `json:"graphEnabled,omitempty"`
@@ -228,9 +367,26 @@ type AdditionalVolumes struct {
 
 type AdditionalVolumesSlice []AdditionalVolumes
 
+// PVCDeletionPolicy says what happens to the PVCs of a deleted datacenter
+type PVCDeletionPolicy string
+
+const (
+	// PVCDeletionPolicyDelete deletes the PVCs along with the datacenter
+	PVCDeletionPolicyDelete PVCDeletionPolicy = "Delete"
+
+	// PVCDeletionPolicyRetain keeps the PVCs, and the data on them, for a
+	// datacenter re-created with the same name
+	PVCDeletionPolicyRetain PVCDeletionPolicy = "Retain"
+)
+
 type StorageConfig struct {
 	CassandraDataVolumeClaimSpec *corev1.PersistentVolumeClaimSpec `json:"cassandraDataVolumeClaimSpec,omitempty"`
 	AdditionalVolumes            AdditionalVolumesSlice            `json:"additionalVolumes,omitempty"`
+	// What to do with the PVCs when the datacenter is deleted, defaults to
+	// Delete. Retained PVCs are reused by a datacenter created again with the
+	// same name.
+	// +kubebuilder:validation:Enum=Delete;Retain
+	DeletionPolicy PVCDeletionPolicy `json:"deletionPolicy,omitempty"`
 }
 
 // GetRacks is a getter for the Rack slice in the spec
@@ -252,6 +408,8 @@ type ServiceConfig struct {
 	AllPodsService        ServiceConfigAdditions `json:"allpodsService,omitempty"`
 	AdditionalSeedService ServiceConfigAdditions `json:"additionalSeedService,omitempty"`
 	NodePortService       ServiceConfigAdditions `json:"nodePortService,omitempty"`
+	// Services of the pods when external access is enabled
+	PodService ServiceConfigAdditions `json:"podService,omitempty"`
 }
 
 // ServiceConfigAdditions exposes additional options for each service
@@ -271,10 +429,42 @@ type Rack struct {
 
 	//NodeAffinityLabels to pin the rack, using node affinity
 	NodeAffinityLabels map[string]string `json:"nodeAffinityLabels,omitempty"`
+
+	// Tolerations of the pods of the rack, added to the ones of the
+	// podTemplateSpec
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity of the pods of the rack. Its node affinity is combined with the
+	// node affinity labels, its pod anti-affinity with the one keeping the
+	// server pods on separate workers, and its pod affinity with the one of the
+	// podTemplateSpec.
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// TopologySpreadConstraints of the pods of the rack, added to the ones of
+	// the podTemplateSpec. A constraint without a label selector applies to
+	// the pods of the rack.
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// PriorityClassName of the pods of the rack, overriding the one of the
+	// datacenter
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// Config for the server in the rack, in YAML format. It is merged over
+	// the config of the datacenter, its settings taking precedence.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Config json.RawMessage `json:"config,omitempty"`
 }
 
 type CassandraNodeStatus struct {
 	HostID string `json:"hostID,omitempty"`
+
+	// Tokens owned by the node, recorded when the datacenter is stopped
+	Tokens []string `json:"tokens,omitempty"`
+
+	// UID of the PVC holding the data of the node when the datacenter was
+	// stopped. A node whose PVC still has this UID resumes with its data
+	// intact.
+	PVCUID types.UID `json:"pvcUID,omitempty"`
 }
 
 type CassandraStatusMap map[string]CassandraNodeStatus
@@ -292,6 +482,24 @@ const (
 	DatacenterResuming       DatacenterConditionType = "Resuming"
 	DatacenterRollingRestart DatacenterConditionType = "RollingRestart"
 	DatacenterValid          DatacenterConditionType = "Valid"
+
+	// Set when a long-running Management API job such as cleanup or
+	// decommission fails. The reason names the job type.
+	DatacenterJobFailed DatacenterConditionType = "JobFailed"
+
+	// Set while a datacenter with addDatacenter in its spec is joining the
+	// cluster, the message reports how far along the rebuild is
+	DatacenterAddingDatacenter DatacenterConditionType = "AddingDatacenter"
+
+	// Set when the datacenter took over the PVCs retained by a deleted
+	// datacenter of the same name. Its nodes start from the data on them
+	// rather than joining the cluster as new nodes.
+	DatacenterAdoptedPVCs DatacenterConditionType = "AdoptedPVCs"
+
+	// Set while the config of the datacenter has settings that the config
+	// builder does not support for its server version. The datacenter is
+	// still reconciled, the message lists the offending settings.
+	DatacenterUnsupportedConfig DatacenterConditionType = "UnsupportedConfig"
 )
 
 type DatacenterCondition struct {
@@ -359,6 +567,25 @@ type CassandraDatacenterStatus struct {
 
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Settings of the config applied to the running nodes of each rack
+	// without restarting them, by rack name. The pod template of the rack
+	// still has their previous value, and they are applied again to the
+	// nodes that restart.
+	// +optional
+	HotAppliedConfig map[string][]string `json:"hotAppliedConfig,omitempty"`
+
+	// Set once the datacenter found other datacenters of its cluster created
+	// before it, before it was initialized. Their seeds are then part of the
+	// seeds of its nodes, through the additional seed service.
+	// +optional
+	SeededFromPeers bool `json:"seededFromPeers,omitempty"`
+
+	// Digests the images of the pod templates of the racks are pinned to, by
+	// image reference. A reference lists several digests while the racks are
+	// moving from one digest to another.
+	// +optional
+	ImageDigests map[string][]string `json:"imageDigests,omitempty"`
 }
 
 // +genclient
@@ -392,6 +619,38 @@ type ManagementApiAuthConfig struct {
 	// other strategy configs (e.g. Cert Manager) go here
 }
 
+// ImageConfig sets where the images of the containers of a datacenter are
+// pulled from. The registry and the repositories apply to the default images,
+// not to the images set explicitly in the spec.
+type ImageConfig struct {
+	// Registry of the default images, in place of the one set by the
+	// DEFAULT_CONTAINER_REGISTRY_OVERRIDE env var of the operator
+	Registry string `json:"registry,omitempty"`
+
+	// Repositories of the default images, by kind of image. The tag of the
+	// default image is kept.
+	Repositories ImageRepositories `json:"repositories,omitempty"`
+
+	// Secrets to pull the images with, added to every pod the operator
+	// creates for the datacenter. When a registry is set, they replace the
+	// secret set by DEFAULT_CONTAINER_REGISTRY_OVERRIDE_PULL_SECRETS.
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+}
+
+// ImageRepositories are repositories of images, without the registry and the
+// tag, such as datastax/cassandra-mgmtapi-3_11_7
+type ImageRepositories struct {
+	Server        string `json:"server,omitempty"`
+	ConfigBuilder string `json:"configBuilder,omitempty"`
+	SystemLogger  string `json:"systemLogger,omitempty"`
+	Reaper        string `json:"reaper,omitempty"`
+	// Image of the job that creates the keyspace of Reaper
+	ReaperSchemaInit string `json:"reaperSchemaInit,omitempty"`
+	// Image of the init container that writes the config files rendered by the
+	// operator
+	BusyBox string `json:"busybox,omitempty"`
+}
+
 type ReaperConfig struct {
 	Enabled bool `json:"enabled,omitempty"`
 
@@ -416,12 +675,42 @@ func init() {
 	SchemeBuilder.Register(&CassandraDatacenter{}, &CassandraDatacenterList{})
 }
 
+// UsesConfigRenderer tells if the operator renders the config files itself,
+// when it supports the server version and the config. It does when asked to
+// and for the server versions the config builder does not support.
+func (dc *CassandraDatacenter) UsesConfigRenderer() bool {
+	if dc.Spec.ConfigBuilderImage != "" {
+		return false
+	}
+	return dc.Spec.UseConfigRenderer || !images.IsConfigBuilderCompatible(dc.Spec.ServerType, dc.Spec.ServerVersion)
+}
+
 func (dc *CassandraDatacenter) GetConfigBuilderImage() string {
 	if dc.Spec.ConfigBuilderImage != "" {
-		return dc.Spec.ConfigBuilderImage
+		return images.PinDigest(dc.Spec.ConfigBuilderImage)
 	} else {
-		return images.GetConfigBuilderImage()
+		return dc.ApplyImageConfig(images.GetConfigBuilderImage(), dc.GetImageRepositories().ConfigBuilder)
+	}
+}
+
+// GetImageRepositories returns the repositories of the imageConfig of the
+// datacenter, empty if it has none
+func (dc *CassandraDatacenter) GetImageRepositories() ImageRepositories {
+	if dc.Spec.ImageConfig == nil {
+		return ImageRepositories{}
+	}
+	return dc.Spec.ImageConfig.Repositories
+}
+
+// ApplyImageConfig replaces the registry of a default image with the one of
+// the imageConfig of the datacenter, and its repository with the given one.
+// Empty values keep the ones of the image.
+func (dc *CassandraDatacenter) ApplyImageConfig(image, repository string) string {
+	registry := ""
+	if dc.Spec.ImageConfig != nil {
+		registry = dc.Spec.ImageConfig.Registry
 	}
+	return images.OverrideImage(image, registry, repository)
 }
 
 // GetServerImage produces a fully qualified container image to pull
@@ -430,7 +719,11 @@ func (dc *CassandraDatacenter) GetConfigBuilderImage() string {
 // In the event that no valid image could be retrieved from the specified version,
 // an error is returned.
 func (dc *CassandraDatacenter) GetServerImage() (string, error) {
-	return makeImage(dc.Spec.ServerType, dc.Spec.ServerVersion, dc.Spec.ServerImage)
+	image, err := makeImage(dc.Spec.ServerType, dc.Spec.ServerVersion, dc.Spec.ServerImage)
+	if err != nil || dc.Spec.ServerImage != "" {
+		return image, err
+	}
+	return dc.ApplyImageConfig(image, dc.GetImageRepositories().Server), nil
 }
 
 // makeImage takes the server type/version and image from the spec,
@@ -439,11 +732,12 @@ func (dc *CassandraDatacenter) GetServerImage() (string, error) {
 // serverImage should be an empty string, or [hostname[:port]/][path/with/repo]:[Server container img tag]
 // If serverImage is empty, we attempt to find an appropriate container image based on the serverVersion
 // In the event that no image is found, an error is returned
+// The image is pinned to its digest in the image catalog, if it has one
 func makeImage(serverType, serverVersion, serverImage string) (string, error) {
 	if serverImage == "" {
 		return images.GetCassandraImage(serverType, serverVersion)
 	}
-	return serverImage, nil
+	return images.PinDigest(serverImage), nil
 }
 
 // GetRackLabels ...
@@ -519,6 +813,14 @@ func (dc *CassandraDatacenter) GetDatacenterLabels() map[string]string {
 	return labels
 }
 
+// UsesAdditionalSeedService returns true if the nodes of the datacenter also
+// seed from the additional seed service, which carries the additional seeds
+// of the spec and the seeds of the other datacenters the datacenter joined
+// the cluster through
+func (dc *CassandraDatacenter) UsesAdditionalSeedService() bool {
+	return len(dc.Spec.AdditionalSeeds) > 0 || dc.Status.SeededFromPeers
+}
+
 // GetClusterLabels returns a new map with the cluster label key and cluster name value
 func (dc *CassandraDatacenter) GetClusterLabels() map[string]string {
 	return map[string]string{
@@ -534,6 +836,51 @@ func (dc *CassandraDatacenter) GetAdditionalSeedsServiceName() string {
 	return dc.Spec.ClusterName + "-" + dc.Name + fmt.Sprintf("-additional-seed-service")
 }
 
+// GetAddDatacenterReplicationFactor returns the replication factor given to
+// this datacenter when it is added to the cluster
+func (dc *CassandraDatacenter) GetAddDatacenterReplicationFactor() int {
+	if dc.Spec.AddDatacenter != nil && dc.Spec.AddDatacenter.ReplicationFactor > 0 {
+		return dc.Spec.AddDatacenter.ReplicationFactor
+	}
+	if dc.Spec.Size < 3 {
+		return int(dc.Spec.Size)
+	}
+	return 3
+}
+
+// IsRebuildPending tells if the datacenter joins the cluster with addDatacenter
+// and its nodes are not rebuilt from the source datacenter yet. It is done
+// once the datacenter is initialized, or when it adopted the PVCs of a
+// previous datacenter.
+func (dc *CassandraDatacenter) IsRebuildPending() bool {
+	return dc.Spec.AddDatacenter != nil &&
+		dc.GetConditionStatus(DatacenterInitialized) != corev1.ConditionTrue &&
+		dc.GetConditionStatus(DatacenterAdoptedPVCs) != corev1.ConditionTrue
+}
+
+// GetRebuildConcurrency returns how many nodes are rebuilt at the same time
+func (dc *CassandraDatacenter) GetRebuildConcurrency() int {
+	if dc.Spec.AddDatacenter != nil && dc.Spec.AddDatacenter.RebuildConcurrency > 0 {
+		return dc.Spec.AddDatacenter.RebuildConcurrency
+	}
+	return 1
+}
+
+// GetFailedNodeTimeout returns how long a Kubernetes node can be gone before
+// the Cassandra nodes with data on it are replaced
+func (dc *CassandraDatacenter) GetFailedNodeTimeout() time.Duration {
+	if dc.Spec.AutoReplace != nil && dc.Spec.AutoReplace.FailedNodeTimeoutSeconds > 0 {
+		return time.Duration(dc.Spec.AutoReplace.FailedNodeTimeoutSeconds) * time.Second
+	}
+	return 30 * time.Minute
+}
+
+// IsNodeMaintenanceEnabled tells if the pods are moved off of the Kubernetes
+// nodes in maintenance, either with VMware PSP or through nodeMaintenance
+func (dc *CassandraDatacenter) IsNodeMaintenanceEnabled() bool {
+	return utils.IsPSPEnabled() || dc.Spec.NodeMaintenance != nil
+}
+
 func (dc *CassandraDatacenter) GetAllPodsServiceName() string {
 	return dc.Spec.ClusterName + "-" + dc.Name + "-all-pods-service"
 }
@@ -546,6 +893,12 @@ func (dc *CassandraDatacenter) GetNodePortServiceName() string {
 	return dc.Spec.ClusterName + "-" + dc.Name + "-node-port-service"
 }
 
+// GetPodServiceName returns the name of the service of a pod when external
+// access is enabled
+func (dc *CassandraDatacenter) GetPodServiceName(podName string) string {
+	return podName + "-external"
+}
+
 func (dc *CassandraDatacenter) ShouldGenerateSuperuserSecret() bool {
 	return len(dc.Spec.SuperuserSecretName) == 0
 }
@@ -563,14 +916,26 @@ func (dc *CassandraDatacenter) GetSuperuserSecretNamespacedName() types.Namespac
 	}
 }
 
-// GetConfigAsJSON gets a JSON-encoded string suitable for passing to configBuilder
-func (dc *CassandraDatacenter) GetConfigAsJSON() (string, error) {
+// ValidateConfig checks the config of the datacenter and of its racks against
+// the settings the config builder accepts for its server type and version
+func (dc *CassandraDatacenter) ValidateConfig() field.ErrorList {
+	errs := serverconfig.ValidateConfig(dc.Spec.Config, dc.Spec.ServerType, dc.Spec.ServerVersion,
+		field.NewPath("spec", "config"))
+	for i, rack := range dc.Spec.Racks {
+		errs = append(errs, serverconfig.ValidateConfig(rack.Config, dc.Spec.ServerType, dc.Spec.ServerVersion,
+			field.NewPath("spec", "racks").Index(i).Child("config"))...)
+	}
+	return errs
+}
+
+// getConfig combines the model values of the datacenter with its config
+func (dc *CassandraDatacenter) getConfig() (*gabs.Container, error) {
 
 	// We use the cluster seed-service name here for the seed list as it will
 	// resolve to the seed nodes. This obviates the need to update the
 	// cassandra.yaml whenever the seed nodes change.
 	seeds := []string{dc.GetSeedServiceName()}
-	if len(dc.Spec.AdditionalSeeds) > 0 {
+	if dc.UsesAdditionalSeedService() {
 		seeds = append(seeds, dc.GetAdditionalSeedsServiceName())
 	}
 
@@ -601,6 +966,12 @@ func (dc *CassandraDatacenter) GetConfigAsJSON() (string, error) {
 		internodeSSL = dc.Spec.Networking.NodePort.InternodeSSL
 	}
 
+	// The address of the service of the pod is filled in when it starts
+	broadcastRPCAddress := ""
+	if dc.IsExternalAccessEnabled() {
+		broadcastRPCAddress = serverconfig.ExternalAddressPlaceholder
+	}
+
 	modelValues := serverconfig.GetModelValues(
 		seeds,
 		dc.Spec.ClusterName,
@@ -611,34 +982,81 @@ func (dc *CassandraDatacenter) GetConfigAsJSON() (string, error) {
 		native,
 		nativeSSL,
 		internode,
-		internodeSSL)
+		internodeSSL,
+		broadcastRPCAddress)
+
+	// The rebuild streams the data of the nodes of a datacenter joining the
+	// cluster, so they do not stream it a first time while bootstrapping
+	if dc.IsRebuildPending() {
+		modelValues["cassandra-yaml"].(serverconfig.NodeConfig)["auto_bootstrap"] = false
+	}
 
 	var modelBytes []byte
 
 	modelBytes, err := json.Marshal(modelValues)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// Combine the model values with the user-specified values
 
 	modelParsed, err := gabs.ParseJSON([]byte(modelBytes))
 	if err != nil {
-		return "", errors.Wrap(err, "Model information for CassandraDatacenter resource was not properly configured")
+		return nil, errors.Wrap(err, "Model information for CassandraDatacenter resource was not properly configured")
 	}
 
 	if dc.Spec.Config != nil {
 		configParsed, err := gabs.ParseJSON([]byte(dc.Spec.Config))
 		if err != nil {
-			return "", errors.Wrap(err, "Error parsing Spec.Config for CassandraDatacenter resource")
+			return nil, errors.Wrap(err, "Error parsing Spec.Config for CassandraDatacenter resource")
 		}
 
 		if err := modelParsed.Merge(configParsed); err != nil {
-			return "", errors.Wrap(err, "Error merging Spec.Config for CassandraDatacenter resource")
+			return nil, errors.Wrap(err, "Error merging Spec.Config for CassandraDatacenter resource")
+		}
+	}
+
+	return modelParsed, nil
+}
+
+// GetConfigAsJSON gets a JSON-encoded string suitable for passing to configBuilder
+func (dc *CassandraDatacenter) GetConfigAsJSON() (string, error) {
+	config, err := dc.getConfig()
+	if err != nil {
+		return "", err
+	}
+	return config.String(), nil
+}
+
+// GetRackConfigAsJSON gets the JSON-encoded config of the servers of a rack,
+// with the config of the rack deep-merged over the one of the datacenter
+func (dc *CassandraDatacenter) GetRackConfigAsJSON(rackName string) (string, error) {
+	config, err := dc.getConfig()
+	if err != nil {
+		return "", err
+	}
+
+	for _, rack := range dc.Spec.Racks {
+		if rack.Name != rackName || rack.Config == nil {
+			continue
+		}
+
+		rackParsed, err := gabs.ParseJSON([]byte(rack.Config))
+		if err != nil {
+			return "", errors.Wrapf(err, "Error parsing config of rack %s for CassandraDatacenter resource", rackName)
+		}
+
+		// The settings of the rack replace the ones of the datacenter instead
+		// of being combined with them
+		err = config.MergeFn(rackParsed, func(destination, source interface{}) interface{} {
+			return source
+		})
+		if err != nil {
+			return "", errors.Wrapf(err, "Error merging config of rack %s for CassandraDatacenter resource", rackName)
 		}
 	}
 
-	return modelParsed.String(), nil
+	return config.String(), nil
 }
 
 // Gets the defined CQL port for NodePort.
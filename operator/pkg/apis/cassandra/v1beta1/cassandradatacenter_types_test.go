@@ -5,6 +5,7 @@ package v1beta1
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/datastax/cass-operator/operator/pkg/images"
@@ -235,6 +236,35 @@ func TestCassandraDatacenter_GetServerImage(t *testing.T) {
 			want:      "",
 			errString: "Unknown server type ''",
 		},
+		{
+			name: "image config registry and repository",
+			fields: fields{
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "dse",
+					ServerVersion: "6.8.4",
+					ImageConfig: &ImageConfig{
+						Registry:     "registry.example.com",
+						Repositories: ImageRepositories{Server: "team/dse"},
+					},
+				},
+			},
+			want:      "registry.example.com/team/dse:6.8.4",
+			errString: "",
+		},
+		{
+			name: "image config does not apply to explicit server image",
+			fields: fields{
+				Spec: CassandraDatacenterSpec{
+					ServerImage:   "jfrog.io:6789/dse-server-team/dse-server:6.8.0-123",
+					ServerVersion: "6.8.0",
+					ImageConfig: &ImageConfig{
+						Registry: "registry.example.com",
+					},
+				},
+			},
+			want:      "jfrog.io:6789/dse-server-team/dse-server:6.8.0-123",
+			errString: "",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -262,6 +292,27 @@ func TestCassandraDatacenter_GetServerImage(t *testing.T) {
 	}
 }
 
+func TestCassandraDatacenter_GetConfigBuilderImage_ImageConfig(t *testing.T) {
+	dc := &CassandraDatacenter{
+		Spec: CassandraDatacenterSpec{
+			ImageConfig: &ImageConfig{
+				Registry:     "registry.example.com",
+				Repositories: ImageRepositories{ConfigBuilder: "team/config-builder"},
+			},
+		},
+	}
+	_, tag := splitTag(images.GetConfigBuilderImage())
+	assert.Equal(t, "registry.example.com/team/config-builder"+tag, dc.GetConfigBuilderImage())
+
+	dc.Spec.ConfigBuilderImage = "example.com/config-builder:1.0.0"
+	assert.Equal(t, "example.com/config-builder:1.0.0", dc.GetConfigBuilderImage())
+}
+
+func splitTag(image string) (string, string) {
+	i := strings.LastIndex(image, ":")
+	return image[:i], image[i:]
+}
+
 func Test_GenerateBaseConfigString(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -283,6 +334,39 @@ func Test_GenerateBaseConfigString(t *testing.T) {
 			want:      `{"cassandra-yaml":{"authenticator":"AllowAllAuthenticator","batch_size_fail_threshold_in_kb":1280},"cluster-info":{"name":"exampleCluster","seeds":"exampleCluster-seed-service"},"datacenter-info":{"graph-enabled":0,"name":"exampleDC","solr-enabled":0,"spark-enabled":0}}`,
 			errString: "",
 		},
+		{
+			name: "Adding datacenter",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName:   "exampleCluster",
+					AddDatacenter: &AddDatacenterConfig{SourceDatacenter: "dc1"},
+				},
+			},
+			want:      `{"cassandra-yaml":{"auto_bootstrap":false},"cluster-info":{"name":"exampleCluster","seeds":"exampleCluster-seed-service"},"datacenter-info":{"graph-enabled":0,"name":"exampleDC","solr-enabled":0,"spark-enabled":0}}`,
+			errString: "",
+		},
+		{
+			name: "Added datacenter",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName:   "exampleCluster",
+					AddDatacenter: &AddDatacenterConfig{SourceDatacenter: "dc1"},
+				},
+				Status: CassandraDatacenterStatus{
+					Conditions: []DatacenterCondition{
+						*NewDatacenterCondition(DatacenterInitialized, corev1.ConditionTrue),
+					},
+				},
+			},
+			want:      `{"cassandra-yaml":{},"cluster-info":{"name":"exampleCluster","seeds":"exampleCluster-seed-service"},"datacenter-info":{"graph-enabled":0,"name":"exampleDC","solr-enabled":0,"spark-enabled":0}}`,
+			errString: "",
+		},
 		{
 			name: "Simple Test for error",
 			dc: &CassandraDatacenter{
@@ -318,6 +402,39 @@ func Test_GenerateBaseConfigString(t *testing.T) {
 	}
 }
 
+func TestCassandraDatacenter_GetRackConfigAsJSON(t *testing.T) {
+	dc := &CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "exampleDC",
+		},
+		Spec: CassandraDatacenterSpec{
+			ClusterName: "exampleCluster",
+			Config:      []byte(`{"cassandra-yaml":{"authenticator":"AllowAllAuthenticator","concurrent_compactors":2},"jvm-options":{"max_heap_size":"2G"}}`),
+			Racks: []Rack{
+				{Name: "r1"},
+				{Name: "r2", Config: []byte(`{"cassandra-yaml":{"concurrent_compactors":8},"jvm-options":{"max_heap_size":"8G","initial_heap_size":"8G"}}`)},
+				{Name: "r3", Config: []byte(`{"cassandra-yaml"}`)},
+			},
+		},
+	}
+
+	dcConfig, err := dc.GetConfigAsJSON()
+	assert.NoError(t, err)
+
+	got, err := dc.GetRackConfigAsJSON("r1")
+	assert.NoError(t, err)
+	assert.Equal(t, dcConfig, got, "a rack without config gets the one of the datacenter")
+
+	got, err = dc.GetRackConfigAsJSON("r2")
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`{"cassandra-yaml":{"authenticator":"AllowAllAuthenticator","concurrent_compactors":8},"cluster-info":{"name":"exampleCluster","seeds":"exampleCluster-seed-service"},"datacenter-info":{"graph-enabled":0,"name":"exampleDC","solr-enabled":0,"spark-enabled":0},"jvm-options":{"initial_heap_size":"8G","max_heap_size":"8G"}}`,
+		got)
+
+	_, err = dc.GetRackConfigAsJSON("r3")
+	assert.EqualError(t, err, "Error parsing config of rack r3 for CassandraDatacenter resource: invalid character '}' after object key")
+}
+
 func TestCassandraDatacenter_GetContainerPorts(t *testing.T) {
 	type fields struct {
 		TypeMeta   metav1.TypeMeta
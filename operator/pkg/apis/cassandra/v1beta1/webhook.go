@@ -52,7 +52,7 @@ func ValidateSingleDatacenter(dc CassandraDatacenter) error {
 
 	isDse := dc.Spec.ServerType == "dse"
 	isCassandra3 := dc.Spec.ServerType == "cassandra" && strings.HasPrefix(dc.Spec.ServerVersion, "3.")
-	isCassandra4 := dc.Spec.ServerType == "cassandra" && strings.HasPrefix(dc.Spec.ServerVersion, "4.")
+	isCassandra4OrLater := dc.Spec.ServerType == "cassandra" && !isCassandra3
 
 	var c map[string]interface{}
 	_ = json.Unmarshal(dc.Spec.Config, &c)
@@ -62,15 +62,49 @@ func ValidateSingleDatacenter(dc CassandraDatacenter) error {
 	_, hasDseYaml := c["dse-yaml"]
 
 	serverStr := fmt.Sprintf("%s-%s", dc.Spec.ServerType, dc.Spec.ServerVersion)
-	if hasJvmOptions && (isDse || isCassandra4) {
+	if hasJvmOptions && (isDse || isCassandra4OrLater) {
 		return attemptedTo("define config jvm-options with %s", serverStr)
 	}
 	if hasJvmServerOptions && isCassandra3 {
 		return attemptedTo("define config jvm-server-options with %s", serverStr)
 	}
-	if hasDseYaml && (isCassandra3 || isCassandra4) {
+	if hasDseYaml && (isCassandra3 || isCassandra4OrLater) {
 		return attemptedTo("define config dse-yaml with %s", serverStr)
 	}
+	if errs := dc.ValidateConfig(); len(errs) > 0 {
+		return attemptedTo("define config not supported by %s: %v", serverStr, errs.ToAggregate())
+	}
+	if !dc.UsesConfigRenderer() && !images.IsConfigBuilderCompatible(dc.Spec.ServerType, dc.Spec.ServerVersion) {
+		return attemptedTo("use the config builder with %s, which it does not support", serverStr)
+	}
+
+	if imageConfig := dc.Spec.ImageConfig; imageConfig != nil {
+		if strings.ContainsAny(strings.TrimSuffix(imageConfig.Registry, "/"), "/@") {
+			return attemptedTo("use image registry '%s', which is not a host with an optional port", imageConfig.Registry)
+		}
+		repositories := imageConfig.Repositories
+		for _, repository := range []string{repositories.Server, repositories.ConfigBuilder,
+			repositories.SystemLogger, repositories.Reaper, repositories.BusyBox} {
+			if strings.ContainsAny(repository, ":@") {
+				return attemptedTo("use image repository '%s' with a registry port, a tag or a digest", repository)
+			}
+		}
+	}
+
+	if dc.IsExternalAccessEnabled() {
+		if dc.IsNodePortEnabled() || dc.IsHostNetworkEnabled() {
+			return attemptedTo("enable externalAccess with nodePort or hostNetwork")
+		}
+		// Only the config files rendered by the operator get the address of
+		// the service of the pod
+		if isDse || !dc.UsesConfigRenderer() {
+			return attemptedTo("enable externalAccess with %s and the config builder", serverStr)
+		}
+	}
+
+	if dc.Spec.AddDatacenter != nil && dc.Spec.AddDatacenter.SourceDatacenter == dc.Name {
+		return attemptedTo("add datacenter %s with itself as the source datacenter", dc.Name)
+	}
 
 	// if using multiple nodes per worker, requests and limits should be set for both cpu and memory
 	if dc.Spec.AllowMultipleNodesPerWorker {
@@ -161,7 +195,7 @@ func ValidateDatacenterFieldChanges(oldDc CassandraDatacenter, newDc CassandraDa
 	return nil
 }
 
-// +kubebuilder:webhook:path=/validate-cassandradatacenter,mutating=false,failurePolicy=ignore,groups=cassandra.datastax.com,resources=cassandradatacenters,verbs=create;update,versions=v1beta1,name=validate-cassandradatacenter-webhook
+// +kubebuilder:webhook:path=/validate-cassandradatacenter,mutating=false,failurePolicy=ignore,groups=cassandra.datastax.com,resources=cassandradatacenters,verbs=create;update;delete,versions=v1beta1,name=validate-cassandradatacenter-webhook
 var _ webhook.Validator = &CassandraDatacenter{}
 
 func (dc *CassandraDatacenter) ValidateCreate() error {
@@ -190,5 +224,10 @@ func (dc *CassandraDatacenter) ValidateUpdate(old runtime.Object) error {
 }
 
 func (dc *CassandraDatacenter) ValidateDelete() error {
+	log.Info("Validating webhook called for delete")
+	if dc.Spec.DeletionProtection {
+		return fmt.Errorf("CassandraDatacenter delete rejected, deletionProtection is enabled on datacenter %s", dc.Name)
+	}
+
 	return nil
 }
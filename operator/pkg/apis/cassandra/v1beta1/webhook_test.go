@@ -12,6 +12,8 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/datastax/cass-operator/operator/pkg/images"
 )
 
 func Test_ValidateSingleDatacenter(t *testing.T) {
@@ -193,6 +195,85 @@ func Test_ValidateSingleDatacenter(t *testing.T) {
 			},
 			errString: "attempted to define config jvm-options with dse-6.8.4",
 		},
+		{
+			name: "Cassandra 5.0 invalid config file jvm-options",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "5.0.0",
+					Config: json.RawMessage(`
+					{
+						"jvm-options": {
+							"max_heap_size": "2G"
+						}
+					}
+					`),
+				},
+			},
+			errString: "attempted to define config jvm-options with cassandra-5.0.0",
+		},
+		{
+			name: "Cassandra 4.1 setting with a value without a unit",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.1.3",
+					Config: json.RawMessage(`
+					{
+						"cassandra-yaml": {
+							"read_request_timeout": "5000"
+						}
+					}
+					`),
+				},
+			},
+			errString: "attempted to define config not supported by cassandra-4.1.3: " +
+				`spec.config.cassandra-yaml.read_request_timeout: Invalid value: "5000": must be of type duration`,
+		},
+		{
+			name: "Cassandra 3.11 unknown cassandra-yaml setting",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.7",
+					Config: json.RawMessage(`
+					{
+						"cassandra-yaml": {
+							"num_token": 16
+						}
+					}
+					`),
+				},
+			},
+			errString: "attempted to define config not supported by cassandra-3.11.7: " +
+				"spec.config.cassandra-yaml.num_token: Forbidden: unknown cassandra-yaml setting for cassandra 3.11",
+		},
+		{
+			name: "Cassandra 4.0 invalid rack config",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Racks: []Rack{
+						{Name: "r1"},
+						{Name: "r2", Config: json.RawMessage(`{"cassandra-yaml": {"concurrent_compactors": "8"}}`)},
+					},
+				},
+			},
+			errString: `spec.racks[1].config.cassandra-yaml.concurrent_compactors: Invalid value: "8": must be of type integer`,
+		},
 		{
 			name: "Allow multiple nodes per worker requires resource requests",
 			dc: &CassandraDatacenter{
@@ -233,6 +314,138 @@ func Test_ValidateSingleDatacenter(t *testing.T) {
 			},
 			errString: "use multiple nodes per worker without cpu and memory requests and limits",
 		},
+		{
+			name: "Add datacenter from itself invalid",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.7",
+					AddDatacenter: &AddDatacenterConfig{
+						SourceDatacenter: "exampleDC",
+					},
+				},
+			},
+			errString: "add datacenter exampleDC with itself as the source datacenter",
+		},
+		{
+			name: "Image config valid",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "dse",
+					ServerVersion: "6.8.4",
+					ImageConfig: &ImageConfig{
+						Registry:     "registry.example.com:5000/",
+						Repositories: ImageRepositories{Server: "team/dse"},
+					},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Image config registry with a path",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "dse",
+					ServerVersion: "6.8.4",
+					ImageConfig: &ImageConfig{
+						Registry: "registry.example.com/team",
+					},
+				},
+			},
+			errString: "use image registry 'registry.example.com/team', which is not a host with an optional port",
+		},
+		{
+			name: "Image config repository with a tag",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "dse",
+					ServerVersion: "6.8.4",
+					ImageConfig: &ImageConfig{
+						Repositories: ImageRepositories{Reaper: "team/reaper:2.0.5"},
+					},
+				},
+			},
+			errString: "use image repository 'team/reaper:2.0.5' with a registry port, a tag or a digest",
+		},
+		{
+			name: "External access valid",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Networking: &NetworkingConfig{
+						ExternalAccess: &ExternalAccessConfig{
+							LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+						},
+					},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "External access with a NodePort service",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Networking: &NetworkingConfig{
+						NodePort:       &NodePortConfig{Native: 30042},
+						ExternalAccess: &ExternalAccessConfig{},
+					},
+				},
+			},
+			errString: "enable externalAccess with nodePort or hostNetwork",
+		},
+		{
+			name: "External access with DSE",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "dse",
+					ServerVersion: "6.8.4",
+					Networking: &NetworkingConfig{
+						ExternalAccess: &ExternalAccessConfig{},
+					},
+				},
+			},
+			errString: "enable externalAccess with dse-6.8.4 and the config builder",
+		},
+		{
+			name: "External access with the config builder",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.7",
+					Networking: &NetworkingConfig{
+						ExternalAccess: &ExternalAccessConfig{},
+					},
+				},
+			},
+			errString: "enable externalAccess with cassandra-3.11.7 and the config builder",
+		},
 	}
 
 	for _, tt := range tests {
@@ -251,6 +464,49 @@ func Test_ValidateSingleDatacenter(t *testing.T) {
 	}
 }
 
+func Test_ValidateSingleDatacenter_ImageCatalog(t *testing.T) {
+	catalog, err := images.ParseCatalog([]byte(`
+servers:
+- serverType: cassandra
+  version: 4.2.0
+  image: example.com/cassandra:4.2.0
+  configBuilder: false
+`))
+	if err != nil {
+		t.Fatalf("ParseCatalog() err = %v", err)
+	}
+
+	dc := CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "exampleDC",
+		},
+		Spec: CassandraDatacenterSpec{
+			ServerType:    "cassandra",
+			ServerVersion: "4.2.0",
+		},
+	}
+
+	want := "use unsupported Cassandra version '4.2.0'"
+	if err := ValidateSingleDatacenter(dc); err == nil || !strings.HasSuffix(err.Error(), want) {
+		t.Errorf("ValidateSingleDatacenter() err = %v, want suffix %v", err, want)
+	}
+
+	if err := images.SetCatalog(catalog); err != nil {
+		t.Fatalf("SetCatalog() err = %v", err)
+	}
+	defer images.SetCatalog(nil)
+
+	if err := ValidateSingleDatacenter(dc); err != nil {
+		t.Errorf("ValidateSingleDatacenter() err = %v, want nil", err)
+	}
+
+	dc.Spec.ConfigBuilderImage = "datastax/cass-config-builder:1.0.3"
+	want = "use the config builder with cassandra-4.2.0, which it does not support"
+	if err := ValidateSingleDatacenter(dc); err == nil || !strings.HasSuffix(err.Error(), want) {
+		t.Errorf("ValidateSingleDatacenter() err = %v, want suffix %v", err, want)
+	}
+}
+
 func Test_ValidateDatacenterFieldChanges(t *testing.T) {
 	storageSize := resource.MustParse("1Gi")
 	storageName := "server-data"
@@ -758,3 +1014,20 @@ func Test_ValidateDatacenterFieldChanges(t *testing.T) {
 		})
 	}
 }
+
+func Test_ValidateDelete(t *testing.T) {
+	dc := &CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "exampleDC",
+		},
+	}
+	if err := dc.ValidateDelete(); err != nil {
+		t.Errorf("ValidateDelete() err = %v, want nil", err)
+	}
+
+	dc.Spec.DeletionProtection = true
+	err := dc.ValidateDelete()
+	if err == nil || !strings.HasSuffix(err.Error(), "deletionProtection is enabled on datacenter exampleDC") {
+		t.Errorf("ValidateDelete() err = %v, want deletionProtection error", err)
+	}
+}
@@ -11,6 +11,27 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddDatacenterConfig) DeepCopyInto(out *AddDatacenterConfig) {
+	*out = *in
+	if in.Keyspaces != nil {
+		in, out := &in.Keyspaces, &out.Keyspaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddDatacenterConfig.
+func (in *AddDatacenterConfig) DeepCopy() *AddDatacenterConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AddDatacenterConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AdditionalVolumes) DeepCopyInto(out *AdditionalVolumes) {
 	*out = *in
@@ -50,6 +71,22 @@ func (in AdditionalVolumesSlice) DeepCopy() AdditionalVolumesSlice {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoReplaceConfig) DeepCopyInto(out *AutoReplaceConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoReplaceConfig.
+func (in *AutoReplaceConfig) DeepCopy() *AutoReplaceConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoReplaceConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CassandraDatacenter) DeepCopyInto(out *CassandraDatacenter) {
 	*out = *in
@@ -114,6 +151,11 @@ func (in *CassandraDatacenterList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CassandraDatacenterSpec) DeepCopyInto(out *CassandraDatacenterSpec) {
 	*out = *in
+	if in.ImageConfig != nil {
+		in, out := &in.ImageConfig, &out.ImageConfig
+		*out = new(ImageConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.DockerImageRunsAsCassandra != nil {
 		in, out := &in.DockerImageRunsAsCassandra, &out.DockerImageRunsAsCassandra
 		*out = new(bool)
@@ -185,6 +227,21 @@ func (in *CassandraDatacenterSpec) DeepCopyInto(out *CassandraDatacenterSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.AddDatacenter != nil {
+		in, out := &in.AddDatacenter, &out.AddDatacenter
+		*out = new(AddDatacenterConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AutoReplace != nil {
+		in, out := &in.AutoReplace, &out.AutoReplace
+		*out = new(AutoReplaceConfig)
+		**out = **in
+	}
+	if in.NodeMaintenance != nil {
+		in, out := &in.NodeMaintenance, &out.NodeMaintenance
+		*out = new(NodeMaintenanceConfig)
+		**out = **in
+	}
 	if in.Reaper != nil {
 		in, out := &in.Reaper, &out.Reaper
 		*out = new(ReaperConfig)
@@ -222,7 +279,7 @@ func (in *CassandraDatacenterStatus) DeepCopyInto(out *CassandraDatacenterStatus
 		in, out := &in.NodeStatuses, &out.NodeStatuses
 		*out = make(CassandraStatusMap, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 	}
 	if in.NodeReplacements != nil {
@@ -231,6 +288,36 @@ func (in *CassandraDatacenterStatus) DeepCopyInto(out *CassandraDatacenterStatus
 		copy(*out, *in)
 	}
 	in.QuietPeriod.DeepCopyInto(&out.QuietPeriod)
+	if in.HotAppliedConfig != nil {
+		in, out := &in.HotAppliedConfig, &out.HotAppliedConfig
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.ImageDigests != nil {
+		in, out := &in.ImageDigests, &out.ImageDigests
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
 	return
 }
 
@@ -247,6 +334,11 @@ func (in *CassandraDatacenterStatus) DeepCopy() *CassandraDatacenterStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CassandraNodeStatus) DeepCopyInto(out *CassandraNodeStatus) {
 	*out = *in
+	if in.Tokens != nil {
+		in, out := &in.Tokens, &out.Tokens
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -266,7 +358,7 @@ func (in CassandraStatusMap) DeepCopyInto(out *CassandraStatusMap) {
 		in := &in
 		*out = make(CassandraStatusMap, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 		return
 	}
@@ -331,6 +423,65 @@ func (in *DseWorkloads) DeepCopy() *DseWorkloads {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalAccessConfig) DeepCopyInto(out *ExternalAccessConfig) {
+	*out = *in
+	if in.LoadBalancerSourceRanges != nil {
+		in, out := &in.LoadBalancerSourceRanges, &out.LoadBalancerSourceRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalAccessConfig.
+func (in *ExternalAccessConfig) DeepCopy() *ExternalAccessConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalAccessConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageConfig) DeepCopyInto(out *ImageConfig) {
+	*out = *in
+	out.Repositories = in.Repositories
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageConfig.
+func (in *ImageConfig) DeepCopy() *ImageConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageRepositories) DeepCopyInto(out *ImageRepositories) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageRepositories.
+func (in *ImageRepositories) DeepCopy() *ImageRepositories {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageRepositories)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ManagementApiAuthConfig) DeepCopyInto(out *ManagementApiAuthConfig) {
 	*out = *in
@@ -397,6 +548,11 @@ func (in *NetworkingConfig) DeepCopyInto(out *NetworkingConfig) {
 		*out = new(NodePortConfig)
 		**out = **in
 	}
+	if in.ExternalAccess != nil {
+		in, out := &in.ExternalAccess, &out.ExternalAccess
+		*out = new(ExternalAccessConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -410,6 +566,22 @@ func (in *NetworkingConfig) DeepCopy() *NetworkingConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeMaintenanceConfig) DeepCopyInto(out *NodeMaintenanceConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeMaintenanceConfig.
+func (in *NodeMaintenanceConfig) DeepCopy() *NodeMaintenanceConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeMaintenanceConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodePortConfig) DeepCopyInto(out *NodePortConfig) {
 	*out = *in
@@ -436,6 +608,30 @@ func (in *Rack) DeepCopyInto(out *Rack) {
 			(*out)[key] = val
 		}
 	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = make(json.RawMessage, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -474,6 +670,7 @@ func (in *ServiceConfig) DeepCopyInto(out *ServiceConfig) {
 	in.AllPodsService.DeepCopyInto(&out.AllPodsService)
 	in.AdditionalSeedService.DeepCopyInto(&out.AdditionalSeedService)
 	in.NodePortService.DeepCopyInto(&out.NodePortService)
+	in.PodService.DeepCopyInto(&out.PodService)
 	return
 }
 
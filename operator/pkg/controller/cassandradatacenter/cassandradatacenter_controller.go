@@ -4,6 +4,7 @@
 package cassandradatacenter
 
 import (
+	"context"
 	"fmt"
 
 	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
@@ -20,7 +21,9 @@ import (
 
 	"github.com/datastax/cass-operator/operator/pkg/reconciliation"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	types "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -36,6 +39,12 @@ func Add(mgr manager.Manager) error {
 	return add(mgr, reconciliation.NewReconciler(mgr))
 }
 
+// AddWithReconciler adds the CassandraDatacenter Controller to the Manager
+// with a reconciler that the caller has already set up
+func AddWithReconciler(mgr manager.Manager, r *reconciliation.ReconcileCassandraDatacenter) error {
+	return add(mgr, r)
+}
+
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
@@ -120,7 +129,16 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
-	// Setup watches for Nodes to check for taints being added
+	// Setup watches for the seed pods and readiness of the other datacenters of
+	// a cluster, which are published to each datacenter as additional seeds and
+	// decide when a new datacenter may bootstrap.
+
+	if err := addPeerDatacenterWatches(c, mgr.GetClient()); err != nil {
+		return err
+	}
+
+	// Setup watches for Nodes to check for taints and cordons being added.
+	// Only the datacenters with node maintenance enabled are mapped to nodes.
 
 	nodeMapFn := handler.ToRequestsFunc(
 		func(a handler.MapObject) []reconcile.Request {
@@ -164,22 +182,20 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 				return true
 			}
 
-			return !utils.ElementsMatch(
-				nodeOld.Spec.Taints, nodeNew.Spec.Taints)
+			return nodeOld.Spec.Unschedulable != nodeNew.Spec.Unschedulable ||
+				!utils.ElementsMatch(nodeOld.Spec.Taints, nodeNew.Spec.Taints)
 		},
 	}
 
-	if utils.IsPSPEnabled() {
-		err = c.Watch(
-			&source.Kind{Type: &corev1.Node{}},
-			&handler.EnqueueRequestsFromMapFunc{
-				ToRequests: nodeMapFn,
-			},
-			nodeTaintsChangedPredicate,
-		)
-		if err != nil {
-			return err
-		}
+	err = c.Watch(
+		&source.Kind{Type: &corev1.Node{}},
+		&handler.EnqueueRequestsFromMapFunc{
+			ToRequests: nodeMapFn,
+		},
+		nodeTaintsChangedPredicate,
+	)
+	if err != nil {
+		return err
 	}
 
 	// Setup watches for pvc to check for taints being added
@@ -259,5 +275,116 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	return nil
 }
 
+// peerRequests returns reconcile requests for every datacenter of the cluster
+// except the one in namespace/dcName
+func peerRequests(cl client.Client, clusterName, namespace, dcName string) []reconcile.Request {
+	requests := []reconcile.Request{}
+	if clusterName == "" {
+		return requests
+	}
+
+	dcList := &api.CassandraDatacenterList{}
+	err := cl.List(context.Background(), dcList, client.MatchingLabels{api.ClusterLabel: clusterName})
+	if err != nil {
+		log.Error(err, "Could not list datacenters for peer watch")
+		return requests
+	}
+
+	for _, dc := range dcList.Items {
+		if dc.Namespace == namespace && dc.Name == dcName {
+			continue
+		}
+
+		log.Info("peer watch adding reconciliation request",
+			"cassandraDatacenter", dc.Name,
+			"namespace", dc.Namespace)
+
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      dc.Name,
+				Namespace: dc.Namespace,
+			}},
+		)
+	}
+	return requests
+}
+
+func isSeedPod(meta metav1.Object) bool {
+	return meta.GetLabels()[api.SeedNodeLabel] == "true"
+}
+
+func addPeerDatacenterWatches(c controller.Controller, cl client.Client) error {
+	seedPodMapFn := handler.ToRequestsFunc(
+		func(a handler.MapObject) []reconcile.Request {
+			labels := a.Meta.GetLabels()
+			return peerRequests(cl, labels[api.ClusterLabel], a.Meta.GetNamespace(), labels[api.DatacenterLabel])
+		})
+
+	seedPodsChangedPredicate := predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return isSeedPod(e.Meta)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return isSeedPod(e.Meta)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if isSeedPod(e.MetaOld) != isSeedPod(e.MetaNew) {
+				return true
+			}
+			podOld, ok1 := e.ObjectOld.(*corev1.Pod)
+			podNew, ok2 := e.ObjectNew.(*corev1.Pod)
+			if !ok1 || !ok2 {
+				return false
+			}
+			return isSeedPod(e.MetaNew) && podOld.Status.PodIP != podNew.Status.PodIP
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+
+	err := c.Watch(
+		&source.Kind{Type: &corev1.Pod{}},
+		&handler.EnqueueRequestsFromMapFunc{
+			ToRequests: seedPodMapFn,
+		},
+		seedPodsChangedPredicate,
+	)
+	if err != nil {
+		return err
+	}
+
+	peerMapFn := handler.ToRequestsFunc(
+		func(a handler.MapObject) []reconcile.Request {
+			dc, ok := a.Object.(*api.CassandraDatacenter)
+			if !ok {
+				return []reconcile.Request{}
+			}
+			return peerRequests(cl, dc.Spec.ClusterName, dc.Namespace, dc.Name)
+		})
+
+	peerReadinessChangedPredicate := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			dcOld, ok1 := e.ObjectOld.(*api.CassandraDatacenter)
+			dcNew, ok2 := e.ObjectNew.(*api.CassandraDatacenter)
+			if !ok1 || !ok2 {
+				return false
+			}
+			return dcOld.GetConditionStatus(api.DatacenterReady) != dcNew.GetConditionStatus(api.DatacenterReady)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+
+	return c.Watch(
+		&source.Kind{Type: &api.CassandraDatacenter{}},
+		&handler.EnqueueRequestsFromMapFunc{
+			ToRequests: peerMapFn,
+		},
+		peerReadinessChangedPredicate,
+	)
+}
+
 // blank assignment to verify that ReconcileCassandraDatacenter implements reconciliation.Reconciler
 var _ reconcile.Reconciler = &reconciliation.ReconcileCassandraDatacenter{}
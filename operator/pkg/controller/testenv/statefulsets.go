@@ -0,0 +1,320 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package testenv
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/httphelper/fakemgmtapi"
+)
+
+const (
+	// Pods record the generation of the StatefulSet they were created from
+	revisionLabel = "controller-revision-hash"
+
+	// How often pod statuses are refreshed from the fake Management API,
+	// which does not generate any Kubernetes events of its own
+	resyncPeriod = time.Second
+)
+
+// statefulSetSimulator stands in for the StatefulSet controller and the
+// kubelet, neither of which run in envtest. It creates the pods and volume
+// claims of every StatefulSet, replaces pods whose template is out of date,
+// and reports a pod as ready once Cassandra is up in the fake Management API.
+type statefulSetSimulator struct {
+	client  client.Client
+	mgmtApi *fakemgmtapi.Server
+
+	mu     sync.Mutex
+	podIPs map[types.NamespacedName]string
+}
+
+func addStatefulSetSimulator(mgr manager.Manager, mgmtApi *fakemgmtapi.Server) error {
+	sim := &statefulSetSimulator{
+		client:  mgr.GetClient(),
+		mgmtApi: mgmtApi,
+		podIPs:  map[types.NamespacedName]string{},
+	}
+
+	c, err := controller.New("statefulset-simulator", mgr, controller.Options{Reconciler: sim})
+	if err != nil {
+		return err
+	}
+
+	err = c.Watch(&source.Kind{Type: &appsv1.StatefulSet{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	return c.Watch(
+		&source.Kind{Type: &corev1.Pod{}},
+		&handler.EnqueueRequestForOwner{
+			IsController: true,
+			OwnerType:    &appsv1.StatefulSet{},
+		})
+}
+
+// podIP returns a stable IP for the pod, so that a pod that is deleted and
+// recreated comes back as the same node in the fake ring
+func (sim *statefulSetSimulator) podIP(key types.NamespacedName) string {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+
+	ip, ok := sim.podIPs[key]
+	if !ok {
+		n := len(sim.podIPs) + 1
+		ip = fmt.Sprintf("10.%d.%d.%d", n/65536%256, n/256%256, n%256)
+		sim.podIPs[key] = ip
+	}
+	return ip
+}
+
+func (sim *statefulSetSimulator) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ctx := context.Background()
+
+	sts := &appsv1.StatefulSet{}
+	if err := sim.client.Get(ctx, request.NamespacedName, sts); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	revision := strconv.FormatInt(sts.Generation, 10)
+
+	status := appsv1.StatefulSetStatus{
+		ObservedGeneration: sts.Generation,
+		CurrentRevision:    revision,
+		UpdateRevision:     revision,
+	}
+
+	// Like the real controller, only one out of date pod is replaced at a
+	// time, and only once every other pod is ready
+	replacing := false
+
+	for i := int32(0); i < replicas; i++ {
+		pod, err := sim.reconcilePod(ctx, sts, i, revision)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+
+		status.Replicas++
+		if pod.Labels[revisionLabel] == revision {
+			status.CurrentReplicas++
+			status.UpdatedReplicas++
+		} else if !replacing {
+			replacing = true
+			if err := sim.client.Delete(ctx, pod); err != nil && !errors.IsNotFound(err) {
+				return reconcile.Result{}, err
+			}
+			continue
+		}
+		if isPodReady(pod) {
+			status.ReadyReplicas++
+		}
+	}
+
+	if err := sim.removeExtraPods(ctx, sts, replicas); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	sts.Status = status
+	if err := sim.client.Status().Update(ctx, sts); err != nil && !errors.IsConflict(err) {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{RequeueAfter: resyncPeriod}, nil
+}
+
+func (sim *statefulSetSimulator) reconcilePod(ctx context.Context, sts *appsv1.StatefulSet, ordinal int32, revision string) (*corev1.Pod, error) {
+	key := types.NamespacedName{
+		Name:      fmt.Sprintf("%s-%d", sts.Name, ordinal),
+		Namespace: sts.Namespace,
+	}
+	ip := sim.podIP(key)
+
+	pod := &corev1.Pod{}
+	err := sim.client.Get(ctx, key, pod)
+	if errors.IsNotFound(err) {
+		// Whatever ran on the old pod is gone
+		sim.mgmtApi.StopNode(ip)
+
+		if err := sim.createClaims(ctx, sts, key.Name); err != nil {
+			return nil, err
+		}
+		pod = newPodForStatefulSet(sts, key, revision)
+		if err := sim.client.Create(ctx, pod); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if pod.Status.PodIP == "" {
+		sim.mgmtApi.AddNode(ip)
+		sim.mgmtApi.SetDatacenter(ip, pod.Labels[api.DatacenterLabel])
+	}
+
+	node, _ := sim.mgmtApi.Node(ip)
+	ready := node.Started && node.Status == fakemgmtapi.StatusNormal
+
+	if pod.Status.PodIP == ip && isPodReady(pod) == ready {
+		return pod, nil
+	}
+
+	pod.Status = podStatus(pod, ip, ready)
+	if err := sim.client.Status().Update(ctx, pod); err != nil {
+		return nil, err
+	}
+	return pod, nil
+}
+
+func (sim *statefulSetSimulator) createClaims(ctx context.Context, sts *appsv1.StatefulSet, podName string) error {
+	for _, template := range sts.Spec.VolumeClaimTemplates {
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        template.Name + "-" + podName,
+				Namespace:   sts.Namespace,
+				Labels:      template.Labels,
+				Annotations: template.Annotations,
+			},
+			Spec: template.Spec,
+		}
+		if err := sim.client.Create(ctx, pvc); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sim *statefulSetSimulator) removeExtraPods(ctx context.Context, sts *appsv1.StatefulSet, replicas int32) error {
+	pods := &corev1.PodList{}
+	if err := sim.client.List(ctx, pods, client.InNamespace(sts.Namespace)); err != nil {
+		return err
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		owner := metav1.GetControllerOf(pod)
+		if owner == nil || owner.UID != sts.UID {
+			continue
+		}
+
+		ordinal, err := strconv.Atoi(pod.Name[len(sts.Name)+1:])
+		if err != nil || int32(ordinal) < replicas {
+			continue
+		}
+
+		sim.mgmtApi.StopNode(pod.Status.PodIP)
+		if err := sim.client.Delete(ctx, pod); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func newPodForStatefulSet(sts *appsv1.StatefulSet, key types.NamespacedName, revision string) *corev1.Pod {
+	labels := map[string]string{}
+	for k, v := range sts.Spec.Template.Labels {
+		labels[k] = v
+	}
+	labels[revisionLabel] = revision
+	labels["statefulset.kubernetes.io/pod-name"] = key.Name
+
+	isController := true
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        key.Name,
+			Namespace:   key.Namespace,
+			Labels:      labels,
+			Annotations: sts.Spec.Template.Annotations,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "StatefulSet",
+				Name:       sts.Name,
+				UID:        sts.UID,
+				Controller: &isController,
+			}},
+		},
+		Spec: *sts.Spec.Template.Spec.DeepCopy(),
+	}
+
+	for _, template := range sts.Spec.VolumeClaimTemplates {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name: template.Name,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: template.Name + "-" + key.Name,
+				},
+			},
+		})
+	}
+
+	return pod
+}
+
+func podStatus(pod *corev1.Pod, ip string, ready bool) corev1.PodStatus {
+	// Started long enough ago for the operator to consider the Management
+	// API up
+	startedAt := metav1.NewTime(time.Now().Add(-time.Minute))
+
+	readyCondition := corev1.ConditionFalse
+	if ready {
+		readyCondition = corev1.ConditionTrue
+	}
+
+	status := corev1.PodStatus{
+		Phase:     corev1.PodRunning,
+		PodIP:     ip,
+		StartTime: &startedAt,
+		Conditions: []corev1.PodCondition{
+			{Type: corev1.PodScheduled, Status: corev1.ConditionTrue},
+			{Type: corev1.ContainersReady, Status: readyCondition},
+			{Type: corev1.PodReady, Status: readyCondition},
+		},
+	}
+
+	for _, container := range pod.Spec.Containers {
+		status.ContainerStatuses = append(status.ContainerStatuses, corev1.ContainerStatus{
+			Name:  container.Name,
+			Image: container.Image,
+			Ready: ready || container.Name != "cassandra",
+			State: corev1.ContainerState{
+				Running: &corev1.ContainerStateRunning{StartedAt: startedAt},
+			},
+		})
+	}
+
+	return status
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
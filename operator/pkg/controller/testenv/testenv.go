@@ -0,0 +1,173 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+// Package testenv runs the CassandraDatacenter controller against a real
+// API server and etcd started by envtest, so that tests exercise status
+// subresources, finalizers, owner references and watches end to end.
+//
+// Nothing in envtest runs pods, so StatefulSets are simulated, and the
+// Management API of every pod is served by a fakemgmtapi.Server.
+//
+// The control plane binaries are looked up the way envtest does it, from
+// KUBEBUILDER_ASSETS or /usr/local/kubebuilder/bin. Tests should skip
+// themselves when Available returns false.
+package testenv
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/datastax/cass-operator/operator/pkg/apis"
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/controller/cassandradatacenter"
+	"github.com/datastax/cass-operator/operator/pkg/httphelper/fakemgmtapi"
+	"github.com/datastax/cass-operator/operator/pkg/reconciliation"
+)
+
+const defaultAssetsDir = "/usr/local/kubebuilder/bin"
+
+// Available reports whether the envtest control plane binaries can be found,
+// or whether an existing cluster should be used instead
+func Available() bool {
+	if os.Getenv("USE_EXISTING_CLUSTER") == "true" {
+		return true
+	}
+
+	dir := os.Getenv("KUBEBUILDER_ASSETS")
+	if dir == "" {
+		dir = defaultAssetsDir
+	}
+	for _, binary := range []string{"kube-apiserver", "etcd"} {
+		if _, err := os.Stat(filepath.Join(dir, binary)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Environment is a running control plane with the operator's manager
+type Environment struct {
+	Config *rest.Config
+
+	// Client talks to the API server directly, without a cache, so tests see
+	// their own writes right away
+	Client client.Client
+
+	// MgmtApi serves the Management API of every simulated pod
+	MgmtApi *fakemgmtapi.Server
+
+	testEnv *envtest.Environment
+	stop    chan struct{}
+}
+
+func crdDirectory() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "..", "deploy", "crds")
+}
+
+// Start starts the control plane, installs the CassandraDatacenter CRD and
+// runs the manager until Stop is called
+func Start() (*Environment, error) {
+	env := &Environment{
+		MgmtApi: fakemgmtapi.NewServer(),
+		testEnv: &envtest.Environment{
+			CRDDirectoryPaths:     []string{crdDirectory()},
+			ErrorIfCRDPathMissing: true,
+		},
+		stop: make(chan struct{}),
+	}
+
+	if err := apis.AddToScheme(scheme.Scheme); err != nil {
+		return nil, err
+	}
+
+	cfg, err := env.testEnv.Start()
+	if err != nil {
+		return nil, fmt.Errorf("could not start envtest: %v", err)
+	}
+	env.Config = cfg
+
+	if err := env.startManager(); err != nil {
+		_ = env.testEnv.Stop()
+		return nil, err
+	}
+
+	return env, nil
+}
+
+func (env *Environment) startManager() error {
+	mgr, err := manager.New(env.Config, manager.Options{
+		Scheme:             scheme.Scheme,
+		MetricsBindAddress: "0",
+	})
+	if err != nil {
+		return err
+	}
+
+	r := reconciliation.NewReconciler(mgr).(*reconciliation.ReconcileCassandraDatacenter)
+	r.NodeMgmtHttpClient = env.MgmtApi
+	if err := cassandradatacenter.AddWithReconciler(mgr, r); err != nil {
+		return err
+	}
+
+	if err := addStatefulSetSimulator(mgr, env.MgmtApi); err != nil {
+		return err
+	}
+
+	env.Client, err = client.New(env.Config, client.Options{Scheme: scheme.Scheme})
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := mgr.Start(env.stop); err != nil {
+			panic(fmt.Sprintf("manager exited with an error: %v", err))
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops the manager and then the control plane
+func (env *Environment) Stop() error {
+	close(env.stop)
+	return env.testEnv.Stop()
+}
+
+// WaitForDatacenter polls the datacenter until check returns true, and
+// returns the last version it read
+func (env *Environment) WaitForDatacenter(key types.NamespacedName, timeout time.Duration, check func(*api.CassandraDatacenter) bool) (*api.CassandraDatacenter, error) {
+	dc := &api.CassandraDatacenter{}
+	err := wait.PollImmediate(time.Second, timeout, func() (bool, error) {
+		if err := env.Client.Get(context.Background(), key, dc); err != nil {
+			return false, client.IgnoreNotFound(err)
+		}
+		return check(dc), nil
+	})
+	if err != nil {
+		return dc, fmt.Errorf("datacenter %s did not reach the expected state: %v", key, err)
+	}
+	return dc, nil
+}
+
+// WaitForCondition waits until the datacenter reports the condition with
+// the given status
+func (env *Environment) WaitForCondition(key types.NamespacedName, conditionType api.DatacenterConditionType, status corev1.ConditionStatus, timeout time.Duration) error {
+	_, err := env.WaitForDatacenter(key, timeout, func(dc *api.CassandraDatacenter) bool {
+		return dc.GetConditionStatus(conditionType) == status
+	})
+	return err
+}
@@ -0,0 +1,87 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package testenv
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+const timeout = 3 * time.Minute
+
+var env *Environment
+
+func TestMain(m *testing.M) {
+	if !Available() {
+		fmt.Println("skipping envtest tests, the control plane binaries are not installed")
+		os.Exit(0)
+	}
+
+	var err error
+	env, err = Start()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	if err := env.Stop(); err != nil {
+		fmt.Println(err)
+	}
+	os.Exit(code)
+}
+
+func newDatacenter(name string, size int32) *api.CassandraDatacenter {
+	storageClassName := "standard"
+	return &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "cluster1",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.7",
+			Size:          size,
+			StorageConfig: api.StorageConfig{
+				CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+					StorageClassName: &storageClassName,
+					AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{"storage": resource.MustParse("1Gi")},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDatacenterBecomesReady(t *testing.T) {
+	dc := newDatacenter("dc1", 2)
+	key := types.NamespacedName{Name: dc.Name, Namespace: dc.Namespace}
+	assert.NoError(t, env.Client.Create(context.Background(), dc))
+
+	assert.NoError(t, env.WaitForCondition(key, api.DatacenterReady, corev1.ConditionTrue, timeout))
+
+	dc, err := env.WaitForDatacenter(key, timeout, func(dc *api.CassandraDatacenter) bool {
+		return dc.Status.CassandraOperatorProgress == api.ProgressReady
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(dc.Status.NodeStatuses))
+	assert.Contains(t, dc.GetFinalizers(), "finalizer.cassandra.datastax.com")
+
+	roles := env.MgmtApi.Roles()
+	assert.Contains(t, roles, "cluster1-superuser")
+}
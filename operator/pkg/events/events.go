@@ -29,6 +29,20 @@ const (
 	ReplacingNode                     string = "ReplacingNode"
 	StartingCassandraAndReplacingNode string = "StartingCassandraAndReplacingNode"
 	StartingCassandra                 string = "StartingCassandra"
+	SubmittedJob                      string = "SubmittedJob"
+	JobFailed                         string = "JobFailed"
+	WaitingForDatacenter              string = "WaitingForDatacenter"
+	RebuiltDatacenter                 string = "RebuiltDatacenter"
+	UpdatedReplication                string = "UpdatedReplication"
+	DecommissioningDatacenter         string = "DecommissioningDatacenter"
+	DecommissionedDatacenter          string = "DecommissionedDatacenter"
+	RetainedPersistentVolumeClaims    string = "RetainedPersistentVolumeClaims"
+	AdoptedPersistentVolumeClaims     string = "AdoptedPersistentVolumeClaims"
+	DeletionProtected                 string = "DeletionProtected"
+	ReplacingFailedNode               string = "ReplacingFailedNode"
+	HotAppliedConfig                  string = "HotAppliedConfig"
+	DeletedResource                   string = "DeletedResource"
+	UnsupportedConfig                 string = "UnsupportedConfig"
 )
 
 type LoggingEventRecorder struct {
@@ -6,6 +6,8 @@ package httphelper
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -24,14 +26,28 @@ type NodeMgmtClient struct {
 	Client   HttpClient
 	Log      logr.Logger
 	Protocol string
+
+	// Retry policy of the idempotent calls, DefaultRetryPolicy when nil
+	RetryPolicy *RetryPolicy
+
+	// Timeout of every call when set, replacing the timeout of the endpoint
+	Timeout time.Duration
+
+	// Context the calls are made in, such as the one of an admission
+	// request. Background when nil.
+	Ctx context.Context
+
+	// Shared per-pod circuit breakers. Nil disables circuit breaking.
+	Breakers *CircuitBreakers
 }
 
 type nodeMgmtRequest struct {
-	endpoint string
-	host     string
-	method   string
-	timeout  time.Duration
-	body     []byte
+	endpoint   string
+	host       string
+	method     string
+	timeout    time.Duration
+	body       []byte
+	idempotent bool
 }
 
 func buildEndpoint(path string, queryParams ...string) string {
@@ -48,12 +64,16 @@ func buildEndpoint(path string, queryParams ...string) string {
 }
 
 type EndpointState struct {
+	Datacenter             string `json:"DC"`
+	Rack                   string `json:"RACK"`
 	HostID                 string `json:"HOST_ID"`
+	EndpointIP             string `json:"ENDPOINT_IP"`
 	IsAlive                string `json:"IS_ALIVE"`
 	NativeTransportAddress string `json:"NATIVE_TRANSPORT_ADDRESS"`
 	RpcAddress             string `json:"RPC_ADDRESS"`
 	Status                 string `json:"STATUS"`
 	Load                   string `json:"LOAD"`
+	Tokens                 string `json:"TOKENS"`
 }
 
 func (x *EndpointState) GetRpcAddress() string {
@@ -64,6 +84,48 @@ func (x *EndpointState) GetRpcAddress() string {
 	}
 }
 
+// GetEndpointAddress returns the address the node is known by in gossip,
+// which differs from its RPC address when it broadcasts another one to
+// clients
+func (x *EndpointState) GetEndpointAddress() string {
+	if x.EndpointIP != "" {
+		return x.EndpointIP
+	}
+	return x.GetRpcAddress()
+}
+
+// GetTokens decodes the tokens of the endpoint. They are reported in their
+// gossip serialization, the size of each token followed by its bytes and a
+// final zero size, with every byte as a character.
+func (x *EndpointState) GetTokens() []string {
+	data := []byte{}
+	for _, r := range x.Tokens {
+		if r > 0xff {
+			return nil
+		}
+		data = append(data, byte(r))
+	}
+
+	tokens := []string{}
+	for len(data) >= 4 {
+		size := int(binary.BigEndian.Uint32(data))
+		data = data[4:]
+		if size == 0 || size > len(data) {
+			break
+		}
+
+		token := data[:size]
+		data = data[size:]
+		if size == 8 {
+			// Murmur3Partitioner tokens are longs
+			tokens = append(tokens, strconv.FormatInt(int64(binary.BigEndian.Uint64(token)), 10))
+		} else {
+			tokens = append(tokens, hex.EncodeToString(token))
+		}
+	}
+	return tokens
+}
+
 type CassMetadataEndpoints struct {
 	Entity []EndpointState `json:"entity"`
 }
@@ -109,9 +171,10 @@ func (client *NodeMgmtClient) CallMetadataEndpointsEndpoint(pod *corev1.Pod) (Ca
 	}
 
 	request := nodeMgmtRequest{
-		endpoint: "/api/v0/metadata/endpoints",
-		host:     podHost,
-		method:   http.MethodGet,
+		endpoint:   "/api/v0/metadata/endpoints",
+		host:       podHost,
+		method:     http.MethodGet,
+		idempotent: true,
 	}
 
 	bytes, err := callNodeMgmtEndpoint(client, request, "")
@@ -167,9 +230,10 @@ func (client *NodeMgmtClient) CallProbeClusterEndpoint(pod *corev1.Pod, consiste
 	}
 
 	request := nodeMgmtRequest{
-		endpoint: fmt.Sprintf("/api/v0/probes/cluster?consistency_level=%s&rf_per_dc=%d", consistencyLevel, rfPerDc),
-		host:     podHost,
-		method:   http.MethodGet,
+		endpoint:   fmt.Sprintf("/api/v0/probes/cluster?consistency_level=%s&rf_per_dc=%d", consistencyLevel, rfPerDc),
+		host:       podHost,
+		method:     http.MethodGet,
+		idempotent: true,
 	}
 
 	_, err = callNodeMgmtEndpoint(client, request, "")
@@ -283,9 +347,10 @@ func (client *NodeMgmtClient) CallReloadSeedsEndpoint(pod *corev1.Pod) error {
 	}
 
 	request := nodeMgmtRequest{
-		endpoint: "/api/v0/ops/seeds/reload",
-		host:     podHost,
-		method:   http.MethodPost,
+		endpoint:   "/api/v0/ops/seeds/reload",
+		host:       podHost,
+		method:     http.MethodPost,
+		idempotent: true,
 	}
 
 	_, err = callNodeMgmtEndpoint(client, request, "")
@@ -313,9 +378,72 @@ func (client *NodeMgmtClient) CallDecommissionNodeEndpoint(pod *corev1.Pod) erro
 	return err
 }
 
+func (client *NodeMgmtClient) retryPolicyFor(request nodeMgmtRequest) RetryPolicy {
+	if !request.idempotent {
+		return RetryPolicy{MaxAttempts: 1}
+	}
+
+	if client.RetryPolicy != nil {
+		return *client.RetryPolicy
+	}
+	return DefaultRetryPolicy
+}
+
+func (client *NodeMgmtClient) context() context.Context {
+	if client.Ctx != nil {
+		return client.Ctx
+	}
+	return context.Background()
+}
+
 func callNodeMgmtEndpoint(client *NodeMgmtClient, request nodeMgmtRequest, contentType string) ([]byte, error) {
 	client.Log.Info("client::callNodeMgmtEndpoint")
 
+	policy := client.retryPolicyFor(request)
+
+	for attempt := 1; ; attempt++ {
+		if !client.Breakers.allow(request.host) {
+			client.Log.Info("circuit breaker is open, not calling Node Management Endpoint",
+				"pod", request.host,
+				"endpoint", request.endpoint)
+
+			return nil, &RequestError{
+				Kind:     ErrorKindUnreachable,
+				Host:     request.host,
+				Endpoint: request.endpoint,
+				Err:      ErrCircuitOpen,
+			}
+		}
+
+		body, err := attemptNodeMgmtEndpoint(client, request, contentType)
+		if err == nil {
+			client.Breakers.recordSuccess(request.host)
+			return body, nil
+		}
+
+		if !isRetryable(err) {
+			// The node answered, it just did not like the request
+			client.Breakers.recordSuccess(request.host)
+			return nil, err
+		}
+
+		client.Breakers.recordFailure(request.host)
+
+		if attempt >= policy.MaxAttempts || client.context().Err() != nil {
+			return nil, err
+		}
+
+		wait := policy.backoff(attempt)
+		client.Log.Info("retrying call to Node Management Endpoint",
+			"pod", request.host,
+			"endpoint", request.endpoint,
+			"attempt", attempt,
+			"backoff", wait.String())
+		sleep(wait)
+	}
+}
+
+func attemptNodeMgmtEndpoint(client *NodeMgmtClient, request nodeMgmtRequest, contentType string) ([]byte, error) {
 	url := fmt.Sprintf("%s://%s:8080%s", client.Protocol, request.host, request.endpoint)
 
 	var reqBody io.Reader
@@ -330,15 +458,20 @@ func callNodeMgmtEndpoint(client *NodeMgmtClient, request nodeMgmtRequest, conte
 	}
 	req.Close = true
 
+	if client.Timeout > 0 {
+		request.timeout = client.Timeout
+	}
 	if request.timeout == 0 {
 		request.timeout = 60 * time.Second
 	}
 
+	ctx := client.context()
 	if request.timeout > 0 {
-		ctx, cancel := context.WithTimeout(context.Background(), request.timeout)
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, request.timeout)
 		defer cancel()
-		req = req.WithContext(ctx)
 	}
+	req = req.WithContext(ctx)
 
 	if contentType != "" {
 		req.Header.Set("Content-Type", contentType)
@@ -347,7 +480,7 @@ func callNodeMgmtEndpoint(client *NodeMgmtClient, request nodeMgmtRequest, conte
 	res, err := client.Client.Do(req)
 	if err != nil {
 		client.Log.Error(err, "unable to perform request to Node Management Endpoint")
-		return nil, err
+		return nil, newTransportError(request, err)
 	}
 
 	defer func() {
@@ -360,7 +493,7 @@ func callNodeMgmtEndpoint(client *NodeMgmtClient, request nodeMgmtRequest, conte
 	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		client.Log.Error(err, "Unable to read response from Node Management Endpoint")
-		return nil, err
+		return nil, newTransportError(request, err)
 	}
 
 	goodStatus := res.StatusCode >= 200 && res.StatusCode < 300
@@ -369,7 +502,7 @@ func callNodeMgmtEndpoint(client *NodeMgmtClient, request nodeMgmtRequest, conte
 			"statusCode", res.StatusCode,
 			"pod", request.host)
 
-		return nil, fmt.Errorf("incorrect status code of %d when calling endpoint", res.StatusCode)
+		return nil, newStatusCodeError(request, res.StatusCode)
 	}
 
 	return body, nil
@@ -101,4 +101,6 @@ func Test_parseMetadataEndpointsResponseBody(t *testing.T) {
 	assert.Equal(t, 2, len(endpoints.Entity))
 	assert.Equal(t, "10.233.90.45", endpoints.Entity[0].RpcAddress)
 	assert.Equal(t, "95c157dc-2811-446a-a541-9faaab2e6930", endpoints.Entity[0].HostID)
+	assert.Equal(t, []string{"2756844028858338669"}, endpoints.Entity[0].GetTokens())
+	assert.Equal(t, []string{"-1589726493696519215"}, endpoints.Entity[1].GetTokens())
 }
@@ -0,0 +1,129 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package httphelper
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// RequestErrorKind classifies why a call to the Management API failed, so
+// that callers can decide whether to requeue, skip the node, or give up.
+type RequestErrorKind string
+
+const (
+	// The node could not be reached at all: connection refused, timeout,
+	// DNS failure, or an open circuit breaker for the pod
+	ErrorKindUnreachable RequestErrorKind = "Unreachable"
+
+	// The Management API rejected our credentials, either with a 401/403
+	// or by failing the TLS handshake
+	ErrorKindAuthFailure RequestErrorKind = "AuthFailure"
+
+	// The Management API returned a 4xx status code other than 401/403
+	ErrorKindClientError RequestErrorKind = "ClientError"
+
+	// The Management API returned a 5xx status code
+	ErrorKindServerError RequestErrorKind = "ServerError"
+)
+
+// ErrCircuitOpen is wrapped by the RequestError returned when a request is
+// not attempted because the circuit breaker for the pod is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// RequestError is returned by every NodeMgmtClient call that fails after the
+// request was built, i.e. anything that went wrong talking to the node.
+type RequestError struct {
+	Kind       RequestErrorKind
+	Host       string
+	Endpoint   string
+	StatusCode int
+	Err        error
+}
+
+func (e *RequestError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("incorrect status code of %d when calling endpoint %s on %s", e.StatusCode, e.Endpoint, e.Host)
+	}
+	return fmt.Sprintf("%s calling endpoint %s on %s: %v", e.Kind, e.Endpoint, e.Host, e.Err)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+func newStatusCodeError(request nodeMgmtRequest, statusCode int) *RequestError {
+	kind := ErrorKindClientError
+	if statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden {
+		kind = ErrorKindAuthFailure
+	} else if statusCode >= 500 {
+		kind = ErrorKindServerError
+	}
+
+	return &RequestError{
+		Kind:       kind,
+		Host:       request.host,
+		Endpoint:   request.endpoint,
+		StatusCode: statusCode,
+	}
+}
+
+func newTransportError(request nodeMgmtRequest, err error) *RequestError {
+	kind := ErrorKindUnreachable
+	if isTLSAuthError(err) {
+		kind = ErrorKindAuthFailure
+	}
+
+	return &RequestError{
+		Kind:     kind,
+		Host:     request.host,
+		Endpoint: request.endpoint,
+		Err:      err,
+	}
+}
+
+func isTLSAuthError(err error) bool {
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalidCert x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	return errors.As(err, &unknownAuthority) ||
+		errors.As(err, &invalidCert) ||
+		errors.As(err, &hostname)
+}
+
+func hasErrorKind(err error, kind RequestErrorKind) bool {
+	var requestErr *RequestError
+	return errors.As(err, &requestErr) && requestErr.Kind == kind
+}
+
+// IsUnreachable reports whether err means the node could not be reached. It
+// is safe to skip the node for now and try again on a later reconcile.
+func IsUnreachable(err error) bool {
+	return hasErrorKind(err, ErrorKindUnreachable)
+}
+
+// IsAuthFailure reports whether err is an authentication or TLS failure.
+// Retrying will not help until the Management API secrets are fixed.
+func IsAuthFailure(err error) bool {
+	return hasErrorKind(err, ErrorKindAuthFailure)
+}
+
+// IsClientError reports whether the Management API rejected the request
+// with a 4xx status code.
+func IsClientError(err error) bool {
+	return hasErrorKind(err, ErrorKindClientError)
+}
+
+// IsServerError reports whether the Management API failed the request with
+// a 5xx status code.
+func IsServerError(err error) bool {
+	return hasErrorKind(err, ErrorKindServerError)
+}
+
+// isRetryable reports whether a failed attempt might succeed if repeated
+func isRetryable(err error) bool {
+	return IsUnreachable(err) || IsServerError(err)
+}
@@ -0,0 +1,666 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+// Package fakemgmtapi provides an in-process fake of the Management API that
+// simulates a ring of Cassandra nodes. It implements httphelper.HttpClient and
+// routes each request to the node with the pod IP it was addressed to, so it
+// can be dropped into a NodeMgmtClient in place of a real HTTP client.
+package fakemgmtapi
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Gossip statuses reported by the metadata endpoints
+const (
+	StatusNormal  = "NORMAL"
+	StatusLeaving = "LEAVING"
+	StatusLeft    = "LEFT"
+)
+
+// Node is the simulated state of one Cassandra node and its Management API
+type Node struct {
+	IP         string
+	HostID     string
+	Datacenter string
+	Rack       string
+	Tokens     []string
+
+	// Address the node advertises to clients, such as the address of its
+	// load balancer. Its IP when empty.
+	RpcAddress string
+
+	// Reachable is false once the pod is gone or the Management API is down.
+	// Requests to an unreachable node fail as if the connection was refused.
+	Reachable bool
+
+	// Started is set by the lifecycle start endpoint, Cassandra is not
+	// running before that
+	Started bool
+
+	// Gossip status of the node as seen by the ring, empty if it never joined
+	Status string
+
+	// Bytes of data reported as the node's load
+	Load float64
+
+	Drained     bool
+	SeedReloads int
+
+	// Source datacenter of every rebuild the node ran
+	Rebuilds []string
+
+	// Values of the settings changed at runtime, by endpoint path
+	Settings map[string]int
+
+	// JobError makes every job submitted to the node fail with it, without
+	// the job having any effect
+	JobError string
+
+	// "METHOD path" of every request the node received, in order
+	Calls []string
+
+	// Metadata reads left before a leaving node has left the ring
+	leavingPolls int
+}
+
+type Role struct {
+	Password  string
+	Superuser bool
+}
+
+type job struct {
+	id      string
+	jobType string
+	node    *Node
+}
+
+type failure struct {
+	path       string
+	statusCode int
+}
+
+// Server is a fake Management API for a whole ring. Nodes share a single
+// view of gossip, which is what every node would report once the ring has
+// settled.
+type Server struct {
+	// DecommissionPolls is the number of reads of the metadata endpoints
+	// during which a decommissioning node is reported as LEAVING before it
+	// is reported as LEFT. Zero makes decommissions complete immediately.
+	DecommissionPolls int
+
+	mu        sync.Mutex
+	nodes     map[string]*Node
+	roles     map[string]Role
+	keyspaces map[string]map[string]int
+	jobs      map[string]*job
+	failures  map[string][]failure
+	nextID    int
+}
+
+// NewServer returns a server without any node. The schema holds the system
+// keyspaces that are replicated across datacenters, not yet replicated
+// anywhere.
+func NewServer() *Server {
+	return &Server{
+		nodes: map[string]*Node{},
+		roles: map[string]Role{},
+		keyspaces: map[string]map[string]int{
+			"system_auth":        {},
+			"system_distributed": {},
+			"system_traces":      {},
+		},
+		jobs:     map[string]*job{},
+		failures: map[string][]failure{},
+	}
+}
+
+// AddNode makes a Management API reachable on the given pod IP. Cassandra is
+// not started on it until the lifecycle start endpoint is called.
+func (s *Server) AddNode(ip string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if node, ok := s.nodes[ip]; ok {
+		node.Reachable = true
+		return
+	}
+	s.nodes[ip] = &Node{IP: ip, Reachable: true}
+}
+
+// AddStartedNode adds a node that has already joined the ring
+func (s *Server) AddStartedNode(ip string) {
+	s.AddNode(ip)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.start(s.nodes[ip])
+}
+
+// StopNode simulates the pod going away. The node stays in gossip, reported
+// as down, until it is replaced or removed from the ring.
+func (s *Server) StopNode(ip string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if node, ok := s.nodes[ip]; ok {
+		node.Reachable = false
+		node.Started = false
+	}
+}
+
+// Node returns a copy of the state of the node with the given IP
+func (s *Server) Node(ip string) (Node, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[ip]
+	if !ok {
+		return Node{}, false
+	}
+	copied := *node
+	copied.Calls = append([]string(nil), node.Calls...)
+	copied.Rebuilds = append([]string(nil), node.Rebuilds...)
+	copied.Tokens = append([]string(nil), node.Tokens...)
+	copied.Settings = map[string]int{}
+	for path, value := range node.Settings {
+		copied.Settings[path] = value
+	}
+	return copied, true
+}
+
+// Roles returns the roles created through the Management API, by name
+func (s *Server) Roles() map[string]Role {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roles := map[string]Role{}
+	for name, role := range s.roles {
+		roles[name] = role
+	}
+	return roles
+}
+
+// SetKeyspace creates or replaces a keyspace with the given replication
+// factor per datacenter
+func (s *Server) SetKeyspace(name string, replication map[string]int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keyspaces[name] = copyReplication(replication)
+}
+
+// Keyspace returns the replication factor per datacenter of the keyspace
+func (s *Server) Keyspace(name string) (map[string]int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	replication, ok := s.keyspaces[name]
+	return copyReplication(replication), ok
+}
+
+func copyReplication(replication map[string]int) map[string]int {
+	copied := map[string]int{}
+	for dc, rf := range replication {
+		copied[dc] = rf
+	}
+	return copied
+}
+
+// SetDatacenter sets the datacenter the node reports through the metadata
+// endpoints
+func (s *Server) SetDatacenter(ip, dc string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if node, ok := s.nodes[ip]; ok {
+		node.Datacenter = dc
+	}
+}
+
+// FailJobs makes the jobs submitted to the node fail with the message
+func (s *Server) FailJobs(ip, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if node, ok := s.nodes[ip]; ok {
+		node.JobError = message
+	}
+}
+
+// SetRpcAddress sets the address the node advertises to clients, which the
+// metadata endpoints report instead of its IP
+func (s *Server) SetRpcAddress(ip, address string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if node, ok := s.nodes[ip]; ok {
+		node.RpcAddress = address
+	}
+}
+
+// SetRack sets the rack the node reports through the metadata endpoints
+func (s *Server) SetRack(ip, rack string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if node, ok := s.nodes[ip]; ok {
+		node.Rack = rack
+	}
+}
+
+// SetLoad sets the load the node reports through the metadata endpoints
+func (s *Server) SetLoad(ip string, load float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if node, ok := s.nodes[ip]; ok {
+		node.Load = load
+	}
+}
+
+// FailNext makes the next request to path on the node fail with statusCode
+func (s *Server) FailNext(ip, path string, statusCode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[ip] = append(s.failures[ip], failure{path: path, statusCode: statusCode})
+}
+
+// Do implements httphelper.HttpClient
+func (s *Server) Do(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	host, _, err := net.SplitHostPort(req.URL.Host)
+	if err != nil {
+		host = req.URL.Host
+	}
+
+	node, ok := s.nodes[host]
+	if !ok || !node.Reachable {
+		return nil, &net.OpError{
+			Op:  "dial",
+			Net: "tcp",
+			Err: fmt.Errorf("connect: connection refused to %s", req.URL.Host),
+		}
+	}
+
+	node.Calls = append(node.Calls, req.Method+" "+req.URL.Path)
+
+	recorder := httptest.NewRecorder()
+	if statusCode, failed := s.takeFailure(node, req.URL.Path); failed {
+		recorder.WriteHeader(statusCode)
+	} else {
+		s.serve(node, recorder, req)
+	}
+
+	return recorder.Result(), nil
+}
+
+func (s *Server) takeFailure(node *Node, path string) (int, bool) {
+	failures := s.failures[node.IP]
+	for i, f := range failures {
+		if f.path == path {
+			s.failures[node.IP] = append(failures[:i:i], failures[i+1:]...)
+			return f.statusCode, true
+		}
+	}
+	return 0, false
+}
+
+func (s *Server) serve(node *Node, w http.ResponseWriter, req *http.Request) {
+	route := req.Method + " " + req.URL.Path
+
+	switch route {
+	case "GET /api/v0/probes/liveness":
+		writeOK(w)
+		return
+	case "GET /api/v0/probes/readiness":
+		if node.Started && node.Status == StatusNormal {
+			writeOK(w)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	case "POST /api/v0/lifecycle/start":
+		s.serveStart(node, w, req)
+		return
+	}
+
+	if !node.Started {
+		http.Error(w, "Cassandra is not running", http.StatusInternalServerError)
+		return
+	}
+
+	switch route {
+	case "GET /api/v0/metadata/endpoints":
+		s.serveEndpoints(w)
+	case "GET /api/v0/probes/cluster":
+		s.serveProbeCluster(w, req)
+	case "POST /api/v0/ops/auth/role":
+		query := req.URL.Query()
+		superuser, _ := strconv.ParseBool(query.Get("is_superuser"))
+		s.roles[query.Get("username")] = Role{
+			Password:  query.Get("password"),
+			Superuser: superuser,
+		}
+		writeOK(w)
+	case "POST /api/v0/ops/node/drain":
+		node.Drained = true
+		writeOK(w)
+	case "POST /api/v0/ops/seeds/reload":
+		node.SeedReloads++
+		writeOK(w)
+	case "POST /api/v0/ops/node/decommission":
+		if s.decommission(node, w) {
+			writeOK(w)
+		}
+	case "POST /api/v1/ops/node/decommission":
+		if node.JobError != "" || s.decommission(node, w) {
+			s.writeJob(node, w, "decommission")
+		}
+	case "POST /api/v0/ops/node/rebuild":
+		node.Rebuilds = append(node.Rebuilds, req.URL.Query().Get("src_dc"))
+		writeOK(w)
+	case "POST /api/v1/ops/node/rebuild":
+		node.Rebuilds = append(node.Rebuilds, req.URL.Query().Get("src_dc"))
+		s.writeJob(node, w, "rebuild")
+	case "POST /api/v0/ops/node/assassinate":
+		s.serveAssassinate(w, req.URL.Query().Get("address"))
+	case "GET /api/v0/ops/keyspace":
+		s.serveKeyspaces(w, req.URL.Query().Get("keyspaceName"))
+	case "GET /api/v0/ops/keyspace/replication":
+		s.serveReplication(w, req.URL.Query().Get("keyspaceName"))
+	case "POST /api/v0/ops/keyspace/alter":
+		s.serveAlterKeyspace(w, req)
+	case "POST /api/v0/ops/keyspace/cleanup":
+		writeOK(w)
+	case "POST /api/v1/ops/keyspace/cleanup":
+		s.writeJob(node, w, "cleanup")
+	case "POST /api/v0/ops/tables/compactionthroughput",
+		"POST /api/v0/ops/tables/concurrentcompactors",
+		"POST /api/v0/ops/node/streamthroughput",
+		"POST /api/v0/ops/node/interdcstreamthroughput",
+		"POST /api/v0/ops/node/hintedhandoffthrottle":
+		value, err := strconv.Atoi(req.URL.Query().Get("value"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if node.Settings == nil {
+			node.Settings = map[string]int{}
+		}
+		node.Settings[req.URL.Path] = value
+		writeOK(w)
+	case "GET /api/v0/ops/executor/job":
+		s.serveJob(node, w, req.URL.Query().Get("job_id"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func writeOK(w http.ResponseWriter) {
+	_, _ = w.Write([]byte("OK"))
+}
+
+func (s *Server) start(node *Node) {
+	if node.HostID == "" {
+		s.nextID++
+		node.HostID = fmt.Sprintf("00000000-0000-0000-0000-%012d", s.nextID)
+		node.Tokens = []string{strconv.Itoa(s.nextID * 1000)}
+	}
+	node.Started = true
+	node.Drained = false
+	node.Status = StatusNormal
+	// Settings changed at runtime do not survive a restart
+	node.Settings = nil
+}
+
+func (s *Server) serveStart(node *Node, w http.ResponseWriter, req *http.Request) {
+	if node.Started {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if replaceIP := req.URL.Query().Get("replace_ip"); replaceIP != "" {
+		replaced, ok := s.nodes[replaceIP]
+		if !ok || replaced.Status == "" {
+			http.Error(w, fmt.Sprintf("cannot replace unknown node %s", replaceIP), http.StatusInternalServerError)
+			return
+		}
+		if replaced.Started {
+			http.Error(w, fmt.Sprintf("cannot replace live node %s", replaceIP), http.StatusInternalServerError)
+			return
+		}
+
+		// The replacement takes over the ring position of the dead node
+		node.HostID = replaced.HostID
+		node.Tokens = replaced.Tokens
+		delete(s.nodes, replaceIP)
+	}
+
+	s.start(node)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// serveProbeCluster reports whether the consistency level can be met with
+// rf_per_dc replicas, assuming the worst case where the nodes that are down
+// all hold replicas of the same range
+func (s *Server) serveProbeCluster(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	rf, err := strconv.Atoi(query.Get("rf_per_dc"))
+	if err != nil || rf < 1 {
+		rf = 1
+	}
+
+	required := rf
+	switch query.Get("consistency_level") {
+	case "ONE", "LOCAL_ONE":
+		required = 1
+	case "QUORUM", "LOCAL_QUORUM":
+		required = rf/2 + 1
+	}
+
+	down := []string{}
+	for _, other := range s.nodes {
+		if other.Status == StatusNormal && !other.Started {
+			down = append(down, other.IP)
+		}
+	}
+	sort.Strings(down)
+
+	if len(down) > rf-required {
+		http.Error(w, fmt.Sprintf("nodes %v are down", down), http.StatusInternalServerError)
+		return
+	}
+	writeOK(w)
+}
+
+func (s *Server) decommission(node *Node, w http.ResponseWriter) bool {
+	if node.Status != StatusNormal {
+		http.Error(w, fmt.Sprintf("node %s is %s and cannot be decommissioned", node.IP, node.Status),
+			http.StatusInternalServerError)
+		return false
+	}
+
+	node.Status = StatusLeaving
+	node.leavingPolls = s.DecommissionPolls
+	if node.leavingPolls == 0 {
+		node.Status = StatusLeft
+	}
+	return true
+}
+
+func (s *Server) serveEndpoints(w http.ResponseWriter) {
+	ips := []string{}
+	for ip, node := range s.nodes {
+		if node.Status != "" {
+			ips = append(ips, ip)
+		}
+	}
+	sort.Strings(ips)
+
+	entity := []map[string]string{}
+	for _, ip := range ips {
+		node := s.nodes[ip]
+		rpcAddress := node.RpcAddress
+		if rpcAddress == "" {
+			rpcAddress = node.IP
+		}
+		entity = append(entity, map[string]string{
+			"DC":          node.Datacenter,
+			"RACK":        node.Rack,
+			"HOST_ID":     node.HostID,
+			"IS_ALIVE":    strconv.FormatBool(node.Started),
+			"ENDPOINT_IP": node.IP,
+			"RPC_ADDRESS": rpcAddress,
+			"STATUS":      node.Status,
+			"LOAD":        strconv.FormatFloat(node.Load, 'f', -1, 64),
+			"TOKENS":      encodeTokens(node.Tokens),
+		})
+
+		if node.Status == StatusLeaving {
+			node.leavingPolls--
+			if node.leavingPolls <= 0 {
+				node.Status = StatusLeft
+			}
+		}
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{"entity": entity})
+	_, _ = w.Write(body)
+}
+
+// encodeTokens serializes tokens the way gossip reports them, each token as
+// its size and bytes followed by a zero size, with every byte as a character
+func encodeTokens(tokens []string) string {
+	data := []byte{}
+	for _, token := range tokens {
+		value, _ := strconv.ParseInt(token, 10, 64)
+		data = append(data, 0, 0, 0, 8)
+		data = append(data, make([]byte, 8)...)
+		binary.BigEndian.PutUint64(data[len(data)-8:], uint64(value))
+	}
+	data = append(data, 0, 0, 0, 0)
+
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+func (s *Server) serveAssassinate(w http.ResponseWriter, address string) {
+	assassinated, ok := s.nodes[address]
+	if !ok || assassinated.Status == "" {
+		http.Error(w, fmt.Sprintf("unknown endpoint %s", address), http.StatusInternalServerError)
+		return
+	}
+
+	// The node is dropped from gossip without streaming anything
+	assassinated.Status = ""
+	writeOK(w)
+}
+
+func (s *Server) serveKeyspaces(w http.ResponseWriter, name string) {
+	names := []string{}
+	for keyspace := range s.keyspaces {
+		if name == "" || name == keyspace {
+			names = append(names, keyspace)
+		}
+	}
+	sort.Strings(names)
+
+	body, _ := json.Marshal(names)
+	_, _ = w.Write(body)
+}
+
+func (s *Server) serveReplication(w http.ResponseWriter, name string) {
+	replication, ok := s.keyspaces[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("keyspace %s does not exist", name), http.StatusNotFound)
+		return
+	}
+
+	options := map[string]string{
+		"class": "org.apache.cassandra.locator.NetworkTopologyStrategy",
+	}
+	for dc, rf := range replication {
+		options[dc] = strconv.Itoa(rf)
+	}
+
+	body, _ := json.Marshal(options)
+	_, _ = w.Write(body)
+}
+
+func (s *Server) serveAlterKeyspace(w http.ResponseWriter, req *http.Request) {
+	alter := struct {
+		KeyspaceName        string `json:"keyspace_name"`
+		ReplicationSettings []struct {
+			DcName            string `json:"dc_name"`
+			ReplicationFactor int    `json:"replication_factor"`
+		} `json:"replication_settings"`
+	}{}
+	if err := json.NewDecoder(req.Body).Decode(&alter); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := s.keyspaces[alter.KeyspaceName]; !ok {
+		http.Error(w, fmt.Sprintf("keyspace %s does not exist", alter.KeyspaceName), http.StatusInternalServerError)
+		return
+	}
+
+	replication := map[string]int{}
+	for _, setting := range alter.ReplicationSettings {
+		replication[setting.DcName] = setting.ReplicationFactor
+	}
+	s.keyspaces[alter.KeyspaceName] = replication
+	writeOK(w)
+}
+
+func (s *Server) writeJob(node *Node, w http.ResponseWriter, jobType string) {
+	s.nextID++
+	id := fmt.Sprintf("%s-%d", jobType, s.nextID)
+	s.jobs[id] = &job{id: id, jobType: jobType, node: node}
+	_, _ = w.Write([]byte(id))
+}
+
+func (s *Server) serveJob(node *Node, w http.ResponseWriter, id string) {
+	j, ok := s.jobs[id]
+	if !ok || j.node != node {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	// A decommission job is done once the node has left the ring, every other
+	// job completes as soon as it is submitted
+	status := "COMPLETED"
+	if j.jobType == "decommission" && node.Status != StatusLeft {
+		status = "WAITING"
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"id":     j.id,
+		"type":   j.jobType,
+		"status": status,
+	})
+	if node.JobError != "" {
+		body, _ = json.Marshal(map[string]interface{}{
+			"id":     j.id,
+			"type":   j.jobType,
+			"status": "ERROR",
+			"error":  node.JobError,
+		})
+	}
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,234 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package fakemgmtapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/datastax/cass-operator/operator/pkg/httphelper"
+)
+
+func makePod(name, ip string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     corev1.PodStatus{PodIP: ip},
+	}
+}
+
+func makeClient(server *Server) *httphelper.NodeMgmtClient {
+	return &httphelper.NodeMgmtClient{
+		Client:      server,
+		Log:         logf.Log.WithName("fakemgmtapi_test"),
+		Protocol:    "http",
+		RetryPolicy: &httphelper.RetryPolicy{MaxAttempts: 1},
+	}
+}
+
+func getStatuses(t *testing.T, client *httphelper.NodeMgmtClient, pod *corev1.Pod) map[string]string {
+	endpoints, err := client.CallMetadataEndpointsEndpoint(pod)
+	assert.NoError(t, err)
+
+	statuses := map[string]string{}
+	for _, ep := range endpoints.Entity {
+		statuses[ep.GetRpcAddress()] = ep.Status
+	}
+	return statuses
+}
+
+func TestServer_StartAndJoin(t *testing.T) {
+	server := NewServer()
+	client := makeClient(server)
+	pod0 := makePod("pod-0", "10.0.0.1")
+	pod1 := makePod("pod-1", "10.0.0.2")
+
+	server.AddNode(pod0.Status.PodIP)
+	server.AddNode(pod1.Status.PodIP)
+
+	// Cassandra is not running until it is started
+	_, err := client.CallMetadataEndpointsEndpoint(pod0)
+	assert.True(t, httphelper.IsServerError(err))
+
+	assert.NoError(t, client.CallLifecycleStartEndpoint(pod0))
+	assert.Equal(t, map[string]string{"10.0.0.1": StatusNormal}, getStatuses(t, client, pod0))
+
+	assert.NoError(t, client.CallLifecycleStartEndpoint(pod1))
+	assert.Equal(t, map[string]string{
+		"10.0.0.1": StatusNormal,
+		"10.0.0.2": StatusNormal,
+	}, getStatuses(t, client, pod1))
+
+	assert.NoError(t, client.CallProbeClusterEndpoint(pod0, "LOCAL_QUORUM", 2))
+}
+
+func TestServer_UnknownNodeIsUnreachable(t *testing.T) {
+	server := NewServer()
+	client := makeClient(server)
+
+	err := client.CallReloadSeedsEndpoint(makePod("pod-0", "10.0.0.1"))
+	assert.True(t, httphelper.IsUnreachable(err))
+}
+
+func TestServer_Decommission(t *testing.T) {
+	server := NewServer()
+	server.DecommissionPolls = 2
+	client := makeClient(server)
+	pod0 := makePod("pod-0", "10.0.0.1")
+	pod1 := makePod("pod-1", "10.0.0.2")
+
+	server.AddStartedNode(pod0.Status.PodIP)
+	server.AddStartedNode(pod1.Status.PodIP)
+
+	jobID, err := client.CallDecommissionNodeAsyncEndpoint(pod1, false)
+	assert.NoError(t, err)
+
+	job, err := client.CallJobStatusEndpoint(pod1, jobID)
+	assert.NoError(t, err)
+	assert.Equal(t, httphelper.JobWaiting, job.Status)
+
+	assert.Equal(t, StatusLeaving, getStatuses(t, client, pod0)["10.0.0.2"])
+	assert.Equal(t, StatusLeaving, getStatuses(t, client, pod0)["10.0.0.2"])
+	assert.Equal(t, StatusLeft, getStatuses(t, client, pod0)["10.0.0.2"])
+
+	job, err = client.CallJobStatusEndpoint(pod1, jobID)
+	assert.NoError(t, err)
+	assert.Equal(t, httphelper.JobCompleted, job.Status)
+
+	// The job is only known to the node it was submitted to
+	_, err = client.CallJobStatusEndpoint(pod0, jobID)
+	assert.True(t, httphelper.IsClientError(err))
+
+	// A node that is not part of the ring cannot be decommissioned again
+	err = client.CallDecommissionNodeEndpoint(pod1)
+	assert.True(t, httphelper.IsServerError(err))
+}
+
+func TestServer_Replace(t *testing.T) {
+	server := NewServer()
+	client := makeClient(server)
+	pod0 := makePod("pod-0", "10.0.0.1")
+	pod1 := makePod("pod-1", "10.0.0.2")
+	replacement := makePod("pod-1", "10.0.0.3")
+
+	server.AddStartedNode(pod0.Status.PodIP)
+	server.AddStartedNode(pod1.Status.PodIP)
+	replaced, _ := server.Node(pod1.Status.PodIP)
+
+	server.AddNode(replacement.Status.PodIP)
+	err := client.CallLifecycleStartEndpointWithReplaceIp(replacement, pod1.Status.PodIP)
+	assert.True(t, httphelper.IsServerError(err), "a live node cannot be replaced")
+
+	server.StopNode(pod1.Status.PodIP)
+	assert.True(t, httphelper.IsServerError(client.CallProbeClusterEndpoint(pod0, "LOCAL_QUORUM", 2)))
+
+	err = client.CallLifecycleStartEndpointWithReplaceIp(replacement, pod1.Status.PodIP)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"10.0.0.1": StatusNormal,
+		"10.0.0.3": StatusNormal,
+	}, getStatuses(t, client, pod0))
+
+	node, _ := server.Node(replacement.Status.PodIP)
+	assert.Equal(t, replaced.HostID, node.HostID)
+}
+
+func TestServer_ProbeCluster(t *testing.T) {
+	server := NewServer()
+	client := makeClient(server)
+	pods := []*corev1.Pod{
+		makePod("pod-0", "10.0.0.1"),
+		makePod("pod-1", "10.0.0.2"),
+		makePod("pod-2", "10.0.0.3"),
+	}
+	for _, pod := range pods {
+		server.AddStartedNode(pod.Status.PodIP)
+	}
+
+	// A quorum of three replicas survives one node being down, not two
+	server.StopNode(pods[2].Status.PodIP)
+	assert.NoError(t, client.CallProbeClusterEndpoint(pods[0], "LOCAL_QUORUM", 3))
+	assert.True(t, httphelper.IsServerError(client.CallProbeClusterEndpoint(pods[0], "LOCAL_QUORUM", 1)))
+	assert.True(t, httphelper.IsServerError(client.CallProbeClusterEndpoint(pods[0], "ALL", 3)))
+
+	server.StopNode(pods[1].Status.PodIP)
+	assert.True(t, httphelper.IsServerError(client.CallProbeClusterEndpoint(pods[0], "LOCAL_QUORUM", 3)))
+	assert.NoError(t, client.CallProbeClusterEndpoint(pods[0], "LOCAL_ONE", 3))
+}
+
+func TestServer_Assassinate(t *testing.T) {
+	server := NewServer()
+	client := makeClient(server)
+	pod0 := makePod("pod-0", "10.0.0.1")
+	pod1 := makePod("pod-1", "10.0.0.2")
+
+	server.AddStartedNode(pod0.Status.PodIP)
+	server.AddStartedNode(pod1.Status.PodIP)
+	server.SetDatacenter(pod0.Status.PodIP, "dc1")
+	server.SetDatacenter(pod1.Status.PodIP, "dc2")
+	server.StopNode(pod1.Status.PodIP)
+
+	endpoints, err := client.CallMetadataEndpointsEndpoint(pod0)
+	assert.NoError(t, err)
+	assert.Equal(t, "dc2", endpoints.Entity[1].Datacenter)
+
+	assert.NoError(t, client.CallAssassinateEndpoint(pod0, pod1.Status.PodIP))
+	assert.Equal(t, map[string]string{"10.0.0.1": StatusNormal}, getStatuses(t, client, pod0))
+
+	err = client.CallAssassinateEndpoint(pod0, pod1.Status.PodIP)
+	assert.True(t, httphelper.IsServerError(err))
+}
+
+func TestServer_Operations(t *testing.T) {
+	server := NewServer()
+	client := makeClient(server)
+	pod := makePod("pod-0", "10.0.0.1")
+	server.AddStartedNode(pod.Status.PodIP)
+
+	assert.NoError(t, client.CallCreateRoleEndpoint(pod, "admin", "secret", true))
+	assert.Equal(t, map[string]Role{"admin": {Password: "secret", Superuser: true}}, server.Roles())
+
+	assert.NoError(t, client.CallReloadSeedsEndpoint(pod))
+	assert.NoError(t, client.CallDrainEndpoint(pod))
+
+	server.FailNext(pod.Status.PodIP, "/api/v0/ops/seeds/reload", 500)
+	assert.True(t, httphelper.IsServerError(client.CallReloadSeedsEndpoint(pod)))
+	assert.NoError(t, client.CallReloadSeedsEndpoint(pod))
+
+	jobID, err := client.CallRebuildAsyncEndpoint(pod, "dc1")
+	assert.NoError(t, err)
+	job, err := client.CallJobStatusEndpoint(pod, jobID)
+	assert.NoError(t, err)
+	assert.Equal(t, httphelper.JobCompleted, job.Status)
+
+	server.SetKeyspace("ks1", map[string]int{"dc1": 3})
+	keyspaces, err := client.CallListKeyspacesEndpoint(pod, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ks1", "system_auth", "system_distributed", "system_traces"}, keyspaces)
+
+	err = client.CallAlterKeyspaceEndpoint(pod, httphelper.AlterKeyspaceRequest{
+		KeyspaceName: "ks1",
+		ReplicationSettings: []httphelper.ReplicationSetting{
+			{DcName: "dc1", ReplicationFactor: 3},
+			{DcName: "dc2", ReplicationFactor: 2},
+		},
+	})
+	assert.NoError(t, err)
+	replication, err := client.CallGetKeyspaceReplicationEndpoint(pod, "ks1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"class": "org.apache.cassandra.locator.NetworkTopologyStrategy",
+		"dc1":   "3",
+		"dc2":   "2",
+	}, replication)
+
+	node, _ := server.Node(pod.Status.PodIP)
+	assert.Equal(t, []string{"dc1"}, node.Rebuilds)
+	assert.True(t, node.Drained)
+	assert.Equal(t, 2, node.SeedReloads)
+	assert.Equal(t, "POST /api/v0/ops/auth/role", node.Calls[0])
+}
@@ -0,0 +1,128 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package httphelper
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type JobType string
+
+const (
+	JobTypeCleanup      JobType = "cleanup"
+	JobTypeDecommission JobType = "decommission"
+	JobTypeRepair       JobType = "repair"
+	JobTypeCompaction   JobType = "compaction"
+	JobTypeUpgrade      JobType = "upgradesstables"
+	JobTypeFlush        JobType = "flush"
+	JobTypeRebuild      JobType = "rebuild"
+
+	// The ID of a running job is kept in a pod annotation, one per job type,
+	// so that it survives operator restarts and pod status updates
+	JobAnnotationPrefix = "cassandra.datastax.com/job-"
+)
+
+func JobAnnotation(jobType JobType) string {
+	return JobAnnotationPrefix + string(jobType)
+}
+
+// GetPodJobID returns the ID of the job of the given type that was submitted
+// to the pod, or an empty string if there is none
+func GetPodJobID(pod *corev1.Pod, jobType JobType) string {
+	return pod.GetAnnotations()[JobAnnotation(jobType)]
+}
+
+// JobTracker submits long-running operations through the asynchronous v1
+// endpoints of the Management API and follows them across reconciles. The
+// job ID is persisted on the pod, so at most one job of each type runs on a
+// pod at a time.
+type JobTracker struct {
+	MgmtClient *NodeMgmtClient
+	Client     client.Client
+	Ctx        context.Context
+}
+
+// Submit starts a job on the pod unless one of the same type is already
+// being tracked there, and returns the ID of the tracked job.
+func (tracker *JobTracker) Submit(pod *corev1.Pod, jobType JobType, submit func(*corev1.Pod) (string, error)) (string, error) {
+	if jobID := GetPodJobID(pod, jobType); jobID != "" {
+		return jobID, nil
+	}
+
+	jobID, err := submit(pod)
+	if err != nil {
+		return "", err
+	}
+	if jobID == "" {
+		return "", fmt.Errorf("Management API returned no job ID for %s on pod %s", jobType, pod.Name)
+	}
+
+	tracker.MgmtClient.Log.Info("submitted Management API job",
+		"pod", pod.Name,
+		"jobType", jobType,
+		"jobID", jobID)
+
+	patch := client.MergeFrom(pod.DeepCopy())
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Annotations[JobAnnotation(jobType)] = jobID
+	if err := tracker.Client.Patch(tracker.Ctx, pod, patch); err != nil {
+		return "", err
+	}
+
+	return jobID, nil
+}
+
+// Poll checks on the job of the given type tracked on the pod. It returns nil
+// if no job is tracked. A job that is done, successfully or not, is returned
+// once and then forgotten, so the caller must act on the result right away.
+//
+// A job the node no longer knows about, for example because Cassandra was
+// restarted, is reported as failed.
+func (tracker *JobTracker) Poll(pod *corev1.Pod, jobType JobType) (*Job, error) {
+	jobID := GetPodJobID(pod, jobType)
+	if jobID == "" {
+		return nil, nil
+	}
+
+	job, err := tracker.MgmtClient.CallJobStatusEndpoint(pod, jobID)
+	if err != nil {
+		if !IsClientError(err) {
+			return nil, err
+		}
+		job = Job{
+			ID:     jobID,
+			Type:   string(jobType),
+			Status: JobError,
+			Error:  "job is no longer known to the node",
+		}
+	}
+
+	if !job.IsDone() {
+		return &job, nil
+	}
+
+	if err := tracker.Forget(pod, jobType); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// Forget stops tracking the job of the given type on the pod, without
+// touching the job itself
+func (tracker *JobTracker) Forget(pod *corev1.Pod, jobType JobType) error {
+	if GetPodJobID(pod, jobType) == "" {
+		return nil
+	}
+
+	patch := client.MergeFrom(pod.DeepCopy())
+	delete(pod.Annotations, JobAnnotation(jobType))
+	return tracker.Client.Patch(tracker.Ctx, pod, patch)
+}
@@ -0,0 +1,111 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package httphelper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func setupJobTrackerTest(t *testing.T, responses map[string]string) (*fakeMgmtApi, *JobTracker, *corev1.Pod) {
+	api, mgmtClient, pod := setupOperationsTest(t, responses)
+	pod.Namespace = "default"
+
+	tracker := &JobTracker{
+		MgmtClient: mgmtClient,
+		Client:     fake.NewFakeClient(pod.DeepCopy()),
+		Ctx:        context.Background(),
+	}
+	return api, tracker, pod
+}
+
+func getTrackedJobID(t *testing.T, tracker *JobTracker, pod *corev1.Pod, jobType JobType) string {
+	stored := &corev1.Pod{}
+	err := tracker.Client.Get(tracker.Ctx, types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}, stored)
+	assert.NoError(t, err)
+	return GetPodJobID(stored, jobType)
+}
+
+func TestJobTracker_Submit(t *testing.T) {
+	api, tracker, pod := setupJobTrackerTest(t, map[string]string{
+		"POST /api/v1/ops/keyspace/cleanup": "cleanup-job",
+	})
+	defer api.server.Close()
+
+	submit := func(pod *corev1.Pod) (string, error) {
+		return tracker.MgmtClient.CallKeyspaceCleanupAsyncEndpoint(pod, KeyspaceRequest{})
+	}
+
+	jobID, err := tracker.Submit(pod, JobTypeCleanup, submit)
+	assert.NoError(t, err)
+	assert.Equal(t, "cleanup-job", jobID)
+	assert.Equal(t, "cleanup-job", getTrackedJobID(t, tracker, pod, JobTypeCleanup))
+
+	// A tracked job is not submitted a second time
+	jobID, err = tracker.Submit(pod, JobTypeCleanup, submit)
+	assert.NoError(t, err)
+	assert.Equal(t, "cleanup-job", jobID)
+	assert.Equal(t, 1, len(api.requests))
+}
+
+func TestJobTracker_Submit_NotSupported(t *testing.T) {
+	api, tracker, pod := setupJobTrackerTest(t, map[string]string{})
+	defer api.server.Close()
+
+	_, err := tracker.Submit(pod, JobTypeCleanup, func(pod *corev1.Pod) (string, error) {
+		return tracker.MgmtClient.CallKeyspaceCleanupAsyncEndpoint(pod, KeyspaceRequest{})
+	})
+	assert.True(t, IsClientError(err))
+	assert.Equal(t, "", getTrackedJobID(t, tracker, pod, JobTypeCleanup))
+}
+
+func TestJobTracker_Poll(t *testing.T) {
+	api, tracker, pod := setupJobTrackerTest(t, map[string]string{
+		"GET /api/v0/ops/executor/job": `{"id": "repair-job", "type": "repair", "status": "WAITING"}`,
+	})
+	defer api.server.Close()
+
+	job, err := tracker.Poll(pod, JobTypeRepair)
+	assert.NoError(t, err)
+	assert.Nil(t, job)
+	assert.Equal(t, 0, len(api.requests))
+
+	_, err = tracker.Submit(pod, JobTypeRepair, func(*corev1.Pod) (string, error) {
+		return "repair-job", nil
+	})
+	assert.NoError(t, err)
+
+	job, err = tracker.Poll(pod, JobTypeRepair)
+	assert.NoError(t, err)
+	assert.Equal(t, JobWaiting, job.Status)
+	assert.Equal(t, "repair-job", getTrackedJobID(t, tracker, pod, JobTypeRepair))
+
+	api.responses["GET /api/v0/ops/executor/job"] = `{"id": "repair-job", "type": "repair", "status": "COMPLETED"}`
+
+	job, err = tracker.Poll(pod, JobTypeRepair)
+	assert.NoError(t, err)
+	assert.Equal(t, JobCompleted, job.Status)
+	assert.Equal(t, "", getTrackedJobID(t, tracker, pod, JobTypeRepair))
+}
+
+func TestJobTracker_Poll_UnknownJob(t *testing.T) {
+	api, tracker, pod := setupJobTrackerTest(t, map[string]string{})
+	defer api.server.Close()
+
+	_, err := tracker.Submit(pod, JobTypeDecommission, func(*corev1.Pod) (string, error) {
+		return "decommission-job", nil
+	})
+	assert.NoError(t, err)
+
+	job, err := tracker.Poll(pod, JobTypeDecommission)
+	assert.NoError(t, err)
+	assert.Equal(t, JobError, job.Status)
+	assert.Equal(t, "decommission-job", job.ID)
+	assert.Equal(t, "", getTrackedJobID(t, tracker, pod, JobTypeDecommission))
+}
@@ -0,0 +1,573 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package httphelper
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// This file covers the Management API operations beyond the handful the
+// reconciler has always needed. The v0 endpoints block until the operation
+// is done. Their v1 counterparts return a job ID right away, which can then
+// be polled with CallJobStatusEndpoint.
+
+// KeyspaceRequest is the body of the table level operations such as flush
+// and upgradesstables. Empty fields select every keyspace or table.
+type KeyspaceRequest struct {
+	Jobs         int      `json:"jobs,omitempty"`
+	KeyspaceName string   `json:"keyspace_name,omitempty"`
+	Tables       []string `json:"tables,omitempty"`
+}
+
+type CompactRequest struct {
+	KeyspaceName string   `json:"keyspace_name,omitempty"`
+	Tables       []string `json:"tables,omitempty"`
+	Jobs         int      `json:"jobs,omitempty"`
+	SplitOutput  bool     `json:"split_output,omitempty"`
+	UserDefined  bool     `json:"user_defined,omitempty"`
+	StartToken   string   `json:"start_token,omitempty"`
+	EndToken     string   `json:"end_token,omitempty"`
+}
+
+type RepairRequest struct {
+	KeyspaceName string   `json:"keyspace_name"`
+	Tables       []string `json:"tables,omitempty"`
+	Full         bool     `json:"full"`
+}
+
+type TakeSnapshotRequest struct {
+	SnapshotName   string   `json:"snapshot_name,omitempty"`
+	Keyspaces      []string `json:"keyspaces,omitempty"`
+	TableName      string   `json:"table_name,omitempty"`
+	SkipFlush      bool     `json:"skip_flush,omitempty"`
+	KeyspaceTables []string `json:"keyspace_tables,omitempty"`
+}
+
+type SnapshotDetails struct {
+	SnapshotName string `json:"Snapshot name"`
+	Keyspace     string `json:"Keyspace name"`
+	Table        string `json:"Column family name"`
+	TrueSize     string `json:"True size"`
+	SizeOnDisk   string `json:"Size on disk"`
+}
+
+type snapshotDetailsResponse struct {
+	Entity []SnapshotDetails `json:"entity"`
+}
+
+type StreamSession struct {
+	Peer             string `json:"peer"`
+	State            string `json:"state"`
+	TotalFilesToSend int64  `json:"total_files_to_send"`
+	TotalFilesSent   int64  `json:"total_files_sent"`
+	TotalSizeToSend  int64  `json:"total_size_to_send"`
+	TotalSizeSent    int64  `json:"total_size_sent"`
+	TotalFilesToRecv int64  `json:"total_files_to_receive"`
+	TotalFilesRecvd  int64  `json:"total_files_received"`
+	TotalSizeToRecv  int64  `json:"total_size_to_receive"`
+	TotalSizeRecvd   int64  `json:"total_size_received"`
+}
+
+type StreamState struct {
+	PlanID      string          `json:"plan_id"`
+	Description string          `json:"description"`
+	Sessions    []StreamSession `json:"sessions"`
+}
+
+type StreamInfo struct {
+	Entity []StreamState `json:"entity"`
+}
+
+// ReplicationSetting is the replication factor of a keyspace in one datacenter
+type ReplicationSetting struct {
+	DcName            string `json:"dc_name"`
+	ReplicationFactor int    `json:"replication_factor"`
+}
+
+type AlterKeyspaceRequest struct {
+	KeyspaceName        string               `json:"keyspace_name"`
+	ReplicationSettings []ReplicationSetting `json:"replication_settings"`
+}
+
+// SchemaVersions maps each schema version to the endpoints that report it.
+// A healthy cluster has exactly one key.
+type SchemaVersions map[string][]string
+
+type JobStatus string
+
+const (
+	JobWaiting   JobStatus = "WAITING"
+	JobCompleted JobStatus = "COMPLETED"
+	JobError     JobStatus = "ERROR"
+)
+
+// Job is the state of an asynchronous operation started through a v1 endpoint
+type Job struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	Status     JobStatus `json:"status"`
+	SubmitTime int64     `json:"submit_time,omitempty"`
+	EndTime    int64     `json:"end_time,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+func (job *Job) IsDone() bool {
+	return job.Status == JobCompleted || job.Status == JobError
+}
+
+// callPodEndpoint fills in the host of the request from the pod and logs the call
+func (client *NodeMgmtClient) callPodEndpoint(pod *corev1.Pod, request nodeMgmtRequest, contentType string) ([]byte, error) {
+	client.Log.Info(
+		"calling Management API - "+request.method+" "+strings.SplitN(request.endpoint, "?", 2)[0],
+		"pod", pod.Name,
+	)
+
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return nil, err
+	}
+	request.host = podHost
+
+	return callNodeMgmtEndpoint(client, request, contentType)
+}
+
+func (client *NodeMgmtClient) callPodEndpointWithJSON(pod *corev1.Pod, request nodeMgmtRequest, body interface{}) ([]byte, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	request.body = data
+
+	return client.callPodEndpoint(pod, request, "application/json")
+}
+
+// The v1 endpoints answer with the bare job ID, and snapshots with their name
+func parsePlainText(body []byte) string {
+	return strings.Trim(strings.TrimSpace(string(body)), "\"")
+}
+
+func (client *NodeMgmtClient) CallFlushEndpoint(pod *corev1.Pod, req KeyspaceRequest) error {
+	request := nodeMgmtRequest{
+		endpoint:   "/api/v0/ops/tables/flush",
+		method:     http.MethodPost,
+		timeout:    time.Minute * 2,
+		idempotent: true,
+	}
+
+	_, err := client.callPodEndpointWithJSON(pod, request, req)
+	return err
+}
+
+func (client *NodeMgmtClient) CallFlushAsyncEndpoint(pod *corev1.Pod, req KeyspaceRequest) (string, error) {
+	request := nodeMgmtRequest{
+		endpoint: "/api/v1/ops/tables/flush",
+		method:   http.MethodPost,
+	}
+
+	body, err := client.callPodEndpointWithJSON(pod, request, req)
+	if err != nil {
+		return "", err
+	}
+	return parsePlainText(body), nil
+}
+
+func (client *NodeMgmtClient) CallCompactionEndpoint(pod *corev1.Pod, req CompactRequest) error {
+	request := nodeMgmtRequest{
+		endpoint: "/api/v0/ops/tables/compact",
+		method:   http.MethodPost,
+		timeout:  time.Minute * 30,
+	}
+
+	_, err := client.callPodEndpointWithJSON(pod, request, req)
+	return err
+}
+
+func (client *NodeMgmtClient) CallCompactionAsyncEndpoint(pod *corev1.Pod, req CompactRequest) (string, error) {
+	request := nodeMgmtRequest{
+		endpoint: "/api/v1/ops/tables/compact",
+		method:   http.MethodPost,
+	}
+
+	body, err := client.callPodEndpointWithJSON(pod, request, req)
+	if err != nil {
+		return "", err
+	}
+	return parsePlainText(body), nil
+}
+
+// CallSetCompactionThroughputEndpoint throttles compaction on the node, in
+// MB/s. Zero disables throttling.
+func (client *NodeMgmtClient) CallSetCompactionThroughputEndpoint(pod *corev1.Pod, mbPerSec int) error {
+	request := nodeMgmtRequest{
+		endpoint:   buildEndpoint("/api/v0/ops/tables/compactionthroughput", "value", strconv.Itoa(mbPerSec)),
+		method:     http.MethodPost,
+		idempotent: true,
+	}
+
+	_, err := client.callPodEndpoint(pod, request, "")
+	return err
+}
+
+// CallSetConcurrentCompactorsEndpoint changes the number of compactions the
+// node runs at once
+func (client *NodeMgmtClient) CallSetConcurrentCompactorsEndpoint(pod *corev1.Pod, compactors int) error {
+	request := nodeMgmtRequest{
+		endpoint:   buildEndpoint("/api/v0/ops/tables/concurrentcompactors", "value", strconv.Itoa(compactors)),
+		method:     http.MethodPost,
+		idempotent: true,
+	}
+
+	_, err := client.callPodEndpoint(pod, request, "")
+	return err
+}
+
+// CallSetStreamThroughputEndpoint throttles the outbound streaming of the
+// node, in megabits per second. Zero disables throttling.
+func (client *NodeMgmtClient) CallSetStreamThroughputEndpoint(pod *corev1.Pod, megabitsPerSec int) error {
+	request := nodeMgmtRequest{
+		endpoint:   buildEndpoint("/api/v0/ops/node/streamthroughput", "value", strconv.Itoa(megabitsPerSec)),
+		method:     http.MethodPost,
+		idempotent: true,
+	}
+
+	_, err := client.callPodEndpoint(pod, request, "")
+	return err
+}
+
+// CallSetInterDCStreamThroughputEndpoint throttles the outbound streaming of
+// the node to other datacenters, in megabits per second. Zero disables
+// throttling.
+func (client *NodeMgmtClient) CallSetInterDCStreamThroughputEndpoint(pod *corev1.Pod, megabitsPerSec int) error {
+	request := nodeMgmtRequest{
+		endpoint:   buildEndpoint("/api/v0/ops/node/interdcstreamthroughput", "value", strconv.Itoa(megabitsPerSec)),
+		method:     http.MethodPost,
+		idempotent: true,
+	}
+
+	_, err := client.callPodEndpoint(pod, request, "")
+	return err
+}
+
+// CallSetHintedHandoffThrottleEndpoint throttles the delivery of hints by the
+// node, in KB per second
+func (client *NodeMgmtClient) CallSetHintedHandoffThrottleEndpoint(pod *corev1.Pod, kbPerSec int) error {
+	request := nodeMgmtRequest{
+		endpoint:   buildEndpoint("/api/v0/ops/node/hintedhandoffthrottle", "value", strconv.Itoa(kbPerSec)),
+		method:     http.MethodPost,
+		idempotent: true,
+	}
+
+	_, err := client.callPodEndpoint(pod, request, "")
+	return err
+}
+
+func (client *NodeMgmtClient) CallUpgradeSSTablesEndpoint(pod *corev1.Pod, req KeyspaceRequest) error {
+	request := nodeMgmtRequest{
+		endpoint: "/api/v0/ops/tables/sstables/upgrade",
+		method:   http.MethodPost,
+		timeout:  time.Minute * 30,
+	}
+
+	_, err := client.callPodEndpointWithJSON(pod, request, req)
+	return err
+}
+
+func (client *NodeMgmtClient) CallUpgradeSSTablesAsyncEndpoint(pod *corev1.Pod, req KeyspaceRequest) (string, error) {
+	request := nodeMgmtRequest{
+		endpoint: "/api/v1/ops/tables/sstables/upgrade",
+		method:   http.MethodPost,
+	}
+
+	body, err := client.callPodEndpointWithJSON(pod, request, req)
+	if err != nil {
+		return "", err
+	}
+	return parsePlainText(body), nil
+}
+
+// CallCreateSnapshotEndpoint takes a snapshot and returns its name, which is
+// generated by the node when req.SnapshotName is empty
+func (client *NodeMgmtClient) CallCreateSnapshotEndpoint(pod *corev1.Pod, req TakeSnapshotRequest) (string, error) {
+	request := nodeMgmtRequest{
+		endpoint: "/api/v0/ops/node/snapshots",
+		method:   http.MethodPost,
+		timeout:  time.Minute * 5,
+	}
+
+	body, err := client.callPodEndpointWithJSON(pod, request, req)
+	if err != nil {
+		return "", err
+	}
+	return parsePlainText(body), nil
+}
+
+// snapshotsEndpoint returns the snapshots endpoint for the given snapshot
+// names and keyspaces. The management API reads both as lists, with the
+// parameter repeated for each value.
+func snapshotsEndpoint(snapshotNames []string, keyspaces []string) string {
+	params := url.Values{}
+	for _, name := range snapshotNames {
+		params.Add("snapshotNames", name)
+	}
+	for _, keyspace := range keyspaces {
+		params.Add("keyspace", keyspace)
+	}
+
+	endpoint := "/api/v0/ops/node/snapshots"
+	if len(params) > 0 {
+		endpoint = endpoint + "?" + params.Encode()
+	}
+	return endpoint
+}
+
+func (client *NodeMgmtClient) CallListSnapshotsEndpoint(pod *corev1.Pod, snapshotNames []string, keyspace string) ([]SnapshotDetails, error) {
+	keyspaces := []string{}
+	if keyspace != "" {
+		keyspaces = append(keyspaces, keyspace)
+	}
+
+	request := nodeMgmtRequest{
+		endpoint:   snapshotsEndpoint(snapshotNames, keyspaces),
+		method:     http.MethodGet,
+		idempotent: true,
+	}
+
+	body, err := client.callPodEndpoint(pod, request, "")
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := &snapshotDetailsResponse{}
+	if err := json.Unmarshal(body, snapshots); err != nil {
+		return nil, err
+	}
+	return snapshots.Entity, nil
+}
+
+// CallClearSnapshotsEndpoint removes the named snapshots from the given
+// keyspaces. Empty arguments clear every snapshot on the node.
+func (client *NodeMgmtClient) CallClearSnapshotsEndpoint(pod *corev1.Pod, snapshotNames []string, keyspaces []string) error {
+	request := nodeMgmtRequest{
+		endpoint:   snapshotsEndpoint(snapshotNames, keyspaces),
+		method:     http.MethodDelete,
+		idempotent: true,
+	}
+
+	_, err := client.callPodEndpoint(pod, request, "")
+	return err
+}
+
+func (client *NodeMgmtClient) CallRepairEndpoint(pod *corev1.Pod, req RepairRequest) error {
+	request := nodeMgmtRequest{
+		endpoint: "/api/v0/ops/node/repair",
+		method:   http.MethodPost,
+		timeout:  time.Minute * 30,
+	}
+
+	_, err := client.callPodEndpointWithJSON(pod, request, req)
+	return err
+}
+
+func (client *NodeMgmtClient) CallRepairAsyncEndpoint(pod *corev1.Pod, req RepairRequest) (string, error) {
+	request := nodeMgmtRequest{
+		endpoint: "/api/v1/ops/node/repair",
+		method:   http.MethodPost,
+	}
+
+	body, err := client.callPodEndpointWithJSON(pod, request, req)
+	if err != nil {
+		return "", err
+	}
+	return parsePlainText(body), nil
+}
+
+// CallRebuildEndpoint streams the data the node owns from srcDatacenter
+func (client *NodeMgmtClient) CallRebuildEndpoint(pod *corev1.Pod, srcDatacenter string) error {
+	request := nodeMgmtRequest{
+		endpoint: buildEndpoint("/api/v0/ops/node/rebuild", "src_dc", srcDatacenter),
+		method:   http.MethodPost,
+		timeout:  time.Minute * 30,
+	}
+
+	_, err := client.callPodEndpoint(pod, request, "")
+	return err
+}
+
+func (client *NodeMgmtClient) CallRebuildAsyncEndpoint(pod *corev1.Pod, srcDatacenter string) (string, error) {
+	request := nodeMgmtRequest{
+		endpoint: buildEndpoint("/api/v1/ops/node/rebuild", "src_dc", srcDatacenter),
+		method:   http.MethodPost,
+	}
+
+	body, err := client.callPodEndpoint(pod, request, "")
+	if err != nil {
+		return "", err
+	}
+	return parsePlainText(body), nil
+}
+
+func (client *NodeMgmtClient) CallKeyspaceCleanupAsyncEndpoint(pod *corev1.Pod, req KeyspaceRequest) (string, error) {
+	request := nodeMgmtRequest{
+		endpoint: "/api/v1/ops/keyspace/cleanup",
+		method:   http.MethodPost,
+	}
+
+	body, err := client.callPodEndpointWithJSON(pod, request, req)
+	if err != nil {
+		return "", err
+	}
+	return parsePlainText(body), nil
+}
+
+func (client *NodeMgmtClient) CallDecommissionNodeAsyncEndpoint(pod *corev1.Pod, force bool) (string, error) {
+	request := nodeMgmtRequest{
+		endpoint: buildEndpoint("/api/v1/ops/node/decommission", "force", strconv.FormatBool(force)),
+		method:   http.MethodPost,
+	}
+
+	body, err := client.callPodEndpoint(pod, request, "")
+	if err != nil {
+		return "", err
+	}
+	return parsePlainText(body), nil
+}
+
+// CallListKeyspacesEndpoint returns the names of the keyspaces of the
+// cluster, or only keyspaceName if it is set and the keyspace exists
+func (client *NodeMgmtClient) CallListKeyspacesEndpoint(pod *corev1.Pod, keyspaceName string) ([]string, error) {
+	endpoint := "/api/v0/ops/keyspace"
+	if keyspaceName != "" {
+		endpoint = buildEndpoint(endpoint, "keyspaceName", keyspaceName)
+	}
+
+	request := nodeMgmtRequest{
+		endpoint:   endpoint,
+		method:     http.MethodGet,
+		idempotent: true,
+	}
+
+	body, err := client.callPodEndpoint(pod, request, "")
+	if err != nil {
+		return nil, err
+	}
+
+	keyspaces := []string{}
+	if err := json.Unmarshal(body, &keyspaces); err != nil {
+		return nil, err
+	}
+	return keyspaces, nil
+}
+
+// CallGetKeyspaceReplicationEndpoint returns the replication options of the
+// keyspace, the strategy under "class" and the replication factor of every
+// datacenter under its name
+func (client *NodeMgmtClient) CallGetKeyspaceReplicationEndpoint(pod *corev1.Pod, keyspaceName string) (map[string]string, error) {
+	request := nodeMgmtRequest{
+		endpoint:   buildEndpoint("/api/v0/ops/keyspace/replication", "keyspaceName", keyspaceName),
+		method:     http.MethodGet,
+		idempotent: true,
+	}
+
+	body, err := client.callPodEndpoint(pod, request, "")
+	if err != nil {
+		return nil, err
+	}
+
+	replication := map[string]string{}
+	if err := json.Unmarshal(body, &replication); err != nil {
+		return nil, err
+	}
+	return replication, nil
+}
+
+// CallAlterKeyspaceEndpoint sets the replication of the keyspace with the
+// NetworkTopologyStrategy. Every datacenter the keyspace should be replicated
+// to must be listed, not only the ones that change.
+func (client *NodeMgmtClient) CallAlterKeyspaceEndpoint(pod *corev1.Pod, req AlterKeyspaceRequest) error {
+	request := nodeMgmtRequest{
+		endpoint:   "/api/v0/ops/keyspace/alter",
+		method:     http.MethodPost,
+		idempotent: true,
+	}
+
+	_, err := client.callPodEndpointWithJSON(pod, request, req)
+	return err
+}
+
+func (client *NodeMgmtClient) CallGetStreamInfoEndpoint(pod *corev1.Pod) (StreamInfo, error) {
+	request := nodeMgmtRequest{
+		endpoint:   "/api/v0/ops/node/streaminfo",
+		method:     http.MethodGet,
+		idempotent: true,
+	}
+
+	body, err := client.callPodEndpoint(pod, request, "")
+	if err != nil {
+		return StreamInfo{}, err
+	}
+
+	streamInfo := StreamInfo{}
+	if err := json.Unmarshal(body, &streamInfo); err != nil {
+		return StreamInfo{}, err
+	}
+	return streamInfo, nil
+}
+
+func (client *NodeMgmtClient) CallSchemaVersionsEndpoint(pod *corev1.Pod) (SchemaVersions, error) {
+	request := nodeMgmtRequest{
+		endpoint:   "/api/v0/ops/node/schema/versions",
+		method:     http.MethodGet,
+		idempotent: true,
+	}
+
+	body, err := client.callPodEndpoint(pod, request, "")
+	if err != nil {
+		return nil, err
+	}
+
+	versions := SchemaVersions{}
+	if err := json.Unmarshal(body, &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// CallAssassinateEndpoint forcibly removes the node at address from gossip,
+// using pod as the coordinator. Only use it for nodes that are gone for good.
+func (client *NodeMgmtClient) CallAssassinateEndpoint(pod *corev1.Pod, address string) error {
+	request := nodeMgmtRequest{
+		endpoint: buildEndpoint("/api/v0/ops/node/assassinate", "address", address),
+		method:   http.MethodPost,
+		timeout:  time.Minute * 2,
+	}
+
+	_, err := client.callPodEndpoint(pod, request, "")
+	return err
+}
+
+func (client *NodeMgmtClient) CallJobStatusEndpoint(pod *corev1.Pod, jobID string) (Job, error) {
+	request := nodeMgmtRequest{
+		endpoint:   buildEndpoint("/api/v0/ops/executor/job", "job_id", jobID),
+		method:     http.MethodGet,
+		idempotent: true,
+	}
+
+	body, err := client.callPodEndpoint(pod, request, "")
+	if err != nil {
+		return Job{}, err
+	}
+
+	job := Job{}
+	if err := json.Unmarshal(body, &job); err != nil {
+		return Job{}, err
+	}
+	return job, nil
+}
@@ -0,0 +1,318 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package httphelper
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+type recordedRequest struct {
+	method string
+	path   string
+	query  url.Values
+	body   map[string]interface{}
+}
+
+// fakeMgmtApi serves canned responses keyed by "METHOD path" and records
+// every request it sees
+type fakeMgmtApi struct {
+	server    *httptest.Server
+	responses map[string]string
+	requests  []recordedRequest
+}
+
+func newFakeMgmtApi(t *testing.T, responses map[string]string) *fakeMgmtApi {
+	api := &fakeMgmtApi{responses: responses}
+	api.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorded := recordedRequest{
+			method: r.Method,
+			path:   r.URL.Path,
+			query:  r.URL.Query(),
+		}
+		data, _ := ioutil.ReadAll(r.Body)
+		if len(data) > 0 {
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.NoError(t, json.Unmarshal(data, &recorded.body))
+		}
+		api.requests = append(api.requests, recorded)
+
+		response, ok := api.responses[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(response))
+	}))
+	return api
+}
+
+func (api *fakeMgmtApi) lastRequest() recordedRequest {
+	return api.requests[len(api.requests)-1]
+}
+
+// Do sends every request to the fake server, whatever pod it was meant for
+func (api *fakeMgmtApi) Do(req *http.Request) (*http.Response, error) {
+	serverURL, _ := url.Parse(api.server.URL)
+	req.URL.Host = serverURL.Host
+	return api.server.Client().Do(req)
+}
+
+func setupOperationsTest(t *testing.T, responses map[string]string) (*fakeMgmtApi, *NodeMgmtClient, *corev1.Pod) {
+	api := newFakeMgmtApi(t, responses)
+	return api, makeRetryTestClient(api), makeRetryTestPod()
+}
+
+func TestCallFlushEndpoint(t *testing.T) {
+	api, client, pod := setupOperationsTest(t, map[string]string{
+		"POST /api/v0/ops/tables/flush": "OK",
+		"POST /api/v1/ops/tables/flush": "flush-job",
+	})
+	defer api.server.Close()
+
+	err := client.CallFlushEndpoint(pod, KeyspaceRequest{KeyspaceName: "ks1", Tables: []string{"t1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "ks1", api.lastRequest().body["keyspace_name"])
+	assert.Equal(t, []interface{}{"t1"}, api.lastRequest().body["tables"])
+
+	jobID, err := client.CallFlushAsyncEndpoint(pod, KeyspaceRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "flush-job", jobID)
+}
+
+func TestCallCompactionEndpoints(t *testing.T) {
+	api, client, pod := setupOperationsTest(t, map[string]string{
+		"POST /api/v0/ops/tables/compact":              "OK",
+		"POST /api/v1/ops/tables/compact":              "compact-job",
+		"POST /api/v0/ops/tables/compactionthroughput": "OK",
+	})
+	defer api.server.Close()
+
+	err := client.CallCompactionEndpoint(pod, CompactRequest{KeyspaceName: "ks1", SplitOutput: true})
+	assert.NoError(t, err)
+	assert.Equal(t, true, api.lastRequest().body["split_output"])
+
+	jobID, err := client.CallCompactionAsyncEndpoint(pod, CompactRequest{KeyspaceName: "ks1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "compact-job", jobID)
+
+	err = client.CallSetCompactionThroughputEndpoint(pod, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, "64", api.lastRequest().query.Get("value"))
+}
+
+func TestCallRuntimeSettingEndpoints(t *testing.T) {
+	api, client, pod := setupOperationsTest(t, map[string]string{
+		"POST /api/v0/ops/tables/concurrentcompactors":  "OK",
+		"POST /api/v0/ops/node/streamthroughput":        "OK",
+		"POST /api/v0/ops/node/interdcstreamthroughput": "OK",
+		"POST /api/v0/ops/node/hintedhandoffthrottle":   "OK",
+	})
+	defer api.server.Close()
+
+	calls := []struct {
+		call func(*corev1.Pod, int) error
+		path string
+	}{
+		{client.CallSetConcurrentCompactorsEndpoint, "/api/v0/ops/tables/concurrentcompactors"},
+		{client.CallSetStreamThroughputEndpoint, "/api/v0/ops/node/streamthroughput"},
+		{client.CallSetInterDCStreamThroughputEndpoint, "/api/v0/ops/node/interdcstreamthroughput"},
+		{client.CallSetHintedHandoffThrottleEndpoint, "/api/v0/ops/node/hintedhandoffthrottle"},
+	}
+	for _, c := range calls {
+		assert.NoError(t, c.call(pod, 8))
+		assert.Equal(t, c.path, api.lastRequest().path)
+		assert.Equal(t, "8", api.lastRequest().query.Get("value"))
+	}
+}
+
+func TestCallUpgradeSSTablesEndpoints(t *testing.T) {
+	api, client, pod := setupOperationsTest(t, map[string]string{
+		"POST /api/v0/ops/tables/sstables/upgrade": "OK",
+		"POST /api/v1/ops/tables/sstables/upgrade": "upgrade-job",
+	})
+	defer api.server.Close()
+
+	err := client.CallUpgradeSSTablesEndpoint(pod, KeyspaceRequest{Jobs: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(2), api.lastRequest().body["jobs"])
+
+	jobID, err := client.CallUpgradeSSTablesAsyncEndpoint(pod, KeyspaceRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "upgrade-job", jobID)
+}
+
+func TestCallSnapshotEndpoints(t *testing.T) {
+	api, client, pod := setupOperationsTest(t, map[string]string{
+		"POST /api/v0/ops/node/snapshots":   `"snap-1"`,
+		"DELETE /api/v0/ops/node/snapshots": "OK",
+		"GET /api/v0/ops/node/snapshots": `{"entity": [
+			{"Snapshot name": "snap-1", "Keyspace name": "ks1", "Column family name": "t1", "True size": "1 KiB", "Size on disk": "2 KiB"}
+		]}`,
+	})
+	defer api.server.Close()
+
+	name, err := client.CallCreateSnapshotEndpoint(pod, TakeSnapshotRequest{SnapshotName: "snap-1", Keyspaces: []string{"ks1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "snap-1", name)
+	assert.Equal(t, []interface{}{"ks1"}, api.lastRequest().body["keyspaces"])
+
+	snapshots, err := client.CallListSnapshotsEndpoint(pod, []string{"snap-1", "snap-2"}, "ks1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"snap-1", "snap-2"}, api.lastRequest().query["snapshotNames"])
+	assert.Equal(t, "ks1", api.lastRequest().query.Get("keyspace"))
+	assert.Equal(t, []SnapshotDetails{{
+		SnapshotName: "snap-1",
+		Keyspace:     "ks1",
+		Table:        "t1",
+		TrueSize:     "1 KiB",
+		SizeOnDisk:   "2 KiB",
+	}}, snapshots)
+
+	err = client.CallClearSnapshotsEndpoint(pod, []string{"snap-1", "snap-2"}, []string{"ks1"})
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodDelete, api.lastRequest().method)
+	assert.Equal(t, []string{"snap-1", "snap-2"}, api.lastRequest().query["snapshotNames"])
+	assert.Equal(t, []string{"ks1"}, api.lastRequest().query["keyspace"])
+}
+
+func TestCallRepairEndpoints(t *testing.T) {
+	api, client, pod := setupOperationsTest(t, map[string]string{
+		"POST /api/v0/ops/node/repair": "OK",
+		"POST /api/v1/ops/node/repair": "repair-job",
+	})
+	defer api.server.Close()
+
+	err := client.CallRepairEndpoint(pod, RepairRequest{KeyspaceName: "ks1", Full: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "ks1", api.lastRequest().body["keyspace_name"])
+	assert.Equal(t, true, api.lastRequest().body["full"])
+
+	jobID, err := client.CallRepairAsyncEndpoint(pod, RepairRequest{KeyspaceName: "ks1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "repair-job", jobID)
+}
+
+func TestCallAsyncLifecycleEndpoints(t *testing.T) {
+	api, client, pod := setupOperationsTest(t, map[string]string{
+		"POST /api/v1/ops/keyspace/cleanup":  "cleanup-job",
+		"POST /api/v1/ops/node/decommission": "decommission-job",
+		"POST /api/v1/ops/node/rebuild":      "rebuild-job",
+		"POST /api/v0/ops/node/rebuild":      "OK",
+	})
+	defer api.server.Close()
+
+	jobID, err := client.CallKeyspaceCleanupAsyncEndpoint(pod, KeyspaceRequest{KeyspaceName: "ks1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "cleanup-job", jobID)
+
+	jobID, err = client.CallDecommissionNodeAsyncEndpoint(pod, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "decommission-job", jobID)
+	assert.Equal(t, "true", api.lastRequest().query.Get("force"))
+
+	jobID, err = client.CallRebuildAsyncEndpoint(pod, "dc1")
+	assert.NoError(t, err)
+	assert.Equal(t, "rebuild-job", jobID)
+	assert.Equal(t, "dc1", api.lastRequest().query.Get("src_dc"))
+
+	err = client.CallRebuildEndpoint(pod, "dc1")
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/v0/ops/node/rebuild", api.lastRequest().path)
+}
+
+func TestCallClusterStateEndpoints(t *testing.T) {
+	api, client, pod := setupOperationsTest(t, map[string]string{
+		"GET /api/v0/ops/node/streaminfo": `{"entity": [
+			{"plan_id": "p1", "description": "Rebuild", "sessions": [{"peer": "10.0.0.2", "state": "STREAMING", "total_files_to_receive": 4, "total_files_received": 1}]}
+		]}`,
+		"GET /api/v0/ops/node/schema/versions": `{"e84b6a60-24cf-30ca-9b58-452d92911703": ["10.0.0.1", "10.0.0.2"]}`,
+		"POST /api/v0/ops/node/assassinate":    "OK",
+	})
+	defer api.server.Close()
+
+	streamInfo, err := client.CallGetStreamInfoEndpoint(pod)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(streamInfo.Entity))
+	assert.Equal(t, "Rebuild", streamInfo.Entity[0].Description)
+	assert.Equal(t, int64(4), streamInfo.Entity[0].Sessions[0].TotalFilesToRecv)
+
+	versions, err := client.CallSchemaVersionsEndpoint(pod)
+	assert.NoError(t, err)
+	assert.Equal(t, SchemaVersions{"e84b6a60-24cf-30ca-9b58-452d92911703": {"10.0.0.1", "10.0.0.2"}}, versions)
+
+	err = client.CallAssassinateEndpoint(pod, "10.0.0.3")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.3", api.lastRequest().query.Get("address"))
+}
+
+func TestCallKeyspaceEndpoints(t *testing.T) {
+	api, client, pod := setupOperationsTest(t, map[string]string{
+		"GET /api/v0/ops/keyspace":             `["system_auth", "ks1"]`,
+		"GET /api/v0/ops/keyspace/replication": `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "dc1": "3"}`,
+		"POST /api/v0/ops/keyspace/alter":      "OK",
+	})
+	defer api.server.Close()
+
+	keyspaces, err := client.CallListKeyspacesEndpoint(pod, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"system_auth", "ks1"}, keyspaces)
+	assert.Equal(t, "", api.lastRequest().query.Get("keyspaceName"))
+
+	replication, err := client.CallGetKeyspaceReplicationEndpoint(pod, "ks1")
+	assert.NoError(t, err)
+	assert.Equal(t, "ks1", api.lastRequest().query.Get("keyspaceName"))
+	assert.Equal(t, "3", replication["dc1"])
+
+	err = client.CallAlterKeyspaceEndpoint(pod, AlterKeyspaceRequest{
+		KeyspaceName: "ks1",
+		ReplicationSettings: []ReplicationSetting{
+			{DcName: "dc1", ReplicationFactor: 3},
+			{DcName: "dc2", ReplicationFactor: 1},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"keyspace_name": "ks1",
+		"replication_settings": []interface{}{
+			map[string]interface{}{"dc_name": "dc1", "replication_factor": float64(3)},
+			map[string]interface{}{"dc_name": "dc2", "replication_factor": float64(1)},
+		},
+	}, api.lastRequest().body)
+}
+
+func TestCallJobStatusEndpoint(t *testing.T) {
+	api, client, pod := setupOperationsTest(t, map[string]string{
+		"GET /api/v0/ops/executor/job": `{"id": "repair-job", "type": "repair", "status": "ERROR", "submit_time": 1, "end_time": 2, "error": "boom"}`,
+	})
+	defer api.server.Close()
+
+	job, err := client.CallJobStatusEndpoint(pod, "repair-job")
+	assert.NoError(t, err)
+	assert.Equal(t, "repair-job", api.lastRequest().query.Get("job_id"))
+	assert.Equal(t, Job{
+		ID:         "repair-job",
+		Type:       "repair",
+		Status:     JobError,
+		SubmitTime: 1,
+		EndTime:    2,
+		Error:      "boom",
+	}, job)
+	assert.True(t, job.IsDone())
+}
+
+func TestCallOperationEndpoint_NotFound(t *testing.T) {
+	api, client, pod := setupOperationsTest(t, map[string]string{})
+	defer api.server.Close()
+
+	_, err := client.CallSchemaVersionsEndpoint(pod)
+	assert.True(t, IsClientError(err))
+}
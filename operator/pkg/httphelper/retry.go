@@ -0,0 +1,144 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package httphelper
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// RetryPolicy controls how many times an idempotent Management API call is
+// attempted and how long to wait between attempts. Only unreachable nodes and
+// 5xx responses are retried.
+type RetryPolicy struct {
+	// Total number of attempts, including the first one
+	MaxAttempts int
+
+	// Wait before the second attempt, doubled for every attempt after that
+	InitialBackoff time.Duration
+
+	// Upper bound for the wait between two attempts
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy applies to the idempotent calls of a NodeMgmtClient
+// without a RetryPolicy
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     4 * time.Second,
+}
+
+// backoff returns the jittered wait before the given retry, where retry 1 is
+// the wait between the first and the second attempt. The result lies between
+// half and all of the exponential backoff, so that the operator does not hit
+// every node in lockstep.
+func (policy RetryPolicy) backoff(retry int) time.Duration {
+	wait := policy.InitialBackoff
+	for i := 1; i < retry && wait < policy.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if policy.MaxBackoff > 0 && wait > policy.MaxBackoff {
+		wait = policy.MaxBackoff
+	}
+	if wait <= 0 {
+		return 0
+	}
+
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(wait-half)+1))
+}
+
+// Use a var so we can mock this in tests
+var sleep = time.Sleep
+
+const (
+	// Consecutive failed attempts against a pod before its breaker opens
+	DefaultCircuitBreakerThreshold = 3
+
+	// How long an open breaker rejects calls before letting one through
+	DefaultCircuitBreakerCooldown = 30 * time.Second
+)
+
+type circuitState struct {
+	failures  int
+	openUntil time.Time
+}
+
+// CircuitBreakers tracks consecutive failures per pod so that a hung node
+// fails fast instead of burning the full request timeout on every call.
+// Breakers are keyed by pod IP, so a rescheduled pod starts with a closed
+// breaker. A single CircuitBreakers is meant to be shared across reconciles.
+type CircuitBreakers struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	lock   sync.Mutex
+	states map[string]*circuitState
+	now    func() time.Time
+}
+
+func NewCircuitBreakers() *CircuitBreakers {
+	return &CircuitBreakers{
+		Threshold: DefaultCircuitBreakerThreshold,
+		Cooldown:  DefaultCircuitBreakerCooldown,
+		states:    map[string]*circuitState{},
+		now:       time.Now,
+	}
+}
+
+// allow reports whether a request to host may be attempted. Once the
+// cooldown of an open breaker has passed, requests are let through again
+// and the next result decides whether it closes or re-opens.
+func (breakers *CircuitBreakers) allow(host string) bool {
+	if breakers == nil {
+		return true
+	}
+
+	breakers.lock.Lock()
+	defer breakers.lock.Unlock()
+
+	state, ok := breakers.states[host]
+	if !ok {
+		return true
+	}
+	return !breakers.now().Before(state.openUntil)
+}
+
+func (breakers *CircuitBreakers) recordSuccess(host string) {
+	if breakers == nil {
+		return
+	}
+
+	breakers.lock.Lock()
+	defer breakers.lock.Unlock()
+
+	delete(breakers.states, host)
+}
+
+func (breakers *CircuitBreakers) recordFailure(host string) {
+	if breakers == nil {
+		return
+	}
+
+	breakers.lock.Lock()
+	defer breakers.lock.Unlock()
+
+	state, ok := breakers.states[host]
+	if !ok {
+		state = &circuitState{}
+		breakers.states[host] = state
+	}
+
+	state.failures++
+	if state.failures >= breakers.Threshold {
+		state.openUntil = breakers.now().Add(breakers.Cooldown)
+	}
+}
+
+// IsOpen reports whether calls to host are currently being rejected
+func (breakers *CircuitBreakers) IsOpen(host string) bool {
+	return !breakers.allow(host)
+}
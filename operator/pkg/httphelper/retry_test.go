@@ -0,0 +1,230 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package httphelper
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type fakeHttpClient struct {
+	calls     int
+	responses []func() (*http.Response, error)
+}
+
+func (c *fakeHttpClient) Do(req *http.Request) (*http.Response, error) {
+	idx := c.calls
+	if idx >= len(c.responses) {
+		idx = len(c.responses) - 1
+	}
+	c.calls++
+	return c.responses[idx]()
+}
+
+func respondWith(statusCode int) func() (*http.Response, error) {
+	return func() (*http.Response, error) {
+		return &http.Response{
+			StatusCode: statusCode,
+			Body:       ioutil.NopCloser(strings.NewReader("OK")),
+		}, nil
+	}
+}
+
+func failWith(err error) func() (*http.Response, error) {
+	return func() (*http.Response, error) {
+		return nil, err
+	}
+}
+
+func makeRetryTestPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-foo"},
+		Status:     corev1.PodStatus{PodIP: "1.2.3.4"},
+	}
+}
+
+func makeRetryTestClient(httpClient HttpClient) *NodeMgmtClient {
+	return &NodeMgmtClient{
+		Client:   httpClient,
+		Log:      logf.Log.WithName("retry_test"),
+		Protocol: "http",
+	}
+}
+
+func mockSleep() func() {
+	oldSleep := sleep
+	sleep = func(time.Duration) {}
+	return func() {
+		sleep = oldSleep
+	}
+}
+
+func Test_callNodeMgmtEndpoint_RetriesIdempotentCalls(t *testing.T) {
+	defer mockSleep()()
+
+	httpClient := &fakeHttpClient{responses: []func() (*http.Response, error){
+		failWith(fmt.Errorf("connection refused")),
+		respondWith(http.StatusServiceUnavailable),
+		respondWith(http.StatusOK),
+	}}
+	client := makeRetryTestClient(httpClient)
+
+	err := client.CallReloadSeedsEndpoint(makeRetryTestPod())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, httpClient.calls)
+}
+
+func Test_callNodeMgmtEndpoint_GivesUpAfterMaxAttempts(t *testing.T) {
+	defer mockSleep()()
+
+	httpClient := &fakeHttpClient{responses: []func() (*http.Response, error){
+		respondWith(http.StatusInternalServerError),
+	}}
+	client := makeRetryTestClient(httpClient)
+	client.RetryPolicy = &RetryPolicy{MaxAttempts: 2}
+
+	err := client.CallReloadSeedsEndpoint(makeRetryTestPod())
+	assert.True(t, IsServerError(err))
+	assert.Equal(t, 2, httpClient.calls)
+}
+
+type contextRecordingHttpClient struct {
+	contexts []context.Context
+}
+
+func (c *contextRecordingHttpClient) Do(req *http.Request) (*http.Response, error) {
+	c.contexts = append(c.contexts, req.Context())
+	return nil, fmt.Errorf("connection refused")
+}
+
+func Test_callNodeMgmtEndpoint_ClientOverrides(t *testing.T) {
+	defer mockSleep()()
+
+	httpClient := &contextRecordingHttpClient{}
+	client := makeRetryTestClient(httpClient)
+	ctx, cancel := context.WithCancel(context.Background())
+	client.Ctx = ctx
+	client.Timeout = 2 * time.Second
+
+	// The calls get the timeout of the client and are made in its context
+	start := time.Now()
+	_, err := client.CallMetadataEndpointsEndpoint(makeRetryTestPod())
+	assert.True(t, IsUnreachable(err))
+	assert.Len(t, httpClient.contexts, DefaultRetryPolicy.MaxAttempts)
+	deadline, ok := httpClient.contexts[0].Deadline()
+	assert.True(t, ok)
+	assert.True(t, deadline.Before(start.Add(3*time.Second)), "deadline %v", deadline)
+
+	// A cancelled context is not retried
+	httpClient.contexts = nil
+	cancel()
+	_, err = client.CallMetadataEndpointsEndpoint(makeRetryTestPod())
+	assert.True(t, IsUnreachable(err))
+	assert.Len(t, httpClient.contexts, 1)
+	assert.Error(t, httpClient.contexts[0].Err())
+}
+
+func Test_callNodeMgmtEndpoint_DoesNotRetryNonIdempotentCalls(t *testing.T) {
+	defer mockSleep()()
+
+	httpClient := &fakeHttpClient{responses: []func() (*http.Response, error){
+		failWith(fmt.Errorf("connection refused")),
+	}}
+	client := makeRetryTestClient(httpClient)
+
+	err := client.CallDecommissionNodeEndpoint(makeRetryTestPod())
+	assert.True(t, IsUnreachable(err))
+	assert.Equal(t, 1, httpClient.calls)
+}
+
+func Test_callNodeMgmtEndpoint_DoesNotRetryClientErrors(t *testing.T) {
+	defer mockSleep()()
+
+	tests := []struct {
+		statusCode int
+		check      func(error) bool
+	}{
+		{http.StatusBadRequest, IsClientError},
+		{http.StatusNotFound, IsClientError},
+		{http.StatusUnauthorized, IsAuthFailure},
+		{http.StatusForbidden, IsAuthFailure},
+	}
+
+	for _, tt := range tests {
+		httpClient := &fakeHttpClient{responses: []func() (*http.Response, error){
+			respondWith(tt.statusCode),
+		}}
+		client := makeRetryTestClient(httpClient)
+
+		err := client.CallReloadSeedsEndpoint(makeRetryTestPod())
+		assert.True(t, tt.check(err), "status %d", tt.statusCode)
+		assert.Equal(t, 1, httpClient.calls, "status %d", tt.statusCode)
+	}
+}
+
+func Test_callNodeMgmtEndpoint_CircuitBreaker(t *testing.T) {
+	defer mockSleep()()
+
+	now := time.Now()
+	breakers := NewCircuitBreakers()
+	breakers.now = func() time.Time { return now }
+
+	httpClient := &fakeHttpClient{responses: []func() (*http.Response, error){
+		failWith(fmt.Errorf("i/o timeout")),
+	}}
+	client := makeRetryTestClient(httpClient)
+	client.Breakers = breakers
+	pod := makeRetryTestPod()
+
+	// The default policy makes three attempts, which is enough to open the breaker
+	err := client.CallReloadSeedsEndpoint(pod)
+	assert.True(t, IsUnreachable(err))
+	assert.Equal(t, 3, httpClient.calls)
+	assert.True(t, breakers.IsOpen(pod.Status.PodIP))
+
+	// An open breaker fails fast without calling the node
+	err = client.CallDrainEndpoint(pod)
+	assert.True(t, IsUnreachable(err))
+	assert.True(t, strings.Contains(err.Error(), ErrCircuitOpen.Error()))
+	assert.Equal(t, 3, httpClient.calls)
+
+	// Other pods are unaffected
+	assert.False(t, breakers.IsOpen("5.6.7.8"))
+
+	// Once the cooldown has passed a successful call closes the breaker
+	now = now.Add(DefaultCircuitBreakerCooldown)
+	httpClient.responses = []func() (*http.Response, error){respondWith(http.StatusOK)}
+	err = client.CallReloadSeedsEndpoint(pod)
+	assert.NoError(t, err)
+	assert.False(t, breakers.IsOpen(pod.Status.PodIP))
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     3 * time.Second,
+	}
+
+	for i := 0; i < 20; i++ {
+		first := policy.backoff(1)
+		assert.True(t, first >= 500*time.Millisecond && first <= time.Second, first.String())
+
+		second := policy.backoff(2)
+		assert.True(t, second >= time.Second && second <= 2*time.Second, second.String())
+
+		capped := policy.backoff(4)
+		assert.True(t, capped >= 1500*time.Millisecond && capped <= 3*time.Second, capped.String())
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package images
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// EnvImageCatalogFile is the env var with the path of the image catalog file
+// of the operator, usually mounted from a ConfigMap
+const EnvImageCatalogFile = "IMAGE_CATALOG_FILE"
+
+// Catalog lists the server images the operator uses in addition to, or in
+// place of, the ones compiled into it. A new patch release of a server can be
+// supported by adding it to the catalog, without a new build of the operator.
+type Catalog struct {
+	// Regular expression of the supported versions by server type. A version
+	// that is not in Servers but matches it uses the default image name of the
+	// server type.
+	SupportedVersions map[string]string `json:"supportedVersions,omitempty"`
+	Servers           []ServerImage     `json:"servers,omitempty"`
+	// Config builder images, which replace the compiled-in ones when set
+	ConfigBuilderImage    string `json:"configBuilderImage,omitempty"`
+	UBIConfigBuilderImage string `json:"ubiConfigBuilderImage,omitempty"`
+	// Digest of each image, by image reference without the registry, such as
+	// busybox:1.32.0-uclibc. The images that have one are pinned to it, so a
+	// tag pushed again cannot change what the pods run.
+	Digests map[string]string `json:"digests,omitempty"`
+}
+
+// ServerImage is the image of one version of a server type
+type ServerImage struct {
+	ServerType string `json:"serverType"`
+	Version    string `json:"version"`
+	Image      string `json:"image"`
+	// Image used when the operator runs on a UBI base image. Without one, the
+	// default UBI image name of the server type is used.
+	UBIImage string `json:"ubiImage,omitempty"`
+	// Set to false if the config builder cannot render the config of this
+	// version, so that the operator has to render it
+	ConfigBuilder *bool `json:"configBuilder,omitempty"`
+}
+
+// resolvedCatalog is the catalog in use, merged over the compiled-in images
+type resolvedCatalog struct {
+	supportedVersions     map[string]*regexp.Regexp
+	servers               map[string]ServerImage
+	configBuilderImage    string
+	ubiConfigBuilderImage string
+	digests               map[string]string
+}
+
+var digestRegexp = regexp.MustCompile(`^sha256:[a-f0-9]{64}$|^sha512:[a-f0-9]{128}$`)
+
+var (
+	catalogLock sync.RWMutex
+	catalog     = mustResolveCatalog(nil)
+)
+
+func serverKey(serverType, version string) string {
+	return serverType + "/" + version
+}
+
+// defaultCatalog is the catalog of the images compiled into the operator
+func defaultCatalog() *Catalog {
+	c := &Catalog{
+		SupportedVersions: map[string]string{
+			"cassandra": ValidOssVersionRegexp,
+			"dse":       ValidDseVersionRegexp,
+		},
+	}
+	addServers := func(serverType string, versionToImage, versionToUBIImage map[string]Image) {
+		for version, image := range versionToImage {
+			server := ServerImage{
+				ServerType: serverType,
+				Version:    version,
+				Image:      imageLookupMap[image],
+			}
+			if ubiImage, ok := versionToUBIImage[version]; ok {
+				server.UBIImage = imageLookupMap[ubiImage]
+			}
+			c.Servers = append(c.Servers, server)
+		}
+	}
+	addServers("cassandra", versionToOSSCassandra, versionToUBIOSSCassandra)
+	addServers("dse", versionToDSE, versionToUBIDSE)
+	return c
+}
+
+func resolveCatalog(c *Catalog) (*resolvedCatalog, error) {
+	r := &resolvedCatalog{
+		supportedVersions: map[string]*regexp.Regexp{},
+		servers:           map[string]ServerImage{},
+		digests:           map[string]string{},
+	}
+
+	// The catalog is merged over the compiled-in one
+	for _, source := range []*Catalog{defaultCatalog(), c} {
+		if source == nil {
+			continue
+		}
+		for serverType, expr := range source.SupportedVersions {
+			if serverType != "cassandra" && serverType != "dse" {
+				return nil, fmt.Errorf("unknown server type '%s' in supportedVersions", serverType)
+			}
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid supportedVersions of server type '%s': %v", serverType, err)
+			}
+			r.supportedVersions[serverType] = re
+		}
+		for _, server := range source.Servers {
+			if server.ServerType != "cassandra" && server.ServerType != "dse" {
+				return nil, fmt.Errorf("unknown server type '%s' for version '%s'", server.ServerType, server.Version)
+			}
+			if server.Version == "" || server.Image == "" {
+				return nil, fmt.Errorf("server of type '%s' without a version or an image", server.ServerType)
+			}
+			r.servers[serverKey(server.ServerType, server.Version)] = server
+		}
+		if source.ConfigBuilderImage != "" {
+			r.configBuilderImage = source.ConfigBuilderImage
+		}
+		if source.UBIConfigBuilderImage != "" {
+			r.ubiConfigBuilderImage = source.UBIConfigBuilderImage
+		}
+		for image, digest := range source.Digests {
+			if strings.Contains(image, "@") {
+				return nil, fmt.Errorf("image '%s' of digests must not have a digest", image)
+			}
+			if !digestRegexp.MatchString(digest) {
+				return nil, fmt.Errorf("invalid digest '%s' of image '%s'", digest, image)
+			}
+			r.digests[stripRegistry(image)] = digest
+		}
+	}
+
+	return r, nil
+}
+
+func mustResolveCatalog(c *Catalog) *resolvedCatalog {
+	r, err := resolveCatalog(c)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func currentCatalog() *resolvedCatalog {
+	catalogLock.RLock()
+	defer catalogLock.RUnlock()
+	return catalog
+}
+
+// SetCatalog replaces the catalog in use, a nil catalog leaves only the
+// compiled-in images. The catalog is left as it was if it is not valid.
+func SetCatalog(c *Catalog) error {
+	r, err := resolveCatalog(c)
+	if err != nil {
+		return err
+	}
+	catalogLock.Lock()
+	defer catalogLock.Unlock()
+	catalog = r
+	return nil
+}
+
+// ParseCatalog decodes a catalog in YAML or JSON
+func ParseCatalog(data []byte) (*Catalog, error) {
+	jsonData, err := yaml.ToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Catalog{}
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LoadCatalogFile reads the catalog file at the given path and uses it
+func LoadCatalogFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	c, err := ParseCatalog(data)
+	if err != nil {
+		return fmt.Errorf("error parsing image catalog %s: %v", path, err)
+	}
+	return SetCatalog(c)
+}
+
+// WatchCatalogFile reads the catalog file at the given path every interval,
+// and uses it when its content changed since the previous read, until stop is
+// closed. The files of a mounted ConfigMap are replaced rather than written
+// to, so they are polled instead of watched for events. A catalog that cannot
+// be read or is not valid is logged, and the previous one is kept.
+func WatchCatalogFile(path string, interval time.Duration, stop <-chan struct{}) {
+	var last []byte
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Error(err, "Could not read image catalog", "path", path)
+			continue
+		}
+		if bytes.Equal(data, last) {
+			continue
+		}
+		last = data
+
+		c, err := ParseCatalog(data)
+		if err == nil {
+			err = SetCatalog(c)
+		}
+		if err != nil {
+			log.Error(err, "Could not load image catalog, keeping the previous one", "path", path)
+			continue
+		}
+		log.Info("Loaded image catalog", "path", path)
+	}
+}
+
+// IsConfigBuilderCompatible tells if the config builder can render the config
+// of the given server version. Unless the catalog tells otherwise, it can for
+// DSE and for the Cassandra versions of ConfigBuilderOssPrefixes.
+func IsConfigBuilderCompatible(serverType, version string) bool {
+	server, ok := currentCatalog().servers[serverKey(serverType, version)]
+	if ok && server.ConfigBuilder != nil {
+		return *server.ConfigBuilder
+	}
+	if serverType != "cassandra" {
+		return true
+	}
+	for _, prefix := range ConfigBuilderOssPrefixes {
+		if strings.HasPrefix(version, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func isVersionSupported(serverType, version string) bool {
+	c := currentCatalog()
+	if _, ok := c.servers[serverKey(serverType, version)]; ok {
+		return true
+	}
+	re, ok := c.supportedVersions[serverType]
+	return ok && re.MatchString(version)
+}
+
+// PinDigest returns the image pinned to its digest in the catalog, as
+// image:tag@digest. The image is returned as it is if it has no digest in the
+// catalog or is already pinned. The digest is looked up without the registry
+// of the image, so that it also pins the image in the registry set by
+// DEFAULT_CONTAINER_REGISTRY_OVERRIDE.
+func PinDigest(image string) string {
+	if image == "" || IsPinned(image) {
+		return image
+	}
+	if digest, ok := currentCatalog().digests[stripRegistry(image)]; ok {
+		return image + "@" + digest
+	}
+	return image
+}
+
+// IsPinned tells if the image is referenced by digest
+func IsPinned(image string) bool {
+	return strings.Contains(image, "@")
+}
+
+// SplitDigest splits an image pinned by digest into its reference and its
+// digest. The digest is empty if the image is not pinned.
+func SplitDigest(image string) (string, string) {
+	if i := strings.LastIndex(image, "@"); i >= 0 {
+		return image[:i], image[i+1:]
+	}
+	return image, ""
+}
@@ -0,0 +1,219 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package images
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCatalog = `
+supportedVersions:
+  dse: "6\\.8\\.\\d+|6\\.9\\.\\d+"
+servers:
+- serverType: cassandra
+  version: 3.11.8
+  image: example.com/cassandra:3.11.8
+  ubiImage: example.com/cassandra:3.11.8-ubi
+- serverType: cassandra
+  version: 4.2.0
+  image: example.com/cassandra:4.2.0
+  configBuilder: false
+- serverType: dse
+  version: 6.8.4
+  image: example.com/dse:6.8.4
+configBuilderImage: example.com/config-builder:2.0.0
+`
+
+func Test_Catalog(t *testing.T) {
+	c, err := ParseCatalog([]byte(testCatalog))
+	require.NoError(t, err)
+	require.NoError(t, SetCatalog(c))
+	defer SetCatalog(nil)
+
+	tests := []struct {
+		serverType string
+		version    string
+		image      string
+	}{
+		// Added by the catalog
+		{"cassandra", "3.11.8", "example.com/cassandra:3.11.8"},
+		{"cassandra", "4.2.0", "example.com/cassandra:4.2.0"},
+		// Replaced by the catalog
+		{"dse", "6.8.4", "example.com/dse:6.8.4"},
+		// Compiled-in
+		{"cassandra", "3.11.7", "datastax/cassandra-mgmtapi-3_11_7:v0.1.13"},
+		// Supported by the catalog without an image
+		{"dse", "6.9.1", "datastax/dse-server:6.9.1"},
+	}
+	for _, tt := range tests {
+		image, err := GetCassandraImage(tt.serverType, tt.version)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.image, image)
+	}
+
+	assert.True(t, IsOssVersionSupported("4.2.0"))
+	assert.False(t, IsOssVersionSupported("4.2.1"))
+	assert.True(t, IsDseVersionSupported("6.9.0"))
+	_, err = GetCassandraImage("cassandra", "4.2.1")
+	assert.Error(t, err)
+
+	assert.False(t, IsConfigBuilderCompatible("cassandra", "4.2.0"))
+	assert.True(t, IsConfigBuilderCompatible("cassandra", "3.11.8"))
+	assert.True(t, IsConfigBuilderCompatible("dse", "6.9.0"))
+
+	assert.Equal(t, "example.com/config-builder:2.0.0", GetConfigBuilderImage())
+
+	// Back to the compiled-in images
+	require.NoError(t, SetCatalog(nil))
+	assert.False(t, IsOssVersionSupported("4.2.0"))
+	image, err := GetCassandraImage("dse", "6.8.4")
+	assert.NoError(t, err)
+	assert.Equal(t, "datastax/dse-server:6.8.4", image)
+	assert.Equal(t, GetImage(ConfigBuilder), GetConfigBuilderImage())
+}
+
+func Test_CatalogUBI(t *testing.T) {
+	restore, err := tempSetEnv(EnvBaseImageOS, "example")
+	require.NoError(t, err)
+	defer restore()
+
+	c, err := ParseCatalog([]byte(testCatalog))
+	require.NoError(t, err)
+	require.NoError(t, SetCatalog(c))
+	defer SetCatalog(nil)
+
+	image, err := GetCassandraImage("cassandra", "3.11.8")
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com/cassandra:3.11.8-ubi", image)
+
+	// Without a UBI image in the catalog
+	image, err = GetCassandraImage("cassandra", "4.2.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "datastax/cassandra-mgmtapi:4.2.0-ubi7", image)
+
+	// The compiled-in UBI config builder is kept
+	assert.Equal(t, GetImage(UBIConfigBuilder), GetConfigBuilderImage())
+}
+
+func Test_InvalidCatalog(t *testing.T) {
+	tests := []struct {
+		name    string
+		catalog string
+	}{
+		{"unknown field", "servers:\n- serverType: cassandra\n  version: 3.11.8\n  img: example.com/cassandra\n"},
+		{"unknown server type", "servers:\n- serverType: scylla\n  version: 4.0.0\n  image: example.com/scylla\n"},
+		{"missing image", "servers:\n- serverType: cassandra\n  version: 3.11.8\n"},
+		{"invalid regexp", "supportedVersions:\n  cassandra: \"(3\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCatalog([]byte(tt.catalog))
+			if err == nil {
+				err = SetCatalog(c)
+			}
+			assert.Error(t, err)
+		})
+	}
+}
+
+// writeFileAtomically replaces the file at once, as for a mounted ConfigMap
+func writeFileAtomically(t *testing.T, path string, data string) {
+	require.NoError(t, ioutil.WriteFile(path+".tmp", []byte(data), 0644))
+	require.NoError(t, os.Rename(path+".tmp", path))
+}
+
+func Test_WatchCatalogFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "catalog")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer SetCatalog(nil)
+
+	path := filepath.Join(dir, "catalog.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte("servers: []\n"), 0644))
+	require.NoError(t, LoadCatalogFile(path))
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go WatchCatalogFile(path, 10*time.Millisecond, stop)
+
+	writeFileAtomically(t, path, testCatalog)
+	assert.Eventually(t, func() bool { return IsOssVersionSupported("4.2.0") }, 5*time.Second, 10*time.Millisecond)
+
+	// A catalog that is not valid is not used
+	writeFileAtomically(t, path, "servers: {")
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, IsOssVersionSupported("4.2.0"))
+}
+
+const testDigest = "sha256:2b8fd9751c6d1d6e1b4e2d1b0f7b1d1e9b6a9c0b5a1f7c1e4d1a8f2c3b4d5e6f"
+
+func Test_PinDigest(t *testing.T) {
+	require.NoError(t, SetCatalog(&Catalog{
+		Digests: map[string]string{
+			"datastax/cassandra-mgmtapi-3_11_7:v0.1.13": testDigest,
+			"datastax/cassandra-mgmtapi:3.11.9":         testDigest,
+			"docker.io/busybox:1.32.0-uclibc":           testDigest,
+		},
+	}))
+	defer SetCatalog(nil)
+
+	image, err := GetCassandraImage("cassandra", "3.11.7")
+	assert.NoError(t, err)
+	assert.Equal(t, "datastax/cassandra-mgmtapi-3_11_7:v0.1.13@"+testDigest, image)
+
+	// Fallback image
+	image, err = GetCassandraImage("cassandra", "3.11.9")
+	assert.NoError(t, err)
+	assert.Equal(t, "datastax/cassandra-mgmtapi:3.11.9@"+testDigest, image)
+
+	// Without a digest
+	image, err = GetCassandraImage("cassandra", "3.11.6")
+	assert.NoError(t, err)
+	assert.Equal(t, "datastax/cassandra-mgmtapi-3_11_6:v0.1.5", image)
+
+	// The registry of the catalog key is left out
+	assert.Equal(t, "busybox:1.32.0-uclibc@"+testDigest, GetSystemLoggerImage())
+
+	// Already pinned
+	assert.Equal(t, "busybox:1.32.0-uclibc@sha256:0000", PinDigest("busybox:1.32.0-uclibc@sha256:0000"))
+
+	ref, digest := SplitDigest(GetSystemLoggerImage())
+	assert.Equal(t, "busybox:1.32.0-uclibc", ref)
+	assert.Equal(t, testDigest, digest)
+	ref, digest = SplitDigest("busybox:1.32.0-uclibc")
+	assert.Equal(t, "busybox:1.32.0-uclibc", ref)
+	assert.Empty(t, digest)
+}
+
+func Test_PinDigestRegistryOverride(t *testing.T) {
+	restore, err := tempSetEnv(envDefaultRegistryOverride, "localhost:5000")
+	require.NoError(t, err)
+	defer restore()
+
+	require.NoError(t, SetCatalog(&Catalog{
+		Digests: map[string]string{"datastax/cassandra-mgmtapi-3_11_7:v0.1.13": testDigest},
+	}))
+	defer SetCatalog(nil)
+
+	image, err := GetCassandraImage("cassandra", "3.11.7")
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost:5000/datastax/cassandra-mgmtapi-3_11_7:v0.1.13@"+testDigest, image)
+}
+
+func Test_InvalidDigests(t *testing.T) {
+	for _, digests := range []map[string]string{
+		{"busybox:1.32.0-uclibc": "latest"},
+		{"busybox:1.32.0-uclibc": "sha256:1234"},
+		{"busybox:1.32.0-uclibc@" + testDigest: testDigest},
+	} {
+		assert.Error(t, SetCatalog(&Catalog{Digests: digests}))
+	}
+}
@@ -6,7 +6,6 @@ package images
 import (
 	"fmt"
 	"os"
-	"regexp"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
@@ -14,14 +13,19 @@ import (
 )
 
 var ValidDsePrefixes = []string{"6.8"}
-var ValidOssPrefixes = []string{"3.11", "4.0"}
+var ValidOssPrefixes = []string{"3.11", "4.0", "4.1", "5.0"}
+
+// ConfigBuilderOssPrefixes are the Cassandra versions the config builder can
+// render the config of. The config of later versions is rendered by the
+// operator.
+var ConfigBuilderOssPrefixes = []string{"3.11", "4.0"}
 
 const (
 	envDefaultRegistryOverride            = "DEFAULT_CONTAINER_REGISTRY_OVERRIDE"
 	envDefaultRegistryOverridePullSecrets = "DEFAULT_CONTAINER_REGISTRY_OVERRIDE_PULL_SECRETS"
 	EnvBaseImageOS                        = "BASE_IMAGE_OS"
 	ValidDseVersionRegexp                 = "6\\.8\\.\\d+"
-	ValidOssVersionRegexp                 = "(3\\.11\\.\\d+)|(4\\.0\\.\\d+)"
+	ValidOssVersionRegexp                 = "(3\\.11\\.\\d+)|(4\\.0\\.\\d+)|(4\\.1\\.\\d+)|(5\\.0\\.\\d+)"
 	UbiImageSuffix                        = "-ubi7"
 )
 
@@ -37,6 +41,9 @@ const (
 //    - versionToUBIOSSCassandra
 //    - versionToUBIDSE
 //
+// Cassandra/DSE images can also be added without a new build of the
+// operator, through the image catalog (see catalog.go).
+//
 
 type Image int
 
@@ -134,19 +141,17 @@ var versionToUBIDSE map[string]Image = map[string]Image{
 var log = logf.Log.WithName("images")
 
 func IsDseVersionSupported(version string) bool {
-	validVersions := regexp.MustCompile(ValidDseVersionRegexp)
-	return validVersions.MatchString(version)
+	return isVersionSupported("dse", version)
 }
 
 func IsOssVersionSupported(version string) bool {
-	validVersions := regexp.MustCompile(ValidOssVersionRegexp)
-	return validVersions.MatchString(version)
+	return isVersionSupported("cassandra", version)
 }
 
 func stripRegistry(image string) string {
 	comps := strings.Split(image, "/")
 
-	if len(comps) > 1 && strings.Contains(comps[0], ".") || strings.Contains(comps[0], ":") {
+	if len(comps) > 1 && (strings.Contains(comps[0], ".") || strings.Contains(comps[0], ":")) {
 		return strings.Join(comps[1:], "/")
 	} else {
 		return image
@@ -192,7 +197,7 @@ func GetImage(name Image) string {
 	}
 
 	if image != "" {
-		return applyDefaultRegistryOverride(image)
+		return PinDigest(applyDefaultRegistryOverride(image))
 	} else {
 		return ""
 	}
@@ -208,27 +213,20 @@ func shouldUseUBI() bool {
 }
 
 func GetCassandraImage(serverType, version string) (string, error) {
-	var imageKey Image
-	var found bool
-
-	dseMap := versionToDSE
-	cassandraMap := versionToOSSCassandra
-
-	if shouldUseUBI() {
-		dseMap = versionToUBIDSE
-		cassandraMap = versionToUBIOSSCassandra
+	if serverType != "dse" && serverType != "cassandra" {
+		return "", fmt.Errorf("Unknown server type '%s'", serverType)
 	}
 
-	switch serverType {
-	case "dse":
-		imageKey, found = dseMap[version]
-	case "cassandra":
-		imageKey, found = cassandraMap[version]
-	default:
-		return "", fmt.Errorf("Unknown server type '%s'", serverType)
+	image := ""
+	if server, found := currentCatalog().servers[serverKey(serverType, version)]; found {
+		if shouldUseUBI() {
+			image = server.UBIImage
+		} else {
+			image = server.Image
+		}
 	}
 
-	if !found {
+	if image == "" {
 		// For fallback images, just return the image name directly
 		fallbackImageName := ""
 
@@ -246,19 +244,26 @@ func GetCassandraImage(serverType, version string) (string, error) {
 		}
 
 		if shouldUseUBI() {
-			return fmt.Sprintf("%s%s", fallbackImageName, UbiImageSuffix), nil
+			fallbackImageName = fmt.Sprintf("%s%s", fallbackImageName, UbiImageSuffix)
 		}
 
-		return fallbackImageName, nil
+		return PinDigest(fallbackImageName), nil
 	}
 
-	return GetImage(imageKey), nil
+	return PinDigest(applyDefaultRegistryOverride(image)), nil
 }
 
 func GetConfigBuilderImage() string {
+	c := currentCatalog()
 	if shouldUseUBI() {
+		if c.ubiConfigBuilderImage != "" {
+			return PinDigest(applyDefaultRegistryOverride(c.ubiConfigBuilderImage))
+		}
 		return GetImage(UBIConfigBuilder)
 	} else {
+		if c.configBuilderImage != "" {
+			return PinDigest(applyDefaultRegistryOverride(c.configBuilderImage))
+		}
 		return GetImage(ConfigBuilder)
 	}
 }
@@ -284,3 +289,41 @@ func AddDefaultRegistryImagePullSecrets(podSpec *corev1.PodSpec) bool {
 	}
 	return false
 }
+
+// OverrideImage replaces the registry and the repository of an image, and
+// keeps its tag. An empty registry or repository keeps the one of the image.
+// The image is pinned again to the digest of its new reference in the image
+// catalog, if it has one.
+func OverrideImage(image, registry, repository string) string {
+	if registry == "" && repository == "" {
+		return image
+	}
+
+	name, _ := SplitDigest(image)
+
+	imageRegistry := ""
+	if comps := strings.SplitN(name, "/", 2); len(comps) == 2 &&
+		(strings.Contains(comps[0], ".") || strings.Contains(comps[0], ":") || comps[0] == "localhost") {
+		imageRegistry = comps[0]
+		name = comps[1]
+	}
+
+	imageRepository := name
+	tag := ""
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		imageRepository = name[:i]
+		tag = name[i:]
+	}
+
+	if registry != "" {
+		imageRegistry = strings.TrimSuffix(registry, "/")
+	}
+	if repository != "" {
+		imageRepository = repository
+	}
+
+	if imageRegistry != "" {
+		return PinDigest(imageRegistry + "/" + imageRepository + tag)
+	}
+	return PinDigest(imageRepository + tag)
+}
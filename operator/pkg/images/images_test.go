@@ -85,3 +85,56 @@ func Test_CalculateDockerImageRunsAsCassandra(t *testing.T) {
 		assert.Equal(t, got, tt.want, fmt.Sprintf("Version: %s should not have returned %v", tt.version, got))
 	}
 }
+
+func Test_OssVersions(t *testing.T) {
+	tests := []struct {
+		version       string
+		image         string
+		configBuilder bool
+	}{
+		{"3.11.7", "datastax/cassandra-mgmtapi-3_11_7:v0.1.13", true},
+		{"3.11.9", "datastax/cassandra-mgmtapi:3.11.9", true},
+		{"4.0.1", "datastax/cassandra-mgmtapi:4.0.1", true},
+		{"4.1.3", "datastax/cassandra-mgmtapi:4.1.3", false},
+		{"5.0.0", "datastax/cassandra-mgmtapi:5.0.0", false},
+	}
+	for _, tt := range tests {
+		assert.True(t, IsOssVersionSupported(tt.version), "version %s", tt.version)
+		image, err := GetCassandraImage("cassandra", tt.version)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.image, image)
+		assert.Equal(t, tt.configBuilder, IsConfigBuilderCompatible("cassandra", tt.version), "version %s", tt.version)
+	}
+
+	for _, version := range []string{"3.0.22", "4.2.0", "5.1.0"} {
+		assert.False(t, IsOssVersionSupported(version), "version %s", version)
+	}
+}
+
+func Test_DefaultRegistryOverrideWithoutRepository(t *testing.T) {
+	restore, err := tempSetEnv(envDefaultRegistryOverride, "localhost:5000")
+	require.NoError(t, err)
+	defer restore()
+
+	assert.Equal(t, "localhost:5000/busybox:1.32.0-uclibc", GetImage(BusyBox))
+}
+
+func Test_OverrideImage(t *testing.T) {
+	tests := []struct {
+		image      string
+		registry   string
+		repository string
+		want       string
+	}{
+		{"busybox:1.32.0-uclibc", "", "", "busybox:1.32.0-uclibc"},
+		{"busybox:1.32.0-uclibc", "registry.example.com", "", "registry.example.com/busybox:1.32.0-uclibc"},
+		{"localhost:5000/datastax/dse-server:6.8.4", "registry.example.com/", "", "registry.example.com/datastax/dse-server:6.8.4"},
+		{"localhost:5000/datastax/dse-server:6.8.4", "", "team/dse", "localhost:5000/team/dse:6.8.4"},
+		{"datastax/dse-server:6.8.4", "registry.example.com", "team/dse", "registry.example.com/team/dse:6.8.4"},
+		{"datastax/dse-server", "registry.example.com", "", "registry.example.com/datastax/dse-server"},
+		{"busybox:1.32.0-uclibc@sha256:0000", "", "team/busybox", "team/busybox:1.32.0-uclibc"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, OverrideImage(tt.image, tt.registry, tt.repository))
+	}
+}
@@ -133,6 +133,9 @@ func filterPodsByRackName(pods []*corev1.Pod, rackName string) []*corev1.Pod {
 //
 type EMMServiceImpl struct {
 	EMMSPI
+
+	// NodeMaintenance replaces the VMware PSP taints when set
+	NodeMaintenance *api.NodeMaintenanceConfig
 }
 
 func (impl *EMMServiceImpl) getPodPVCSelectedNodeName(podName string) (string, error) {
@@ -173,7 +176,7 @@ func (impl *EMMServiceImpl) cleanupEMMAnnotations() (bool, error) {
 	nodes = utils.UnionStringSet(nodes, nodes2)
 
 	// Strip EMM failure annotation from pods where node is no longer tainted
-	podsFailedEmm := impl.getPodsWithAnnotationKey(EMMFailureAnnotation)
+	podsFailedEmm := impl.getPodsWithAnnotationKey(impl.getFailureAnnotation())
 	nodesWithPodsFailedEMM := utils.GetPodNodeNameSet(podsFailedEmm)
 	nodesNoLongerEMM := utils.SubtractStringSet(
 		nodesWithPodsFailedEMM,
@@ -182,7 +185,7 @@ func (impl *EMMServiceImpl) cleanupEMMAnnotations() (bool, error) {
 	podsNoLongerFailed := utils.FilterPodsWithNodeInNameSet(podsFailedEmm, nodesNoLongerEMM)
 	didUpdate := false
 	for _, pod := range podsNoLongerFailed {
-		err := impl.removePodAnnotation(pod, EMMFailureAnnotation)
+		err := impl.removePodAnnotation(pod, impl.getFailureAnnotation())
 		if err != nil {
 			return false, err
 		}
@@ -204,13 +207,16 @@ func (impl *EMMServiceImpl) emmFailureStillProcessing() (bool, error) {
 	nodes = utils.UnionStringSet(nodes, nodes2)
 
 	// Strip EMM failure annotation from pods where node is no longer tainted
-	podsFailedEmm := impl.getPodsWithAnnotationKey(EMMFailureAnnotation)
+	podsFailedEmm := impl.getPodsWithAnnotationKey(impl.getFailureAnnotation())
 	nodesWithPodsFailedEMM := utils.GetPodNodeNameSet(podsFailedEmm)
 
 	return len(utils.IntersectionStringSet(nodes, nodesWithPodsFailedEMM)) > 0, nil
 }
 
 func (impl *EMMServiceImpl) getPlannedDownTimeNodeNameSet() (utils.StringSet, error) {
+	if impl.NodeMaintenance != nil {
+		return impl.getNodeMaintenanceNameSet(api.NodeMaintenancePlannedDowntime)
+	}
 	nodes, err := impl.getNodesWithTaintKeyValueEffect(EMMTaintKey, string(PlannedDowntime), corev1.TaintEffectNoSchedule)
 	if err != nil {
 		return nil, err
@@ -219,6 +225,9 @@ func (impl *EMMServiceImpl) getPlannedDownTimeNodeNameSet() (utils.StringSet, er
 }
 
 func (impl *EMMServiceImpl) getEvacuateAllDataNodeNameSet() (utils.StringSet, error) {
+	if impl.NodeMaintenance != nil {
+		return impl.getNodeMaintenanceNameSet(api.NodeMaintenanceEvacuateAllData)
+	}
 	nodes, err := impl.getNodesWithTaintKeyValueEffect(EMMTaintKey, string(EvacuateAllData), corev1.TaintEffectNoSchedule)
 	if err != nil {
 		return nil, err
@@ -258,7 +267,7 @@ func (impl *EMMServiceImpl) failEMM(nodeName string, failure EMMFailure) (bool,
 	pods := impl.getPodsForNodeName(nodeName)
 	didUpdate := false
 	for _, pod := range pods {
-		added, err := impl.addPodAnnotation(pod, EMMFailureAnnotation, string(failure))
+		added, err := impl.addPodAnnotation(pod, impl.getFailureAnnotation(), string(failure))
 		if err != nil {
 			return false, err
 		}
@@ -268,6 +277,9 @@ func (impl *EMMServiceImpl) failEMM(nodeName string, failure EMMFailure) (bool,
 }
 
 func (impl *EMMServiceImpl) getNodeNameSet() (utils.StringSet, error) {
+	if impl.NodeMaintenance != nil {
+		return impl.getSchedulableNodeNameSet()
+	}
 	nodes, err := impl.EMMSPI.GetAllNodes()
 	if err != nil {
 		return nil, err
@@ -547,7 +559,7 @@ func checkNodeEMM(provider EMMService) result.ReconcileResult {
 		updated := false
 		for node := range unavailableNodes {
 			if updated, err = provider.failEMM(node, NotEnoughResources); err != nil {
-				logger.Error(err, "Failed to add "+EMMFailureAnnotation, "Node", node)
+				logger.Error(err, "Failed to add EMM failure annotation", "Node", node)
 				return result.Error(err)
 			}
 			anyUpdated = anyUpdated || updated
@@ -0,0 +1,95 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+// Outside of VMware PSP, the EMM engine is driven by the nodeMaintenance
+// settings of the datacenter. Cordoned nodes, and nodes carrying the
+// configured taint, are handled the way nodes with the PSP drain taint are,
+// with the same per-rack availability rules. A failed operation is reported
+// with its own annotation on the pods of the node, and holds until the node
+// is uncordoned or untainted.
+
+package psp
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/datastax/cass-operator/operator/internal/result"
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/utils"
+)
+
+const NodeMaintenanceFailureAnnotation = "cassandra.datastax.com/node-maintenance-failure"
+
+func isNodeCordoned(node *corev1.Node) bool {
+	if node.Spec.Unschedulable {
+		return true
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == corev1.TaintNodeUnschedulable {
+			return true
+		}
+	}
+	return false
+}
+
+// getNodeMaintenanceMode returns how the node is to be handled, or an empty
+// string if it is not in maintenance. The maintenance taint takes precedence
+// over a cordon.
+func getNodeMaintenanceMode(config *api.NodeMaintenanceConfig, node *corev1.Node) api.NodeMaintenanceMode {
+	if config.TaintKey != "" {
+		for _, taint := range node.Spec.Taints {
+			if taint.Key != config.TaintKey || taint.Effect != corev1.TaintEffectNoSchedule {
+				continue
+			}
+			if taint.Value == string(EvacuateAllData) {
+				return api.NodeMaintenanceEvacuateAllData
+			}
+			return api.NodeMaintenancePlannedDowntime
+		}
+	}
+
+	if isNodeCordoned(node) {
+		if config.CordonMode == "" {
+			return api.NodeMaintenancePlannedDowntime
+		}
+		return config.CordonMode
+	}
+
+	return ""
+}
+
+func (impl *EMMServiceImpl) getNodeMaintenanceNameSet(mode api.NodeMaintenanceMode) (utils.StringSet, error) {
+	nodes, err := impl.GetAllNodesInDC()
+	if err != nil {
+		return nil, err
+	}
+	nodes = utils.FilterNodesWithFn(nodes, func(node *corev1.Node) bool {
+		return getNodeMaintenanceMode(impl.NodeMaintenance, node) == mode
+	})
+	return utils.GetNodeNameSet(nodes), nil
+}
+
+func (impl *EMMServiceImpl) getSchedulableNodeNameSet() (utils.StringSet, error) {
+	nodes, err := impl.GetAllNodes()
+	if err != nil {
+		return nil, err
+	}
+	nodes = utils.FilterNodesWithFn(nodes, func(node *corev1.Node) bool {
+		return !isNodeCordoned(node)
+	})
+	return utils.GetNodeNameSet(nodes), nil
+}
+
+func (impl *EMMServiceImpl) getFailureAnnotation() string {
+	if impl.NodeMaintenance != nil {
+		return NodeMaintenanceFailureAnnotation
+	}
+	return EMMFailureAnnotation
+}
+
+func CheckNodeMaintenance(spi EMMSPI, config *api.NodeMaintenanceConfig) result.ReconcileResult {
+	service := &EMMServiceImpl{EMMSPI: spi, NodeMaintenance: config}
+	logger := service.getLogger()
+	logger.Info("psp::CheckNodeMaintenance")
+	return checkNodeEMM(service)
+}
@@ -0,0 +1,102 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package psp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/utils"
+)
+
+func maintenanceNode(name string, unschedulable bool, taints ...corev1.Taint) *corev1.Node {
+	node := &corev1.Node{}
+	node.Name = name
+	node.Spec.Unschedulable = unschedulable
+	node.Spec.Taints = taints
+	return node
+}
+
+func Test_getNodeMaintenanceMode(t *testing.T) {
+	config := &api.NodeMaintenanceConfig{TaintKey: "example.com/maintenance"}
+
+	cordonTaint := corev1.Taint{Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule}
+	drainTaint := corev1.Taint{Key: config.TaintKey, Value: "drain", Effect: corev1.TaintEffectNoSchedule}
+	downtimeTaint := corev1.Taint{Key: config.TaintKey, Value: "reboot", Effect: corev1.TaintEffectNoSchedule}
+	noExecuteTaint := corev1.Taint{Key: config.TaintKey, Value: "drain", Effect: corev1.TaintEffectNoExecute}
+
+	tests := []struct {
+		name string
+		node *corev1.Node
+		want api.NodeMaintenanceMode
+	}{
+		{"healthy", maintenanceNode("n", false), ""},
+		{"cordoned", maintenanceNode("n", true), api.NodeMaintenancePlannedDowntime},
+		{"cordon taint", maintenanceNode("n", false, cordonTaint), api.NodeMaintenancePlannedDowntime},
+		{"drain taint", maintenanceNode("n", true, drainTaint), api.NodeMaintenanceEvacuateAllData},
+		{"other taint value", maintenanceNode("n", false, downtimeTaint), api.NodeMaintenancePlannedDowntime},
+		{"other taint effect", maintenanceNode("n", false, noExecuteTaint), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, getNodeMaintenanceMode(config, tt.node))
+		})
+	}
+
+	config = &api.NodeMaintenanceConfig{CordonMode: api.NodeMaintenanceEvacuateAllData}
+	require.Equal(t, api.NodeMaintenanceEvacuateAllData, getNodeMaintenanceMode(config, maintenanceNode("n", true)))
+	require.Equal(t, api.NodeMaintenanceMode(""), getNodeMaintenanceMode(config, maintenanceNode("n", false, drainTaint)),
+		"a taint only counts when its key is configured")
+}
+
+func Test_nodeMaintenanceNameSets(t *testing.T) {
+	testObj := &MockEMMSPI{}
+	service := &EMMServiceImpl{
+		EMMSPI:          testObj,
+		NodeMaintenance: &api.NodeMaintenanceConfig{},
+	}
+
+	nodes := []*corev1.Node{
+		maintenanceNode("node1", true),
+		maintenanceNode("node2", false),
+		// The VMware taints are ignored outside of PSP
+		evacuateDataNode("node3"),
+	}
+	testObj.On("GetAllNodesInDC").Return(nodes, nil)
+	testObj.On("GetAllNodes").Return(nodes, nil)
+
+	plannedDowntime, err := service.getPlannedDownTimeNodeNameSet()
+	require.NoError(t, err)
+	require.Equal(t, utils.StringSet{"node1": true}, plannedDowntime)
+
+	evacuateAllData, err := service.getEvacuateAllDataNodeNameSet()
+	require.NoError(t, err)
+	require.Empty(t, evacuateAllData)
+
+	schedulable, err := service.getNodeNameSet()
+	require.NoError(t, err)
+	require.Equal(t, utils.StringSet{"node2": true, "node3": true}, schedulable)
+}
+
+func Test_failNodeMaintenance(t *testing.T) {
+	testObj := &MockEMMSPI{}
+	service := &EMMServiceImpl{
+		EMMSPI:          testObj,
+		NodeMaintenance: &api.NodeMaintenanceConfig{},
+	}
+
+	pod := pod("pod1", "node1")
+	testObj.On("GetDCPods").Return([]*corev1.Pod{pod})
+	testObj.On("UpdatePod", pod).Return(nil)
+
+	changed, err := service.failEMM("node1", TooManyExistingFailures)
+	testObj.AssertExpectations(t)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, string(TooManyExistingFailures), pod.Annotations[NodeMaintenanceFailureAnnotation])
+	require.NotContains(t, pod.Annotations, EMMFailureAnnotation)
+}
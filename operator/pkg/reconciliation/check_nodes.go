@@ -237,3 +237,11 @@ func (rc *ReconciliationContext) IsStopped() bool {
 func (rc *ReconciliationContext) IsInitialized() bool {
 	return rc.Datacenter.GetConditionStatus(api.DatacenterInitialized) == corev1.ConditionTrue
 }
+
+// HasClusterData returns true if the nodes of the datacenter are part of the
+// cluster already, because the datacenter was initialized or it adopted the
+// PVCs retained by a previous datacenter of the same name
+func (rc *ReconciliationContext) HasClusterData() bool {
+	return rc.IsInitialized() ||
+		rc.Datacenter.GetConditionStatus(api.DatacenterAdoptedPVCs) == corev1.ConditionTrue
+}
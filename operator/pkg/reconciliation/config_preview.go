@@ -0,0 +1,147 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"gopkg.in/yaml.v2"
+	appsv1 "k8s.io/api/apps/v1"
+
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/utils"
+)
+
+// RackConfigPreview describes what a change of a CassandraDatacenter does to
+// the config and the statefulset of one of its racks
+type RackConfigPreview struct {
+	RackName string `json:"rackName"`
+	// The config given to the server-config-init container of the rack, as
+	// returned by GetRackConfigAsJSON
+	ConfigData json.RawMessage `json:"configData"`
+	// Unified diff between the YAML of the current and the desired statefulset
+	// of the rack, empty if they are the same
+	StatefulSetDiff string `json:"statefulSetDiff,omitempty"`
+	// True if the pods of the rack are restarted to apply the change
+	RollingRestart bool `json:"rollingRestart"`
+	// The settings applied to the running nodes instead of restarting them
+	HotAppliedSettings []string `json:"hotAppliedSettings,omitempty"`
+	// True if the rack is not in the current datacenter, or if there is no
+	// current datacenter
+	NewRack bool `json:"newRack,omitempty"`
+}
+
+// PreviewConfigChange computes, for each rack of the desired datacenter, the
+// config and the statefulset the operator would build for it, and how they
+// differ from the ones of the current datacenter. The current datacenter may
+// be nil to only get the config of the racks.
+func PreviewConfigChange(current, desired *api.CassandraDatacenter) ([]RackConfigPreview, error) {
+	currentRacks := utils.StringSet{}
+	if current != nil {
+		for _, rack := range current.GetRacks() {
+			currentRacks[rack.Name] = true
+		}
+	}
+
+	previews := []RackConfigPreview{}
+	for _, rack := range desired.GetRacks() {
+		configData, err := desired.GetRackConfigAsJSON(rack.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		desiredSts, err := newStatefulSetForCassandraDatacenter(rack.Name, desired, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		preview := RackConfigPreview{
+			RackName:   rack.Name,
+			ConfigData: json.RawMessage(configData),
+		}
+
+		var currentSts *appsv1.StatefulSet
+		if currentRacks[rack.Name] {
+			currentSts, err = newStatefulSetForCassandraDatacenter(rack.Name, current, 0)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			preview.NewRack = true
+		}
+
+		if current != nil {
+			preview.StatefulSetDiff, err = diffStatefulSets(currentSts, desiredSts)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if currentSts != nil && !utils.ResourcesHaveSameHash(currentSts, desiredSts) {
+			// Same as CheckRackPodTemplate, which does not apply the config at
+			// runtime for a canary upgrade
+			changes, ok := getStatefulSetRuntimeChanges(currentSts, desiredSts)
+			if ok && !desired.Spec.CanaryUpgrade {
+				preview.HotAppliedSettings = changes
+			} else {
+				preview.RollingRestart = !reflect.DeepEqual(currentSts.Spec.Template, desiredSts.Spec.Template)
+			}
+		}
+
+		previews = append(previews, preview)
+	}
+
+	return previews, nil
+}
+
+// diffStatefulSets returns the unified diff between the YAML of two
+// statefulsets, leaving out their hash annotation. A nil statefulset is
+// diffed as an empty document.
+func diffStatefulSets(current, desired *appsv1.StatefulSet) (string, error) {
+	currentYaml, err := statefulSetYaml(current)
+	if err != nil {
+		return "", err
+	}
+	desiredYaml, err := statefulSetYaml(desired)
+	if err != nil {
+		return "", err
+	}
+	if currentYaml == desiredYaml {
+		return "", nil
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(currentYaml),
+		B:        difflib.SplitLines(desiredYaml),
+		FromFile: "current",
+		ToFile:   "desired",
+		Context:  3,
+	})
+}
+
+func statefulSetYaml(sts *appsv1.StatefulSet) (string, error) {
+	if sts == nil {
+		return "", nil
+	}
+	sts = sts.DeepCopy()
+	utils.RemoveHashAnnotation(sts)
+
+	// The JSON of the statefulset is decoded as YAML so that the fields are
+	// named and left out as in the API
+	data, err := json.Marshal(sts)
+	if err != nil {
+		return "", err
+	}
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return "", err
+	}
+	out, err := yaml.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,130 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func previewDatacenter(config string, racks ...string) *api.CassandraDatacenter {
+	storageClassName := "standard"
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dc1",
+			Namespace: "test",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "cluster1",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.7",
+			Size:          3,
+			Config:        []byte(config),
+			StorageConfig: api.StorageConfig{
+				CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+					StorageClassName: &storageClassName,
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{"storage": resource.MustParse("1Gi")},
+					},
+				},
+			},
+		},
+	}
+	for _, rack := range racks {
+		dc.Spec.Racks = append(dc.Spec.Racks, api.Rack{Name: rack})
+	}
+	return dc
+}
+
+func TestPreviewConfigChange(t *testing.T) {
+	current := previewDatacenter(`{"cassandra-yaml": {"concurrent_compactors": 2}}`, "r1", "r2")
+
+	tests := []struct {
+		name           string
+		desired        *api.CassandraDatacenter
+		rollingRestart bool
+		hotApplied     []string
+		diff           []string
+	}{
+		{
+			name:    "no change",
+			desired: current.DeepCopy(),
+		},
+		{
+			name:       "runtime setting",
+			desired:    previewDatacenter(`{"cassandra-yaml": {"concurrent_compactors": 4}}`, "r1", "r2"),
+			hotApplied: []string{"concurrent_compactors"},
+			diff:       []string{`-          value: '{"cassandra-yaml":{"concurrent_compactors":2}`, `+          value: '{"cassandra-yaml":{"concurrent_compactors":4}`},
+		},
+		{
+			name:           "setting needing a restart",
+			desired:        previewDatacenter(`{"cassandra-yaml": {"concurrent_compactors": 2, "concurrent_reads": 64}}`, "r1", "r2"),
+			rollingRestart: true,
+			diff:           []string{`"concurrent_reads":64`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previews, err := PreviewConfigChange(current, tt.desired)
+			assert.NoError(t, err)
+			assert.Len(t, previews, 2)
+
+			for i, preview := range previews {
+				configData, err := tt.desired.GetRackConfigAsJSON(preview.RackName)
+				assert.NoError(t, err)
+				assert.Equal(t, current.Spec.Racks[i].Name, preview.RackName)
+				assert.JSONEq(t, configData, string(preview.ConfigData))
+				assert.False(t, preview.NewRack)
+				assert.Equal(t, tt.rollingRestart, preview.RollingRestart)
+				assert.Equal(t, tt.hotApplied, preview.HotAppliedSettings)
+				if len(tt.diff) == 0 {
+					assert.Empty(t, preview.StatefulSetDiff)
+				}
+				for _, line := range tt.diff {
+					assert.Contains(t, preview.StatefulSetDiff, line)
+				}
+				assert.NotContains(t, preview.StatefulSetDiff, "resource-hash")
+			}
+		})
+	}
+}
+
+func TestPreviewConfigChange_NewRack(t *testing.T) {
+	current := previewDatacenter(`{}`, "r1")
+	desired := previewDatacenter(`{}`, "r1", "r2")
+
+	previews, err := PreviewConfigChange(current, desired)
+	assert.NoError(t, err)
+	assert.Len(t, previews, 2)
+	assert.False(t, previews[0].NewRack)
+	assert.Empty(t, previews[0].StatefulSetDiff)
+	assert.True(t, previews[1].NewRack)
+	assert.False(t, previews[1].RollingRestart)
+	assert.Contains(t, previews[1].StatefulSetDiff, "+  name: cluster1-dc1-r2-sts\n")
+
+	// Without a current datacenter only the config is previewed
+	previews, err = PreviewConfigChange(nil, desired)
+	assert.NoError(t, err)
+	assert.Len(t, previews, 2)
+	assert.True(t, previews[0].NewRack)
+	assert.Empty(t, previews[0].StatefulSetDiff)
+}
+
+func TestPreviewConfigChange_CanaryUpgrade(t *testing.T) {
+	current := previewDatacenter(`{"cassandra-yaml": {"concurrent_compactors": 2}}`, "r1")
+	desired := previewDatacenter(`{"cassandra-yaml": {"concurrent_compactors": 4}}`, "r1")
+	desired.Spec.CanaryUpgrade = true
+
+	previews, err := PreviewConfigChange(current, desired)
+	assert.NoError(t, err)
+	assert.True(t, previews[0].RollingRestart)
+	assert.Empty(t, previews[0].HotAppliedSettings)
+}
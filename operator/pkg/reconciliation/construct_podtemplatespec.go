@@ -14,6 +14,7 @@ import (
 	"github.com/datastax/cass-operator/operator/pkg/httphelper"
 	"github.com/datastax/cass-operator/operator/pkg/images"
 	"github.com/datastax/cass-operator/operator/pkg/oplabels"
+	"github.com/datastax/cass-operator/operator/pkg/serverconfig"
 	"github.com/datastax/cass-operator/operator/pkg/utils"
 
 	corev1 "k8s.io/api/core/v1"
@@ -27,8 +28,30 @@ const (
 	CassandraContainerName               = "cassandra"
 	PvcName                              = "server-data"
 	SystemLoggerContainerName            = "server-system-logger"
+	ServerConfigFilesVolumeName          = "server-config-files"
+	ExternalAddressVolumeName            = "external-address"
 )
 
+// configFilesScript copies the config files rendered by the operator to the
+// server-config volume, with the addresses of the pod filled in
+var configFilesScript = fmt.Sprintf(`BROADCAST_IP="$POD_IP"
+if [ "$USE_HOST_IP_FOR_BROADCAST" = "true" ]; then BROADCAST_IP="$HOST_IP"; fi
+for f in /config-files/*; do
+  sed -e "s/%s/$POD_IP/g" -e "s/%s/$BROADCAST_IP/g" "$f" > "/config/$(basename "$f")"
+done`, serverconfig.PodIPPlaceholder, serverconfig.BroadcastIPPlaceholder)
+
+// externalAccessConfigFilesScript is the configFilesScript of the pods with
+// external access. It waits for the operator to annotate the pod with the
+// address of its service, which the external-address volume follows.
+var externalAccessConfigFilesScript = fmt.Sprintf(`until [ -s /external-address/address ]; do
+  echo "Waiting for the external address of the pod"
+  sleep 5
+done
+EXTERNAL_ADDRESS="$(cat /external-address/address)"
+for f in /config-files/*; do
+  sed -e "s/%s/$POD_IP/g" -e "s/%s/$POD_IP/g" -e "s/%s/$EXTERNAL_ADDRESS/g" "$f" > "/config/$(basename "$f")"
+done`, serverconfig.PodIPPlaceholder, serverconfig.BroadcastIPPlaceholder, serverconfig.ExternalAddressPlaceholder)
+
 // calculateNodeAffinity provides a way to decide where to schedule pods within a statefulset based on labels
 func calculateNodeAffinity(labels map[string]string) *corev1.NodeAffinity {
 	if len(labels) == 0 {
@@ -93,6 +116,113 @@ func calculatePodAntiAffinity(allowMultipleNodesPerWorker bool) *corev1.PodAntiA
 	}
 }
 
+// mergeNodeAffinity combines the node affinity of a rack with the one
+// calculated from the node affinity labels. Kubernetes ORs the required node
+// selector terms, so the calculated requirements are added to every term of
+// the rack for both to apply.
+func mergeNodeAffinity(calculated *corev1.NodeAffinity, rack *corev1.NodeAffinity) *corev1.NodeAffinity {
+	if rack == nil {
+		return calculated
+	}
+	merged := rack.DeepCopy()
+	if calculated == nil {
+		return merged
+	}
+
+	required := merged.RequiredDuringSchedulingIgnoredDuringExecution
+	if required == nil || len(required.NodeSelectorTerms) == 0 {
+		merged.RequiredDuringSchedulingIgnoredDuringExecution = calculated.RequiredDuringSchedulingIgnoredDuringExecution.DeepCopy()
+	} else {
+		calculatedTerm := calculated.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0]
+		for i := range required.NodeSelectorTerms {
+			term := &required.NodeSelectorTerms[i]
+			term.MatchExpressions = append(term.MatchExpressions, calculatedTerm.MatchExpressions...)
+		}
+	}
+
+	return merged
+}
+
+// mergePodAntiAffinity adds the pod anti-affinity terms of a rack to the
+// calculated ones
+func mergePodAntiAffinity(calculated *corev1.PodAntiAffinity, rack *corev1.PodAntiAffinity) *corev1.PodAntiAffinity {
+	if rack == nil {
+		return calculated
+	}
+	merged := rack.DeepCopy()
+	if calculated == nil {
+		return merged
+	}
+
+	merged.RequiredDuringSchedulingIgnoredDuringExecution = append(
+		calculated.DeepCopy().RequiredDuringSchedulingIgnoredDuringExecution,
+		merged.RequiredDuringSchedulingIgnoredDuringExecution...)
+	merged.PreferredDuringSchedulingIgnoredDuringExecution = append(
+		calculated.DeepCopy().PreferredDuringSchedulingIgnoredDuringExecution,
+		merged.PreferredDuringSchedulingIgnoredDuringExecution...)
+
+	return merged
+}
+
+// mergePodAffinity adds the pod affinity terms of a rack to the ones of the
+// pod template
+func mergePodAffinity(template *corev1.PodAffinity, rack *corev1.PodAffinity) *corev1.PodAffinity {
+	if rack == nil {
+		return template
+	}
+	merged := rack.DeepCopy()
+	if template == nil {
+		return merged
+	}
+
+	merged.RequiredDuringSchedulingIgnoredDuringExecution = append(
+		template.DeepCopy().RequiredDuringSchedulingIgnoredDuringExecution,
+		merged.RequiredDuringSchedulingIgnoredDuringExecution...)
+	merged.PreferredDuringSchedulingIgnoredDuringExecution = append(
+		template.DeepCopy().PreferredDuringSchedulingIgnoredDuringExecution,
+		merged.PreferredDuringSchedulingIgnoredDuringExecution...)
+
+	return merged
+}
+
+// addRackScheduling adds the tolerations, affinity, topology spread
+// constraints and priority class of the rack to the pod template
+func addRackScheduling(dc *api.CassandraDatacenter, rack *api.Rack, baseTemplate *corev1.PodTemplateSpec) {
+	if rack.Affinity != nil {
+		affinity := baseTemplate.Spec.Affinity
+		affinity.NodeAffinity = mergeNodeAffinity(affinity.NodeAffinity, rack.Affinity.NodeAffinity)
+		affinity.PodAntiAffinity = mergePodAntiAffinity(affinity.PodAntiAffinity, rack.Affinity.PodAntiAffinity)
+		affinity.PodAffinity = mergePodAffinity(affinity.PodAffinity, rack.Affinity.PodAffinity)
+	}
+
+	for _, toleration := range rack.Tolerations {
+		found := false
+		for _, existing := range baseTemplate.Spec.Tolerations {
+			if reflect.DeepEqual(existing, toleration) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			baseTemplate.Spec.Tolerations = append(baseTemplate.Spec.Tolerations, *toleration.DeepCopy())
+		}
+	}
+
+	for _, constraint := range rack.TopologySpreadConstraints {
+		constraint := constraint.DeepCopy()
+		if constraint.LabelSelector == nil {
+			constraint.LabelSelector = &metav1.LabelSelector{
+				MatchLabels: dc.GetRackLabels(rack.Name),
+			}
+		}
+		baseTemplate.Spec.TopologySpreadConstraints = append(baseTemplate.Spec.TopologySpreadConstraints, *constraint)
+	}
+
+	if rack.PriorityClassName != "" {
+		baseTemplate.Spec.PriorityClassName = rack.PriorityClassName
+	}
+}
+
 func selectorFromFieldPath(fieldPath string) *corev1.EnvVarSource {
 	return &corev1.EnvVarSource{
 		FieldRef: &corev1.ObjectFieldSelector{
@@ -271,16 +401,92 @@ func buildInitContainers(dc *api.CassandraDatacenter, rackName string, baseTempl
 
 	serverCfg.Name = ServerConfigContainerName
 
-	if serverCfg.Image == "" {
-		serverCfg.Image = dc.GetConfigBuilderImage()
+	configData, err := dc.GetRackConfigAsJSON(rackName)
+	if err != nil {
+		return err
+	}
+
+	// A config builder image set in the pod template spec selects the config
+	// builder as well
+	renderConfigFiles := serverCfg.Image == "" && dc.UsesConfigRenderer()
+	if renderConfigFiles {
+		_, err := serverconfig.RenderConfigFiles([]byte(configData), dc.Spec.ServerType, dc.Spec.ServerVersion, rackName)
+		if serverconfig.IsNotSupported(err) {
+			if !images.IsConfigBuilderCompatible(dc.Spec.ServerType, dc.Spec.ServerVersion) {
+				return fmt.Errorf("the config builder does not support %s %s: %v",
+					dc.Spec.ServerType, dc.Spec.ServerVersion, err)
+			}
+			renderConfigFiles = false
+		} else if err != nil {
+			return err
+		}
+	}
+	if !renderConfigFiles && dc.IsExternalAccessEnabled() {
+		return fmt.Errorf("external access needs the config files rendered by the operator")
 	}
 
 	serverCfgMount := corev1.VolumeMount{
 		Name:      "server-config",
 		MountPath: "/config",
 	}
+	serverCfgMounts := []corev1.VolumeMount{serverCfgMount}
+
+	if renderConfigFiles {
+		// The files are rendered into the config map of the rack, the init
+		// container only fills in the addresses of the pod. The config map is
+		// kept up to date with the pod template by the reconcile.
+		serverCfg.Image = dc.ApplyImageConfig(images.GetSystemLoggerImage(), dc.GetImageRepositories().BusyBox)
+		if len(serverCfg.Command) == 0 {
+			script := configFilesScript
+			if dc.IsExternalAccessEnabled() {
+				script = externalAccessConfigFilesScript
+			}
+			serverCfg.Command = []string{"/bin/sh", "-c", script}
+		}
+
+		serverCfgMounts = append(serverCfgMounts, corev1.VolumeMount{
+			Name:      ServerConfigFilesVolumeName,
+			MountPath: "/config-files",
+		})
+
+		configFilesVolume := corev1.Volume{
+			Name: ServerConfigFilesVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: getRackConfigMapName(dc, rackName),
+					},
+				},
+			},
+		}
+		baseTemplate.Spec.Volumes = combineVolumeSlices([]corev1.Volume{configFilesVolume}, baseTemplate.Spec.Volumes)
+
+		if dc.IsExternalAccessEnabled() {
+			serverCfgMounts = append(serverCfgMounts, corev1.VolumeMount{
+				Name:      ExternalAddressVolumeName,
+				MountPath: "/external-address",
+			})
+
+			externalAddressVolume := corev1.Volume{
+				Name: ExternalAddressVolumeName,
+				VolumeSource: corev1.VolumeSource{
+					DownwardAPI: &corev1.DownwardAPIVolumeSource{
+						Items: []corev1.DownwardAPIVolumeFile{{
+							Path: "address",
+							FieldRef: &corev1.ObjectFieldSelector{
+								FieldPath: fmt.Sprintf("metadata.annotations['%s']", api.ExternalAddressAnnotation),
+							},
+						}},
+					},
+				},
+			}
+			baseTemplate.Spec.Volumes = combineVolumeSlices([]corev1.Volume{externalAddressVolume}, baseTemplate.Spec.Volumes)
+		}
+	} else if serverCfg.Image == "" {
+		serverCfg.Image = dc.GetConfigBuilderImage()
+	}
 
-	serverCfg.VolumeMounts = combineVolumeMountSlices([]corev1.VolumeMount{serverCfgMount}, serverCfg.VolumeMounts)
+	serverCfg.VolumeMounts = combineVolumeMountSlices(serverCfgMounts, serverCfg.VolumeMounts)
 
 	serverCfg.Resources = *getResourcesOrDefault(&dc.Spec.ConfigBuilderResources, &DefaultsConfigInitContainer)
 
@@ -290,13 +496,10 @@ func buildInitContainers(dc *api.CassandraDatacenter, rackName string, baseTempl
 		useHostIpForBroadcast = "true"
 	}
 
-	configData, err := dc.GetConfigAsJSON()
-	if err != nil {
-		return err
-	}
-
 	serverVersion := dc.Spec.ServerVersion
 
+	// When the operator renders the config files, the config map of the rack
+	// is rendered from these env vars, so that it follows the pod template
 	envDefaults := []corev1.EnvVar{
 		{Name: "CONFIG_FILE_DATA", Value: configData},
 		{Name: "POD_IP", ValueFrom: selectorFromFieldPath("status.podIP")},
@@ -449,9 +652,9 @@ func buildContainers(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTempla
 	if loggerContainer.Image == "" {
 		specImage := dc.Spec.SystemLoggerImage
 		if specImage != "" {
-			loggerContainer.Image = specImage
+			loggerContainer.Image = images.PinDigest(specImage)
 		} else {
-			loggerContainer.Image = images.GetSystemLoggerImage()
+			loggerContainer.Image = dc.ApplyImageConfig(images.GetSystemLoggerImage(), dc.GetImageRepositories().SystemLogger)
 		}
 	}
 
@@ -527,7 +730,7 @@ func buildPodTemplateSpec(dc *api.CassandraDatacenter, nodeAffinityLabels map[st
 
 	// Adds custom registry pull secret if needed
 
-	_ = images.AddDefaultRegistryImagePullSecrets(&baseTemplate.Spec)
+	addImagePullSecrets(dc, &baseTemplate.Spec)
 
 	// Labels
 
@@ -554,8 +757,26 @@ func buildPodTemplateSpec(dc *api.CassandraDatacenter, nodeAffinityLabels map[st
 	affinity := &corev1.Affinity{}
 	affinity.NodeAffinity = calculateNodeAffinity(nodeAffinityLabels)
 	affinity.PodAntiAffinity = calculatePodAntiAffinity(dc.Spec.AllowMultipleNodesPerWorker)
+	if baseTemplate.Spec.Affinity != nil {
+		// The operator has no pod affinity of its own to replace the
+		// template's with
+		affinity.PodAffinity = baseTemplate.Spec.Affinity.PodAffinity
+	}
 	baseTemplate.Spec.Affinity = affinity
 
+	// Scheduling
+
+	if dc.Spec.PriorityClassName != "" {
+		baseTemplate.Spec.PriorityClassName = dc.Spec.PriorityClassName
+	}
+
+	for _, rack := range dc.GetRacks() {
+		if rack.Name == rackName {
+			addRackScheduling(dc, &rack, baseTemplate)
+			break
+		}
+	}
+
 	// Volumes
 
 	addVolumes(dc, baseTemplate)
@@ -574,5 +795,46 @@ func buildPodTemplateSpec(dc *api.CassandraDatacenter, nodeAffinityLabels map[st
 		return nil, err
 	}
 
+	setPinnedImagesPullPolicy(baseTemplate)
+
 	return baseTemplate, nil
 }
+
+// addImagePullSecrets adds to a pod spec the secrets to pull the images of the
+// datacenter. The secret of the registry of the operator is left out when the
+// datacenter has its own registry.
+func addImagePullSecrets(dc *api.CassandraDatacenter, podSpec *corev1.PodSpec) {
+	imageConfig := dc.Spec.ImageConfig
+	if imageConfig == nil || imageConfig.Registry == "" {
+		_ = images.AddDefaultRegistryImagePullSecrets(podSpec)
+	}
+	if imageConfig == nil {
+		return
+	}
+
+	for _, secret := range imageConfig.ImagePullSecrets {
+		found := false
+		for _, existing := range podSpec.ImagePullSecrets {
+			if existing.Name == secret.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, secret)
+		}
+	}
+}
+
+// setPinnedImagesPullPolicy sets the pull policy of the containers whose image
+// is pinned by digest, and that have none, to IfNotPresent. The content of
+// these images cannot change, so a node never has to pull them again.
+func setPinnedImagesPullPolicy(baseTemplate *corev1.PodTemplateSpec) {
+	for _, containers := range [][]corev1.Container{baseTemplate.Spec.InitContainers, baseTemplate.Spec.Containers} {
+		for i := range containers {
+			if containers[i].ImagePullPolicy == "" && images.IsPinned(containers[i].Image) {
+				containers[i].ImagePullPolicy = corev1.PullIfNotPresent
+			}
+		}
+	}
+}
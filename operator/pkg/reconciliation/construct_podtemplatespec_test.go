@@ -10,9 +10,11 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/images"
 	"github.com/datastax/cass-operator/operator/pkg/oplabels"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func Test_calculatePodAntiAffinity(t *testing.T) {
@@ -152,6 +154,223 @@ func TestCassandraDatacenter_buildInitContainer_with_overrides(t *testing.T) {
 	}
 }
 
+func TestCassandraDatacenter_buildInitContainer_rendered_config_files(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dc1",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:       "bob",
+			ServerType:        "cassandra",
+			ServerVersion:     "3.11.7",
+			UseConfigRenderer: true,
+		},
+	}
+
+	podTemplateSpec := corev1.PodTemplateSpec{}
+	err := buildInitContainers(dc, "testRack", &podTemplateSpec)
+	assert.NoError(t, err)
+
+	initContainers := podTemplateSpec.Spec.InitContainers
+	assert.Len(t, initContainers, 1)
+	assert.Equal(t, images.GetSystemLoggerImage(), initContainers[0].Image)
+	assert.Equal(t, []string{"/bin/sh", "-c", configFilesScript}, initContainers[0].Command)
+	assert.Contains(t, initContainers[0].VolumeMounts,
+		corev1.VolumeMount{Name: ServerConfigFilesVolumeName, MountPath: "/config-files"})
+
+	assert.Len(t, podTemplateSpec.Spec.Volumes, 1)
+	assert.Equal(t, ServerConfigFilesVolumeName, podTemplateSpec.Spec.Volumes[0].Name)
+	assert.Equal(t, "dc1-testRack-config", podTemplateSpec.Spec.Volumes[0].ConfigMap.Name)
+
+	// An invalid config fails the pod template
+	dc.Spec.Config = []byte(`{"jvm-options": {"garbage_collector": "ZGC"}}`)
+	err = buildInitContainers(dc, "testRack", &corev1.PodTemplateSpec{})
+	assert.EqualError(t, err, "unknown garbage_collector ZGC, must be one of CMS, G1GC")
+}
+
+func TestCassandraDatacenter_buildInitContainer_external_access(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dc1",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:       "bob",
+			ServerType:        "cassandra",
+			ServerVersion:     "4.0.0",
+			UseConfigRenderer: true,
+			Networking: &api.NetworkingConfig{
+				ExternalAccess: &api.ExternalAccessConfig{},
+			},
+		},
+	}
+
+	podTemplateSpec := corev1.PodTemplateSpec{}
+	err := buildInitContainers(dc, "testRack", &podTemplateSpec)
+	assert.NoError(t, err)
+
+	initContainers := podTemplateSpec.Spec.InitContainers
+	assert.Len(t, initContainers, 1)
+	assert.Equal(t, []string{"/bin/sh", "-c", externalAccessConfigFilesScript}, initContainers[0].Command)
+	assert.Contains(t, initContainers[0].Command[2], "@EXTERNAL_ADDRESS@")
+	assert.Contains(t, initContainers[0].VolumeMounts,
+		corev1.VolumeMount{Name: ExternalAddressVolumeName, MountPath: "/external-address"})
+
+	assert.Len(t, podTemplateSpec.Spec.Volumes, 2)
+	var externalAddressVolume *corev1.Volume
+	for i := range podTemplateSpec.Spec.Volumes {
+		if podTemplateSpec.Spec.Volumes[i].Name == ExternalAddressVolumeName {
+			externalAddressVolume = &podTemplateSpec.Spec.Volumes[i]
+		}
+	}
+	if assert.NotNil(t, externalAddressVolume) && assert.NotNil(t, externalAddressVolume.DownwardAPI) {
+		assert.Equal(t, []corev1.DownwardAPIVolumeFile{{
+			Path: "address",
+			FieldRef: &corev1.ObjectFieldSelector{
+				FieldPath: "metadata.annotations['cassandra.datastax.com/external-address']",
+			},
+		}}, externalAddressVolume.DownwardAPI.Items)
+	}
+
+	// The config builder cannot fill in the address
+	podTemplateSpec = corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{
+				Name:  ServerConfigContainerName,
+				Image: "datastax/cass-config-builder:1.0.3",
+			}},
+		},
+	}
+	err = buildInitContainers(dc, "testRack", &podTemplateSpec)
+	assert.EqualError(t, err, "external access needs the config files rendered by the operator")
+}
+
+func TestCassandraDatacenter_buildInitContainer_config_builder(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(dc *api.CassandraDatacenter)
+	}{
+		{
+			name: "DSE",
+			setup: func(dc *api.CassandraDatacenter) {
+				dc.Spec.ServerType = "dse"
+				dc.Spec.ServerVersion = "6.8.4"
+			},
+		},
+		{
+			name: "Config section without renderer",
+			setup: func(dc *api.CassandraDatacenter) {
+				dc.Spec.UseConfigRenderer = true
+				dc.Spec.Config = []byte(`{"logback-xml": {"root-log-level": "DEBUG"}}`)
+			},
+		},
+		{
+			name: "Default",
+			setup: func(dc *api.CassandraDatacenter) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc := &api.CassandraDatacenter{
+				Spec: api.CassandraDatacenterSpec{
+					ClusterName:   "bob",
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.7",
+				},
+			}
+			tt.setup(dc)
+
+			podTemplateSpec := corev1.PodTemplateSpec{}
+			err := buildInitContainers(dc, "testRack", &podTemplateSpec)
+			assert.NoError(t, err)
+
+			initContainers := podTemplateSpec.Spec.InitContainers
+			assert.Len(t, initContainers, 1)
+			assert.Equal(t, images.GetConfigBuilderImage(), initContainers[0].Image)
+			assert.Empty(t, initContainers[0].Command)
+			assert.Empty(t, podTemplateSpec.Spec.Volumes)
+		})
+	}
+}
+
+func TestCassandraDatacenter_buildInitContainer_config_builder_not_compatible(t *testing.T) {
+	catalog, err := images.ParseCatalog([]byte(`
+servers:
+- serverType: cassandra
+  version: 3.11.7
+  image: example.com/cassandra:3.11.7
+  configBuilder: false
+`))
+	assert.NoError(t, err)
+	assert.NoError(t, images.SetCatalog(catalog))
+	defer images.SetCatalog(nil)
+
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.7",
+		},
+	}
+
+	// The operator renders the config files it supports
+	err = buildInitContainers(dc, "testRack", &corev1.PodTemplateSpec{})
+	assert.NoError(t, err)
+
+	// Others cannot fall back to the config builder
+	dc.Spec.Config = []byte(`{"logback-xml": {"root-log-level": "DEBUG"}}`)
+	err = buildInitContainers(dc, "testRack", &corev1.PodTemplateSpec{})
+	assert.EqualError(t, err, "the config builder does not support cassandra 3.11.7: "+
+		"config files cannot be rendered by the operator: config section logback-xml")
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_image_config(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dc1",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:       "bob",
+			ServerType:        "cassandra",
+			ServerVersion:     "3.11.7",
+			UseConfigRenderer: true,
+			Reaper: &api.ReaperConfig{
+				Enabled: true,
+			},
+			ImageConfig: &api.ImageConfig{
+				Registry: "registry.example.com",
+				Repositories: api.ImageRepositories{
+					Server:       "team/cassandra",
+					SystemLogger: "team/logger",
+					BusyBox:      "team/busybox",
+				},
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "team-registry"}},
+			},
+		},
+	}
+
+	podTemplateSpec, err := buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err)
+
+	imagesByContainer := map[string]string{}
+	for _, containers := range [][]corev1.Container{podTemplateSpec.Spec.InitContainers, podTemplateSpec.Spec.Containers} {
+		for _, container := range containers {
+			imagesByContainer[container.Name] = container.Image
+		}
+	}
+	assert.Equal(t, map[string]string{
+		ServerConfigContainerName: "registry.example.com/team/busybox:1.32.0-uclibc",
+		CassandraContainerName:    "registry.example.com/team/cassandra:v0.1.13",
+		SystemLoggerContainerName: "registry.example.com/team/logger:1.32.0-uclibc",
+		ReaperContainerName:       "registry.example.com/thelastpickle/cassandra-reaper:2.0.5",
+	}, imagesByContainer)
+	assert.Equal(t, []corev1.LocalObjectReference{{Name: "team-registry"}}, podTemplateSpec.Spec.ImagePullSecrets)
+
+	job := buildInitReaperSchemaJob(dc)
+	assert.Equal(t, "registry.example.com/jsanda/reaper-init-keyspace:latest", job.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, []corev1.LocalObjectReference{{Name: "team-registry"}}, job.Spec.Template.Spec.ImagePullSecrets)
+}
+
 func TestCassandraDatacenter_buildContainers_systemlogger_resources_set(t *testing.T) {
 	dc := &api.CassandraDatacenter{
 		Spec: api.CassandraDatacenterSpec{
@@ -832,3 +1051,96 @@ func TestCassandraDatacenter_buildContainers_EnableSystemLoggerSidecar_CustomIma
 
 	assert.Equal(t, "alpine", podTemplateSpec.Spec.Containers[1].Image)
 }
+
+func TestCassandraDatacenter_buildPodTemplateSpec_rack_scheduling(t *testing.T) {
+	templateToleration := corev1.Toleration{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "cassandra"}
+	rackToleration := corev1.Toleration{Key: "pool", Operator: corev1.TolerationOpEqual, Value: "rack1"}
+	rackNodeAffinity := &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+				MatchExpressions: []corev1.NodeSelectorRequirement{{
+					Key:      "node-pool",
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   []string{"a", "b"},
+				}},
+			}},
+		},
+	}
+	templatePodAffinityTerm := corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "cache"}},
+		TopologyKey:   "kubernetes.io/hostname",
+	}
+	rackPodAffinityTerm := corev1.WeightedPodAffinityTerm{
+		Weight: 50,
+		PodAffinityTerm: corev1.PodAffinityTerm{
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "client"}},
+			TopologyKey:   zoneLabel,
+		},
+	}
+
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:       "bob",
+			ServerType:        "cassandra",
+			ServerVersion:     "3.11.7",
+			PriorityClassName: "dc-priority",
+			PodTemplateSpec: &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Tolerations: []corev1.Toleration{templateToleration},
+					Affinity: &corev1.Affinity{
+						PodAffinity: &corev1.PodAffinity{
+							RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{templatePodAffinityTerm},
+						},
+					},
+				},
+			},
+			Racks: []api.Rack{{
+				Name:              "rack1",
+				Tolerations:       []corev1.Toleration{templateToleration, rackToleration},
+				Affinity: &corev1.Affinity{
+					NodeAffinity: rackNodeAffinity,
+					PodAffinity: &corev1.PodAffinity{
+						PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{rackPodAffinityTerm},
+					},
+				},
+				PriorityClassName: "rack-priority",
+				TopologySpreadConstraints: []corev1.TopologySpreadConstraint{{
+					MaxSkew:           1,
+					TopologyKey:       "topology.kubernetes.io/zone",
+					WhenUnsatisfiable: corev1.DoNotSchedule,
+				}},
+			}, {
+				Name: "rack2",
+			}},
+		},
+	}
+
+	got, err := buildPodTemplateSpec(dc, map[string]string{zoneLabel: "testzone"}, "rack1")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []corev1.Toleration{templateToleration, rackToleration}, got.Spec.Tolerations)
+	assert.Equal(t, "rack-priority", got.Spec.PriorityClassName)
+
+	terms := got.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	assert.Equal(t, 1, len(terms))
+	assert.Equal(t, []corev1.NodeSelectorRequirement{
+		rackNodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0],
+		{Key: zoneLabel, Operator: corev1.NodeSelectorOpIn, Values: []string{"testzone"}},
+	}, terms[0].MatchExpressions, "both the rack and the zone requirements apply")
+	assert.Equal(t, calculatePodAntiAffinity(false), got.Spec.Affinity.PodAntiAffinity)
+	assert.Equal(t, &corev1.PodAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution:  []corev1.PodAffinityTerm{templatePodAffinityTerm},
+		PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{rackPodAffinityTerm},
+	}, got.Spec.Affinity.PodAffinity, "both the template and the rack pod affinity apply")
+
+	assert.Equal(t, 1, len(got.Spec.TopologySpreadConstraints))
+	assert.Equal(t, dc.GetRackLabels("rack1"), got.Spec.TopologySpreadConstraints[0].LabelSelector.MatchLabels)
+	assert.Nil(t, dc.Spec.Racks[0].TopologySpreadConstraints[0].LabelSelector, "the spec should not be modified")
+
+	got, err = buildPodTemplateSpec(dc, nil, "rack2")
+	assert.NoError(t, err)
+	assert.Equal(t, []corev1.Toleration{templateToleration}, got.Spec.Tolerations)
+	assert.Equal(t, "dc-priority", got.Spec.PriorityClassName)
+	assert.Empty(t, got.Spec.TopologySpreadConstraints)
+	assert.Equal(t, dc.Spec.PodTemplateSpec.Spec.Affinity.PodAffinity, got.Spec.Affinity.PodAffinity)
+}
@@ -140,7 +140,9 @@ func newAdditionalSeedServiceForCassandraDatacenter(dc *api.CassandraDatacenter)
 	return &service
 }
 
-func newEndpointsForAdditionalSeeds(dc *api.CassandraDatacenter) (*corev1.Endpoints, error) {
+// newEndpointsForAdditionalSeeds creates the endpoints of the additional seed service, from the
+// additional seeds of the spec and the seed IPs of the other datacenters of the cluster
+func newEndpointsForAdditionalSeeds(dc *api.CassandraDatacenter, peerSeeds []string) (*corev1.Endpoints, error) {
 	labels := dc.GetDatacenterLabels()
 	oplabels.AddManagedByLabel(labels)
 	endpoints := corev1.Endpoints{}
@@ -148,23 +150,30 @@ func newEndpointsForAdditionalSeeds(dc *api.CassandraDatacenter) (*corev1.Endpoi
 	endpoints.ObjectMeta.Namespace = dc.Namespace
 	endpoints.ObjectMeta.Labels = labels
 
-	addresses := make([]corev1.EndpointAddress, 0, len(dc.Spec.AdditionalSeeds))
+	ips := []string{}
 	for _, additionalSeed := range dc.Spec.AdditionalSeeds {
 		if ip := net.ParseIP(additionalSeed); ip != nil {
-			addresses = append(addresses, corev1.EndpointAddress{
-				IP: additionalSeed,
-			})
+			ips = append(ips, additionalSeed)
 		} else {
 			additionalSeedIPs, err := resolveAddress(additionalSeed)
 			if err != nil {
 				return nil, err
 			}
-			for _, address := range additionalSeedIPs {
-				addresses = append(addresses, corev1.EndpointAddress{
-					IP: address,
-				})
-			}
+			ips = append(ips, additionalSeedIPs...)
+		}
+	}
+	ips = append(ips, peerSeeds...)
+
+	addresses := make([]corev1.EndpointAddress, 0, len(ips))
+	seen := map[string]bool{}
+	for _, ip := range ips {
+		if seen[ip] {
+			continue
 		}
+		seen[ip] = true
+		addresses = append(addresses, corev1.EndpointAddress{
+			IP: ip,
+		})
 	}
 
 	// See: https://godoc.org/k8s.io/api/core/v1#Endpoints
@@ -229,6 +238,38 @@ func newNodePortServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *core
 	return service
 }
 
+// newPodServiceForCassandraDatacenter creates a LoadBalancer service owned by the CassandraDatacenter,
+// which exposes a single pod to clients outside the Kubernetes cluster when external access is enabled
+func newPodServiceForCassandraDatacenter(dc *api.CassandraDatacenter, podName string) *corev1.Service {
+	labels := dc.GetDatacenterLabels()
+	oplabels.AddManagedByLabel(labels)
+	labels[api.PodServiceLabel] = podName
+
+	var service corev1.Service
+	service.ObjectMeta.Name = dc.GetPodServiceName(podName)
+	service.ObjectMeta.Namespace = dc.Namespace
+	service.ObjectMeta.Labels = labels
+	service.ObjectMeta.Annotations = map[string]string{}
+	service.Spec.Selector = map[string]string{
+		"statefulset.kubernetes.io/pod-name": podName,
+	}
+	service.Spec.Type = "LoadBalancer"
+	// The address is given to clients before the node is ready
+	service.Spec.PublishNotReadyAddresses = true
+	service.Spec.LoadBalancerSourceRanges = dc.Spec.Networking.ExternalAccess.LoadBalancerSourceRanges
+
+	service.Spec.Ports = []corev1.ServicePort{
+		namedServicePort("native", api.DefaultNativePort, api.DefaultNativePort),
+		namedServicePort("tls-native", 9142, 9142),
+	}
+
+	addAdditionalOptions(&service, &dc.Spec.AdditionalServiceConfig.PodService)
+
+	utils.AddHashAnnotation(&service)
+
+	return &service
+}
+
 // newAllPodsServiceForCassandraDatacenter creates a headless service owned by the CassandraDatacenter,
 // which covers all server pods in the datacenter, whether they are ready or not
 func newAllPodsServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *corev1.Service {
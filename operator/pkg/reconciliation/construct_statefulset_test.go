@@ -7,7 +7,9 @@ import (
 	"testing"
 
 	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/utils"
 	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -193,4 +195,38 @@ func Test_newStatefulSetForCassandraDatacenterWithAdditionalVolumes(t *testing.T
 		assert.Equal(t, "server-config", got.Spec.Template.Spec.InitContainers[1].VolumeMounts[0].Name)
 		assert.Equal(t, "/config", got.Spec.Template.Spec.InitContainers[1].VolumeMounts[0].MountPath)
 	}
-}
\ No newline at end of file
+}
+func Test_newStatefulSetForCassandraDatacenter_rackConfig(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "c1",
+			StorageConfig: api.StorageConfig{
+				CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{},
+			},
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.7",
+			Config:        []byte(`{"cassandra-yaml":{"concurrent_compactors":2}}`),
+			Racks:         []api.Rack{{Name: "r1"}, {Name: "r2"}},
+		},
+	}
+
+	getStatefulSets := func() (*appsv1.StatefulSet, *appsv1.StatefulSet) {
+		r1, err := newStatefulSetForCassandraDatacenter("r1", dc, 1)
+		assert.NoError(t, err)
+		r2, err := newStatefulSetForCassandraDatacenter("r2", dc, 1)
+		assert.NoError(t, err)
+		return r1, r2
+	}
+
+	r1, r2 := getStatefulSets()
+
+	dc.Spec.Racks[1].Config = []byte(`{"cassandra-yaml":{"concurrent_compactors":8}}`)
+	newR1, newR2 := getStatefulSets()
+	assert.True(t, utils.ResourcesHaveSameHash(r1, newR1), "the statefulset of the other rack should not change")
+	assert.False(t, utils.ResourcesHaveSameHash(r2, newR2), "the statefulset of the rack should change")
+
+	r2 = newR2
+	configEnv := r2.Spec.Template.Spec.InitContainers[0].Env[0]
+	assert.Equal(t, "CONFIG_FILE_DATA", configEnv.Name)
+	assert.Contains(t, configEnv.Value, `"concurrent_compactors":8`)
+}
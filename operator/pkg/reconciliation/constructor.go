@@ -6,10 +6,14 @@ package reconciliation
 // This file defines constructors for k8s objects
 
 import (
+	"fmt"
+
 	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
 	"github.com/datastax/cass-operator/operator/pkg/oplabels"
+	"github.com/datastax/cass-operator/operator/pkg/serverconfig"
 	"github.com/datastax/cass-operator/operator/pkg/utils"
 
+	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	policyv1beta1 "k8s.io/api/policy/v1beta1"
@@ -45,6 +49,90 @@ func newPodDisruptionBudgetForDatacenter(dc *api.CassandraDatacenter) *policyv1b
 	return pdb
 }
 
+// Create a PodDisruptionBudget object for a rack of the Datacenter. As a
+// budget cannot be updated, the name carries the minimum available pods, so
+// that a new budget can be created before the previous one is deleted.
+func newPodDisruptionBudgetForRack(dc *api.CassandraDatacenter, rackName string, minAvailable int) *policyv1beta1.PodDisruptionBudget {
+	minAvailableValue := intstr.FromInt(minAvailable)
+	labels := dc.GetRackLabels(rackName)
+	oplabels.AddManagedByLabel(labels)
+	selectorLabels := dc.GetRackLabels(rackName)
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-%s-pdb-%d", dc.Name, rackName, minAvailable),
+			Namespace:   dc.Namespace,
+			Labels:      labels,
+			Annotations: map[string]string{},
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: selectorLabels,
+			},
+			MinAvailable: &minAvailableValue,
+		},
+	}
+
+	// add a hash here to facilitate checking if updates are needed
+	utils.AddHashAnnotation(pdb)
+
+	return pdb
+}
+
+func getRackConfigMapName(dc *api.CassandraDatacenter, rackName string) string {
+	return dc.Name + "-" + rackName + "-config"
+}
+
+// Create the ConfigMap with the config files rendered by the operator for the
+// pods of a rack. The files are rendered from the env vars of the
+// server-config-init container of the statefulset, so that the pods always
+// start with the config of their pod template. Returns nil if the config
+// files of the statefulset are rendered by the config builder.
+func newConfigMapForRack(dc *api.CassandraDatacenter, statefulSet *appsv1.StatefulSet) (*corev1.ConfigMap, error) {
+	configMapName := ""
+	for _, volume := range statefulSet.Spec.Template.Spec.Volumes {
+		if volume.Name == ServerConfigFilesVolumeName && volume.ConfigMap != nil {
+			configMapName = volume.ConfigMap.Name
+		}
+	}
+	if configMapName == "" {
+		return nil, nil
+	}
+
+	envValue := func(name string) string {
+		if env := getServerConfigEnv(statefulSet, name); env != nil {
+			return env.Value
+		}
+		return ""
+	}
+	rackName := envValue("RACK_NAME")
+
+	files, err := serverconfig.RenderConfigFiles(
+		[]byte(envValue("CONFIG_FILE_DATA")),
+		envValue("PRODUCT_NAME"),
+		envValue("PRODUCT_VERSION"),
+		rackName)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := dc.GetRackLabels(rackName)
+	oplabels.AddManagedByLabel(labels)
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        configMapName,
+			Namespace:   dc.Namespace,
+			Labels:      labels,
+			Annotations: map[string]string{},
+		},
+		Data: files,
+	}
+
+	// add a hash here to facilitate checking if updates are needed
+	utils.AddHashAnnotation(configMap)
+
+	return configMap, nil
+}
+
 func setOperatorProgressStatus(rc *ReconciliationContext, newState api.ProgressState) error {
 	currentState := rc.Datacenter.Status.CassandraOperatorProgress
 	if currentState == newState {
@@ -67,7 +155,7 @@ func setOperatorProgressStatus(rc *ReconciliationContext, newState api.ProgressS
 }
 
 func buildInitReaperSchemaJob(dc *api.CassandraDatacenter) *batchv1.Job {
-	return &batchv1.Job{
+	job := &batchv1.Job{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Job",
 			APIVersion: "batch/v1",
@@ -84,7 +172,7 @@ func buildInitReaperSchemaJob(dc *api.CassandraDatacenter) *batchv1.Job {
 					Containers: []corev1.Container{
 						{
 							Name:            getReaperSchemaInitJobName(dc),
-							Image:           ReaperSchemaInitJobImage,
+							Image:           dc.ApplyImageConfig(ReaperSchemaInitJobImage, dc.GetImageRepositories().ReaperSchemaInit),
 							ImagePullPolicy: corev1.PullIfNotPresent,
 							Env: []corev1.EnvVar{
 								{
@@ -106,4 +194,6 @@ func buildInitReaperSchemaJob(dc *api.CassandraDatacenter) *batchv1.Job {
 			},
 		},
 	}
+	addImagePullSecrets(dc, &job.Spec.Template.Spec)
+	return job
 }
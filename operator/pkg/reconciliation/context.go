@@ -47,6 +47,11 @@ type ReconciliationContext struct {
 	statefulSets           []*appsv1.StatefulSet
 	dcPods                 []*corev1.Pod
 	clusterPods            []*corev1.Pod
+
+	// Other datacenters of the same cluster, oldest first, and the IPs of
+	// their seed nodes
+	peerDatacenters []*api.CassandraDatacenter
+	peerSeeds       []string
 }
 
 // CreateReconciliationContext gathers all information needed for computeReconciliationActions into a struct.
@@ -56,6 +61,7 @@ func CreateReconciliationContext(
 	scheme *runtime.Scheme,
 	rec record.EventRecorder,
 	secretWatches dynamicwatch.DynamicWatches,
+	nodeMgmtBreakers *httphelper.CircuitBreakers,
 	reqLogger logr.Logger) (*ReconciliationContext, error) {
 	
 	rc := &ReconciliationContext{}
@@ -124,6 +130,7 @@ func CreateReconciliationContext(
 		Client:   httpClient,
 		Log:      rc.ReqLogger,
 		Protocol: protocol,
+		Breakers: nodeMgmtBreakers,
 	}
 
 	return rc, nil
@@ -0,0 +1,289 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/datastax/cass-operator/operator/internal/result"
+	"github.com/datastax/cass-operator/operator/pkg/events"
+	"github.com/datastax/cass-operator/operator/pkg/httphelper"
+)
+
+// decommissionDatacenter removes a datacenter that is being deleted from its
+// cluster, when decommissionOnDelete is set. It first drops the datacenter
+// from the replication of every keyspace, then takes its nodes out of the
+// ring one at a time, and finally checks that none of the other datacenters
+// still sees them. Every step is driven through a ready node of the other
+// datacenters and is picked up again on the next reconcile.
+func (rc *ReconciliationContext) decommissionDatacenter() result.ReconcileResult {
+	dc := rc.Datacenter
+	if !dc.Spec.DecommissionOnDelete {
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("decommission_datacenter::decommissionDatacenter")
+
+	if recResult := rc.CheckPeerDatacenters(); recResult.Completed() {
+		return recResult
+	}
+
+	if len(rc.peerDatacenters) == 0 {
+		rc.ReqLogger.Info("Not decommissioning the last datacenter of the cluster")
+		return result.Continue()
+	}
+
+	coordinators, err := rc.peerCoordinatorPods()
+	if err != nil {
+		return result.Error(err)
+	}
+	if len(coordinators) == 0 {
+		rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.DecommissioningDatacenter,
+			"Waiting for a ready node in another datacenter to decommission datacenter %s", dc.Name)
+		return result.RequeueSoon(10)
+	}
+
+	if recResult := rc.removeDatacenterFromReplication(coordinators[0]); recResult.Completed() {
+		return recResult
+	}
+
+	return rc.removeDatacenterNodes(coordinators)
+}
+
+// peerCoordinatorPods returns a ready pod of every other datacenter of the
+// cluster that has one
+func (rc *ReconciliationContext) peerCoordinatorPods() ([]*corev1.Pod, error) {
+	coordinators := []*corev1.Pod{}
+	for _, peer := range rc.peerDatacenters {
+		podList := &corev1.PodList{}
+		err := rc.Client.List(rc.Ctx, podList,
+			client.InNamespace(peer.Namespace),
+			client.MatchingLabels(peer.GetDatacenterLabels()))
+		if err != nil {
+			rc.ReqLogger.Error(err, "error listing pods of peer datacenter",
+				"datacenter", peer.Name)
+			return nil, err
+		}
+
+		for i := range podList.Items {
+			pod := &podList.Items[i]
+			if pod.Status.PodIP != "" && isServerReady(pod) {
+				coordinators = append(coordinators, pod)
+				break
+			}
+		}
+	}
+	return coordinators, nil
+}
+
+func (rc *ReconciliationContext) removeDatacenterFromReplication(coordinator *corev1.Pod) result.ReconcileResult {
+	dc := rc.Datacenter
+
+	keyspaces, err := rc.NodeMgmtClient.CallListKeyspacesEndpoint(coordinator, "")
+	if err != nil {
+		rc.ReqLogger.Error(err, "error listing keyspaces")
+		return result.Error(err)
+	}
+
+	for _, keyspace := range keyspaces {
+		replication, err := rc.NodeMgmtClient.CallGetKeyspaceReplicationEndpoint(coordinator, keyspace)
+		if err != nil {
+			rc.ReqLogger.Error(err, "error getting keyspace replication", "keyspace", keyspace)
+			return result.Error(err)
+		}
+		if _, ok := replication[dc.Name]; !ok {
+			continue
+		}
+
+		settings := []httphelper.ReplicationSetting{}
+		for _, setting := range replicationSettings(replication, "") {
+			if setting.DcName != dc.Name {
+				settings = append(settings, setting)
+			}
+		}
+		if len(settings) == 0 {
+			rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.DecommissioningDatacenter,
+				"Keyspace %s is only replicated to datacenter %s, its data will be lost", keyspace, dc.Name)
+			continue
+		}
+
+		err = rc.NodeMgmtClient.CallAlterKeyspaceEndpoint(coordinator, httphelper.AlterKeyspaceRequest{
+			KeyspaceName:        keyspace,
+			ReplicationSettings: settings,
+		})
+		if err != nil {
+			rc.ReqLogger.Error(err, "error altering keyspace replication", "keyspace", keyspace)
+			return result.Error(err)
+		}
+
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.UpdatedReplication,
+			"Removed datacenter %s from the replication of keyspace %s", dc.Name, keyspace)
+	}
+
+	return result.Continue()
+}
+
+// departingEndpoints returns the nodes of this datacenter that the
+// coordinator still sees in the ring
+func (rc *ReconciliationContext) departingEndpoints(coordinator *corev1.Pod) ([]httphelper.EndpointState, error) {
+	dc := rc.Datacenter
+
+	endpoints, err := rc.NodeMgmtClient.CallMetadataEndpointsEndpoint(coordinator)
+	if err != nil {
+		return nil, err
+	}
+
+	// Older versions of the Management API do not report the datacenter of
+	// an endpoint, the host IDs in the status are used instead
+	hostIDs := map[string]bool{}
+	for _, nodeStatus := range dc.Status.NodeStatuses {
+		hostIDs[nodeStatus.HostID] = true
+	}
+
+	departing := []httphelper.EndpointState{}
+	for _, ep := range endpoints.Entity {
+		if ep.Datacenter != dc.Name && (ep.Datacenter != "" || !hostIDs[ep.HostID]) {
+			continue
+		}
+		if strings.HasPrefix(ep.Status, "LEFT") {
+			continue
+		}
+		departing = append(departing, ep)
+	}
+	return departing, nil
+}
+
+// removeDatacenterNodes decommissions the nodes of the datacenter one at a
+// time. A node whose pod cannot decommission it is assassinated, but only once
+// gossip reports it as down. A failed decommission is not submitted again
+// until the DecommissionFailedAnnotation is removed from the pod.
+func (rc *ReconciliationContext) removeDatacenterNodes(coordinators []*corev1.Pod) result.ReconcileResult {
+	dc := rc.Datacenter
+
+	podList, err := rc.listPods(dc.GetDatacenterLabels())
+	if err != nil {
+		return result.Error(err)
+	}
+
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if jobID, ok := pod.Annotations[DecommissionFailedAnnotation]; ok {
+			rc.ReqLogger.Info("Decommission failed on node, remove the annotation to try again",
+				"pod", pod.Name,
+				"jobID", jobID,
+				"annotation", DecommissionFailedAnnotation)
+			return result.RequeueSoon(30)
+		}
+		if httphelper.GetPodJobID(pod, httphelper.JobTypeDecommission) == "" {
+			continue
+		}
+		job, err := rc.checkJob(pod, httphelper.JobTypeDecommission)
+		if err != nil {
+			return result.Error(err)
+		}
+		if job != nil && !job.IsDone() {
+			return result.RequeueSoon(5)
+		}
+		if job != nil && job.Status == httphelper.JobError {
+			// The failure has been reported through the JobFailed
+			// condition, it is not retried behind the user's back
+			patch := client.MergeFrom(pod.DeepCopy())
+			if pod.Annotations == nil {
+				pod.Annotations = map[string]string{}
+			}
+			pod.Annotations[DecommissionFailedAnnotation] = job.ID
+			if err := rc.Client.Patch(rc.Ctx, pod, patch); err != nil {
+				return result.Error(err)
+			}
+			return result.RequeueSoon(30)
+		}
+	}
+
+	departing, err := rc.departingEndpoints(coordinators[0])
+	if err != nil {
+		rc.ReqLogger.Error(err, "error getting endpoints from peer datacenter")
+		return result.Error(err)
+	}
+
+	if len(departing) > 0 {
+		ep := departing[0]
+		address := ep.GetEndpointAddress()
+		if strings.HasPrefix(ep.Status, "LEAVING") {
+			return result.RequeueSoon(5)
+		}
+
+		pod := rc.findPodForEndpoint(podList.Items, ep)
+		if pod != nil && isServerReady(pod) {
+			err := rc.submitDecommission(pod)
+			if err == nil {
+				rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.DecommissioningDatacenter,
+					"Decommissioning node %s of pod %s", address, pod.Name)
+				return result.RequeueSoon(5)
+			}
+			if !httphelper.IsUnreachable(err) {
+				rc.ReqLogger.Error(err, "error decommissioning node", "pod", pod.Name)
+				return result.Error(err)
+			}
+		}
+
+		// A node that is up, even with its pod not ready for a moment, is
+		// left to be decommissioned once its pod is back
+		if ep.IsAlive == "true" && strings.HasPrefix(ep.Status, "NORMAL") {
+			rc.ReqLogger.Info("Waiting for the pod of a node that is up to decommission it",
+				"address", address)
+			return result.RequeueSoon(10)
+		}
+
+		// Nothing is left to decommission the node from, it can only be
+		// dropped from gossip
+		if err := rc.NodeMgmtClient.CallAssassinateEndpoint(coordinators[0], address); err != nil {
+			rc.ReqLogger.Error(err, "error assassinating node", "address", address)
+			return result.Error(err)
+		}
+		rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.DecommissioningDatacenter,
+			"Assassinated unreachable node %s", address)
+		return result.RequeueSoon(5)
+	}
+
+	for _, coordinator := range coordinators[1:] {
+		departing, err := rc.departingEndpoints(coordinator)
+		if err != nil {
+			rc.ReqLogger.Error(err, "error getting endpoints from peer datacenter")
+			return result.Error(err)
+		}
+		if len(departing) > 0 {
+			rc.ReqLogger.Info("Datacenter nodes are still seen by another datacenter",
+				"pod", coordinator.Name,
+				"nodes", len(departing))
+			return result.RequeueSoon(5)
+		}
+	}
+
+	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.DecommissionedDatacenter,
+		"Removed datacenter %s from the cluster", dc.Name)
+	return result.Continue()
+}
+
+// findPodForEndpoint returns the pod of a node of the datacenter, by the host
+// ID recorded for the pod or else by the address of the node
+func (rc *ReconciliationContext) findPodForEndpoint(pods []corev1.Pod, ep httphelper.EndpointState) *corev1.Pod {
+	dc := rc.Datacenter
+	for i := range pods {
+		if hostID := dc.Status.NodeStatuses[pods[i].Name].HostID; hostID != "" && hostID == ep.HostID {
+			return &pods[i]
+		}
+	}
+	for i := range pods {
+		if dc.Status.NodeStatuses[pods[i].Name].HostID != "" {
+			continue
+		}
+		if findEndpointForPod(dc, &pods[i], []httphelper.EndpointState{ep}) != nil {
+			return &pods[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/datastax/cass-operator/operator/pkg/httphelper/fakemgmtapi"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDecommissionDatacenter(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.CreationTimestamp = metav1.Now()
+	dc.Spec.DecommissionOnDelete = true
+
+	server := setupRebuildTest(rc, 2)
+	for _, pod := range rc.dcPods {
+		server.SetDatacenter(pod.Status.PodIP, dc.Name)
+	}
+
+	peer := makePeerDatacenter(dc, "dc0", dc.Namespace, time.Hour, true)
+	coordinator := makePeerSeedPod(peer, "dc0-pod-0", "10.1.0.1")
+	coordinator.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "cassandra",
+		Ready: true,
+	}}
+	server.AddStartedNode(coordinator.Status.PodIP)
+	server.SetDatacenter(coordinator.Status.PodIP, peer.Name)
+
+	server.SetKeyspace("ks1", map[string]int{dc.Name: 3, peer.Name: 3})
+	server.SetKeyspace("ks2", map[string]int{dc.Name: 3})
+
+	rc.Client = fake.NewFakeClient(dc, peer, coordinator, rc.dcPods[0], rc.dcPods[1])
+
+	// The first node is decommissioned, the second one is gone and can only
+	// be assassinated
+	server.StopNode(rc.dcPods[1].Status.PodIP)
+
+	assert.True(t, rc.decommissionDatacenter().Completed())
+
+	replication, _ := server.Keyspace("ks1")
+	assert.Equal(t, map[string]int{peer.Name: 3}, replication)
+	replication, _ = server.Keyspace("ks2")
+	assert.Equal(t, map[string]int{dc.Name: 3}, replication, "the only datacenter of a keyspace is kept")
+
+	node, _ := server.Node(rc.dcPods[0].Status.PodIP)
+	assert.Equal(t, "LEFT", node.Status)
+
+	assert.True(t, rc.decommissionDatacenter().Completed())
+	node, _ = server.Node(rc.dcPods[1].Status.PodIP)
+	assert.Empty(t, node.Status)
+
+	assert.False(t, rc.decommissionDatacenter().Completed())
+
+	endpoints, err := rc.NodeMgmtClient.CallMetadataEndpointsEndpoint(coordinator)
+	assert.NoError(t, err)
+	for _, ep := range endpoints.Entity {
+		if ep.Datacenter == dc.Name {
+			assert.Equal(t, "LEFT", ep.Status)
+		}
+	}
+}
+
+func TestDecommissionDatacenter_Disabled(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	server := setupRebuildTest(rc, 1)
+
+	// Nothing is done unless decommissionOnDelete is set
+	assert.False(t, rc.decommissionDatacenter().Completed())
+	node, _ := server.Node(rc.dcPods[0].Status.PodIP)
+	assert.Empty(t, node.Calls)
+
+	// Nor is the last datacenter of a cluster taken out of it
+	rc.Datacenter.Spec.DecommissionOnDelete = true
+	assert.False(t, rc.decommissionDatacenter().Completed())
+	node, _ = server.Node(rc.dcPods[0].Status.PodIP)
+	assert.Empty(t, node.Calls)
+}
+
+func setupDecommissionDatacenterTest(rc *ReconciliationContext) (*fakemgmtapi.Server, *corev1.Pod) {
+	dc := rc.Datacenter
+	dc.CreationTimestamp = metav1.Now()
+	dc.Spec.DecommissionOnDelete = true
+
+	server := setupRebuildTest(rc, 1)
+	server.SetDatacenter(rc.dcPods[0].Status.PodIP, dc.Name)
+
+	peer := makePeerDatacenter(dc, "dc0", dc.Namespace, time.Hour, true)
+	coordinator := makePeerSeedPod(peer, "dc0-pod-0", "10.1.0.1")
+	coordinator.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "cassandra",
+		Ready: true,
+	}}
+	server.AddStartedNode(coordinator.Status.PodIP)
+	server.SetDatacenter(coordinator.Status.PodIP, peer.Name)
+
+	rc.Client = fake.NewFakeClient(dc, peer, coordinator, rc.dcPods[0])
+	return server, coordinator
+}
+
+func TestDecommissionDatacenter_NodeUp(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	server, coordinator := setupDecommissionDatacenterTest(rc)
+
+	// The pod is not ready but the node is still up, it is not assassinated
+	pod := rc.dcPods[0]
+	pod.Status.ContainerStatuses[0].Ready = false
+	assert.NoError(t, rc.Client.Update(rc.Ctx, pod))
+
+	assert.True(t, rc.decommissionDatacenter().Completed())
+
+	node, _ := server.Node(pod.Status.PodIP)
+	assert.Equal(t, "NORMAL", node.Status)
+	node, _ = server.Node(coordinator.Status.PodIP)
+	assert.NotContains(t, node.Calls, "POST /api/v0/ops/node/assassinate")
+}
+
+func TestDecommissionDatacenter_Failed(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	server, _ := setupDecommissionDatacenterTest(rc)
+	ip := rc.dcPods[0].Status.PodIP
+	server.FailJobs(ip, "boom")
+
+	// The decommission is submitted and fails
+	assert.True(t, rc.decommissionDatacenter().Completed())
+	assert.True(t, rc.decommissionDatacenter().Completed())
+
+	pod := &corev1.Pod{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: rc.dcPods[0].Name, Namespace: rc.dcPods[0].Namespace}, pod))
+	assert.Contains(t, pod.Annotations, DecommissionFailedAnnotation)
+
+	// It is neither submitted again nor is the node assassinated
+	assert.True(t, rc.decommissionDatacenter().Completed())
+	node, _ := server.Node(ip)
+	assert.Equal(t, "NORMAL", node.Status)
+	decommissions := 0
+	for _, call := range node.Calls {
+		if call == "POST /api/v1/ops/node/decommission" {
+			decommissions++
+		}
+	}
+	assert.Equal(t, 1, decommissions)
+}
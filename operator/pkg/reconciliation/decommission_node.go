@@ -17,6 +17,11 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 )
 
+// DecommissionFailedAnnotation holds the ID of the decommission job that
+// failed on a pod. The operator does not try to decommission the node again
+// until the annotation is removed.
+const DecommissionFailedAnnotation = "cassandra.datastax.com/decommission-failed"
+
 func (rc *ReconciliationContext) CalculateRackInfoForDecomm(currentSize int) ([]*RackInformation, error) {
 	racks := rc.Datacenter.GetRacks()
 	rackCount := len(racks)
@@ -126,7 +131,7 @@ func (rc *ReconciliationContext) DecommissionNodeOnRack(rackName string, epData
 				return err
 			}
 
-			if err := rc.NodeMgmtClient.CallDecommissionNodeEndpoint(pod); err != nil {
+			if err := rc.submitDecommission(pod); err != nil {
 				rc.ReqLogger.Info(fmt.Sprintf("Error from decommission attempt. This is only an attempt and can"+
 					" fail it will be retried later if decomission has not started. Error: %v", err))
 			}
@@ -157,8 +162,44 @@ func (rc *ReconciliationContext) CheckDecommissioningNodes(epData httphelper.Cas
 
 	for _, pod := range rc.dcPods {
 		if pod.Labels[api.CassNodeState] == stateDecommissioning {
-			if !IsDoneDecommissioning(pod, epData) {
-				if !HasStartedDecommissioning(pod, epData) {
+			if jobID, ok := pod.Annotations[DecommissionFailedAnnotation]; ok {
+				rc.ReqLogger.Info("Decommission failed on node, remove the annotation to try again",
+					"pod", pod.Name,
+					"jobID", jobID,
+					"annotation", DecommissionFailedAnnotation)
+				return result.RequeueSoon(30)
+			}
+
+			if httphelper.GetPodJobID(pod, httphelper.JobTypeDecommission) != "" {
+				job, err := rc.checkJob(pod, httphelper.JobTypeDecommission)
+				if err != nil && !httphelper.IsUnreachable(err) {
+					return result.Error(err)
+				}
+				if err == nil {
+					if job.Status == httphelper.JobCompleted {
+						rc.ReqLogger.Info("Node finished decommissioning")
+						if res := rc.cleanUpAfterDecommissionedPod(pod); res != nil {
+							return res
+						}
+					} else if job.Status == httphelper.JobError {
+						// The failure has been reported through the JobFailed
+						// condition, it is not retried behind the user's back
+						patch := client.MergeFrom(pod.DeepCopy())
+						pod.Annotations[DecommissionFailedAnnotation] = job.ID
+						if err := rc.Client.Patch(rc.Ctx, pod, patch); err != nil {
+							return result.Error(err)
+						}
+						return result.RequeueSoon(30)
+					} else {
+						rc.ReqLogger.Info("Node decommissioning, reconciling again soon")
+					}
+					return result.RequeueSoon(5)
+				}
+				rc.ReqLogger.Info("Could not check on decommission job, falling back to gossip", "error", err.Error())
+			}
+
+			if !IsDoneDecommissioning(rc.Datacenter, pod, epData) {
+				if !HasStartedDecommissioning(rc.Datacenter, pod, epData) {
 					rc.ReqLogger.Info("Decommission has not started trying again")
 					if err := rc.NodeMgmtClient.CallDecommissionNodeEndpoint(pod); err != nil {
 						rc.ReqLogger.Info(fmt.Sprintf("Error from decomimssion attempt. This is only an attempt and can fail. Error: %v", err))
@@ -194,6 +235,17 @@ func (rc *ReconciliationContext) CheckDecommissioningNodes(epData httphelper.Cas
 	return result.Continue()
 }
 
+// submitDecommission starts decommissioning the node through the async
+// Management API, or the synchronous one if the node does not support it
+func (rc *ReconciliationContext) submitDecommission(pod *corev1.Pod) error {
+	_, err := rc.submitJob(pod, httphelper.JobTypeDecommission,
+		func(pod *corev1.Pod) (string, error) {
+			return rc.NodeMgmtClient.CallDecommissionNodeAsyncEndpoint(pod, false)
+		},
+		rc.NodeMgmtClient.CallDecommissionNodeEndpoint)
+	return err
+}
+
 func (rc *ReconciliationContext) cleanUpAfterDecommissionedPod(pod *corev1.Pod) result.ReconcileResult {
 	rc.ReqLogger.Info("Scaling down statefulset")
 	err := rc.RemoveDecommissionedPodFromSts(pod)
@@ -218,22 +270,16 @@ func (rc *ReconciliationContext) cleanUpAfterDecommissionedPod(pod *corev1.Pod)
 	return nil
 }
 
-func HasStartedDecommissioning(pod *v1.Pod, epData httphelper.CassMetadataEndpoints) bool {
-	for idx := range epData.Entity {
-		ep := &epData.Entity[idx]
-		if ep.GetRpcAddress() == pod.Status.PodIP {
-			return strings.HasPrefix(ep.Status, "LEAVING")
-		}
+func HasStartedDecommissioning(dc *api.CassandraDatacenter, pod *v1.Pod, epData httphelper.CassMetadataEndpoints) bool {
+	if ep := findEndpointForPod(dc, pod, epData.Entity); ep != nil {
+		return strings.HasPrefix(ep.Status, "LEAVING")
 	}
 	return false
 }
 
-func IsDoneDecommissioning(pod *v1.Pod, epData httphelper.CassMetadataEndpoints) bool {
-	for idx := range epData.Entity {
-		ep := &epData.Entity[idx]
-		if ep.GetRpcAddress() == pod.Status.PodIP {
-			return strings.HasPrefix(ep.Status, "LEFT")
-		}
+func IsDoneDecommissioning(dc *api.CassandraDatacenter, pod *v1.Pod, epData httphelper.CassMetadataEndpoints) bool {
+	if ep := findEndpointForPod(dc, pod, epData.Entity); ep != nil {
+		return strings.HasPrefix(ep.Status, "LEFT")
 	}
 
 	// If we got here, we could not find endpoint metadata on the node.
@@ -369,7 +415,7 @@ func (rc *ReconciliationContext) EnsurePodsCanAbsorbDecommData(decommPod *v1.Pod
 
 func (rc *ReconciliationContext) GetUsedStorageForPods(epData httphelper.CassMetadataEndpoints) (map[string]float64, error) {
 	podStorageMap := make(map[string]float64)
-	mappedData := MapPodsToEndpointDataByName(rc.dcPods, epData)
+	mappedData := MapPodsToEndpointDataByName(rc.Datacenter, rc.dcPods, epData)
 	for podName, data := range mappedData {
 		load, err := strconv.ParseFloat(data.Load, 64)
 		if err != nil {
@@ -5,22 +5,30 @@ package reconciliation
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/datastax/cass-operator/operator/internal/result"
 	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/events"
 	"github.com/datastax/cass-operator/operator/pkg/httphelper"
+	"github.com/datastax/cass-operator/operator/pkg/httphelper/fakemgmtapi"
 	"github.com/datastax/cass-operator/operator/pkg/mocks"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -155,3 +163,169 @@ func (s *statusMock) Patch(ctx context.Context, obj runtime.Object, patch client
 	s.called = s.called + 1
 	return nil
 }
+
+func TestCheckDecommissioningNodes_JobFailed(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.SetCondition(api.DatacenterCondition{
+		Status: v1.ConditionTrue,
+		Type:   api.DatacenterScalingDown,
+	})
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req.URL.Path == "/api/v0/ops/executor/job" &&
+					req.URL.Query().Get("job_id") == "decommission-job"
+			})).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"id": "decommission-job", "status": "ERROR", "error": "boom"}`)),
+		}, nil).
+		Once()
+
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{
+		Client:   mockHttpClient,
+		Log:      rc.ReqLogger,
+		Protocol: "http",
+	}
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod-1",
+			Namespace: rc.Datacenter.Namespace,
+			Labels: map[string]string{
+				api.CassNodeState: stateDecommissioning,
+			},
+			Annotations: map[string]string{
+				httphelper.JobAnnotation(httphelper.JobTypeDecommission): "decommission-job",
+			},
+		},
+		Status: v1.PodStatus{
+			PodIP: "192.168.101.11",
+		},
+	}
+	rc.Client = fake.NewFakeClient(rc.Datacenter, pod.DeepCopy())
+	rc.dcPods = []*v1.Pod{pod}
+
+	r := rc.CheckDecommissioningNodes(httphelper.CassMetadataEndpoints{})
+	assert.Equal(t, result.RequeueSoon(30), r)
+	mockHttpClient.AssertExpectations(t)
+
+	condition, ok := rc.Datacenter.GetCondition(api.DatacenterJobFailed)
+	assert.True(t, ok)
+	assert.Equal(t, v1.ConditionTrue, condition.Status)
+	assert.Equal(t, "DecommissionFailed", condition.Reason)
+	assert.Contains(t, condition.Message, "boom")
+
+	// The failed job is recorded on the pod instead of being tracked
+	assert.Equal(t, "", httphelper.GetPodJobID(pod, httphelper.JobTypeDecommission))
+	assert.Equal(t, "decommission-job", pod.Annotations[DecommissionFailedAnnotation])
+
+	recorder := rc.Recorder.(*record.FakeRecorder)
+	assert.Contains(t, <-recorder.Events, events.JobFailed)
+
+	// The decommission is not tried again, neither through the Management
+	// API nor from gossip
+	r = rc.CheckDecommissioningNodes(httphelper.CassMetadataEndpoints{
+		Entity: []httphelper.EndpointState{{
+			RpcAddress: "192.168.101.11",
+			Status:     "NORMAL",
+		}},
+	})
+	assert.Equal(t, result.RequeueSoon(30), r)
+	mockHttpClient.AssertExpectations(t)
+}
+
+func TestScaleDown_WithFakeMgmtApi(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.Size = 1
+
+	sts, err := newStatefulSetForCassandraDatacenter("default", rc.Datacenter, 2)
+	assert.NoError(t, err)
+
+	server := fakemgmtapi.NewServer()
+	server.DecommissionPolls = 2
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{
+		Client:   server,
+		Log:      rc.ReqLogger,
+		Protocol: "http",
+	}
+
+	trackObjects := []runtime.Object{rc.Datacenter, sts}
+	var pods []*v1.Pod
+	for i := 0; i < 2; i++ {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-%d", sts.Name, i),
+				Namespace: rc.Datacenter.Namespace,
+				Labels: map[string]string{
+					api.RackLabel:     "default",
+					api.CassNodeState: stateStarted,
+				},
+			},
+			Status: v1.PodStatus{
+				PodIP: fmt.Sprintf("10.0.0.%d", i+1),
+				ContainerStatuses: []v1.ContainerStatus{{
+					Name: "cassandra",
+					State: v1.ContainerState{
+						Running: &v1.ContainerStateRunning{
+							StartedAt: metav1.NewTime(time.Now().Add(-time.Minute)),
+						},
+					},
+				}},
+			},
+		}
+		pvc := &v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "server-data-" + pod.Name,
+				Namespace: rc.Datacenter.Namespace,
+			},
+			Spec: v1.PersistentVolumeClaimSpec{VolumeName: "pv-" + pod.Name},
+		}
+		pv := &v1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-" + pod.Name},
+			Spec: v1.PersistentVolumeSpec{
+				Capacity: v1.ResourceList{"storage": resource.MustParse("10Gi")},
+			},
+		}
+		trackObjects = append(trackObjects, pod.DeepCopy(), pvc, pv)
+		pods = append(pods, pod)
+
+		server.AddStartedNode(pod.Status.PodIP)
+		server.SetLoad(pod.Status.PodIP, 1024)
+	}
+
+	rc.Client = fake.NewFakeClient(trackObjects...)
+	rc.dcPods = pods
+	rc.statefulSets = []*appsv1.StatefulSet{sts}
+
+	epData, err := rc.NodeMgmtClient.CallMetadataEndpointsEndpoint(pods[0])
+	assert.NoError(t, err)
+	assert.Equal(t, result.RequeueSoon(10), rc.DecommissionNodes(epData))
+	assert.Equal(t, stateDecommissioning, pods[1].Labels[api.CassNodeState])
+
+	node, _ := server.Node(pods[1].Status.PodIP)
+	assert.Equal(t, fakemgmtapi.StatusLeaving, node.Status)
+
+	// Reconcile until the node has left the ring and the StatefulSet has been
+	// scaled down
+	for i := 0; i < 5 && *sts.Spec.Replicas == 2; i++ {
+		epData, err = rc.NodeMgmtClient.CallMetadataEndpointsEndpoint(pods[0])
+		assert.NoError(t, err)
+		assert.Equal(t, result.RequeueSoon(5), rc.CheckDecommissioningNodes(epData))
+	}
+	assert.Equal(t, int32(1), *sts.Spec.Replicas)
+
+	node, _ = server.Node(pods[1].Status.PodIP)
+	assert.Equal(t, fakemgmtapi.StatusLeft, node.Status)
+
+	// The StatefulSet controller removes the pod
+	rc.dcPods = pods[:1]
+	assert.Equal(t, result.Continue(), rc.CheckDecommissioningNodes(epData))
+	assert.Equal(t, v1.ConditionFalse, rc.Datacenter.GetConditionStatus(api.DatacenterScalingDown))
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/datastax/cass-operator/operator/internal/result"
 	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
 	"github.com/datastax/cass-operator/operator/pkg/dynamicwatch"
+	"github.com/datastax/cass-operator/operator/pkg/events"
 	"github.com/datastax/cass-operator/operator/pkg/httphelper"
 	"github.com/datastax/cass-operator/operator/pkg/utils"
 	"github.com/datastax/cass-operator/operator/pkg/psp"
@@ -129,7 +130,7 @@ func (rc *ReconciliationContext) updateDcMaps() error {
 func (rc *ReconciliationContext) calculateReconciliationActions() (reconcile.Result, error) {
 
 	rc.ReqLogger.Info("handler::calculateReconciliationActions")
-	if utils.IsPSPEnabled() {
+	if rc.Datacenter.IsNodeMaintenanceEnabled() {
 		if err := rc.updateDcMaps(); err != nil {
 			// We will not skip reconciliation if the map update failed
 			// return result.Error(err).Output()
@@ -141,10 +142,14 @@ func (rc *ReconciliationContext) calculateReconciliationActions() (reconcile.Res
 		return result.Output()
 	}
 
-	if err := rc.addFinalizer(); err != nil {
+	if err := rc.addFinalizerAndClusterLabel(); err != nil {
 		return result.Error(err).Output()
 	}
 
+	if result := rc.CheckPeerDatacenters(); result.Completed() {
+		return result.Output()
+	}
+
 	if result := rc.CheckHeadlessServices(); result.Completed() {
 		return result.Output()
 	}
@@ -198,6 +203,15 @@ type ReconcileCassandraDatacenter struct {
 	// during reconciliation where we update the mappings for the watches.
 	// Putting it here allows us to get it to both places.
 	SecretWatches dynamicwatch.DynamicWatches
+
+	// NodeMgmtBreakers outlives a single reconcile so that a hung node keeps
+	// failing fast across reconciles until its breaker cools down.
+	NodeMgmtBreakers *httphelper.CircuitBreakers
+
+	// NodeMgmtHttpClient, when set, is used to talk to the Management API
+	// instead of the client built from the datacenter's security settings.
+	// Tests use it to substitute a fake Management API.
+	NodeMgmtHttpClient httphelper.HttpClient
 }
 
 // Reconcile reads that state of the cluster for a Datacenter object
@@ -226,7 +240,7 @@ func (r *ReconcileCassandraDatacenter) Reconcile(request reconcile.Request) (rec
 
 	logger.Info("======== handler::Reconcile has been called")
 
-	rc, err := CreateReconciliationContext(&request, r.client, r.scheme, r.recorder, r.SecretWatches, logger)
+	rc, err := CreateReconciliationContext(&request, r.client, r.scheme, r.recorder, r.SecretWatches, r.NodeMgmtBreakers, logger)
 
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -242,6 +256,10 @@ func (r *ReconcileCassandraDatacenter) Reconcile(request reconcile.Request) (rec
 		return result.Error(err).Output()
 	}
 
+	if r.NodeMgmtHttpClient != nil {
+		rc.NodeMgmtClient.Client = r.NodeMgmtHttpClient
+	}
+
 	if err := rc.isValid(rc.Datacenter); err != nil {
 		logger.Error(err, "CassandraDatacenter resource is invalid")
 		rc.Recorder.Eventf(rc.Datacenter, "Warning", "ValidationFailed", err.Error())
@@ -274,13 +292,28 @@ func (r *ReconcileCassandraDatacenter) Reconcile(request reconcile.Request) (rec
 	return res, err
 }
 
-func (rc *ReconciliationContext) addFinalizer() error {
-	if len(rc.Datacenter.GetFinalizers()) < 1 && rc.Datacenter.GetDeletionTimestamp() == nil {
+// addFinalizerAndClusterLabel adds the finalizer of the operator to the
+// CassandraDatacenter, and labels it with its cluster so that the other
+// datacenters of the cluster can find it
+func (rc *ReconciliationContext) addFinalizerAndClusterLabel() error {
+	dc := rc.Datacenter
+	updateNeeded := false
+
+	if len(dc.GetFinalizers()) < 1 && dc.GetDeletionTimestamp() == nil {
 		rc.ReqLogger.Info("Adding Finalizer for the CassandraDatacenter")
-		rc.Datacenter.SetFinalizers([]string{"finalizer.cassandra.datastax.com"})
+		dc.SetFinalizers([]string{"finalizer.cassandra.datastax.com"})
+		updateNeeded = true
+	}
 
+	if dc.GetLabels()[api.ClusterLabel] != dc.Spec.ClusterName {
+		rc.ReqLogger.Info("Adding cluster label to the CassandraDatacenter")
+		dc.SetLabels(utils.MergeMap(map[string]string{}, dc.GetLabels(), dc.GetClusterLabels()))
+		updateNeeded = true
+	}
+
+	if updateNeeded {
 		// Update CR
-		err := rc.Client.Update(rc.Ctx, rc.Datacenter)
+		err := rc.Client.Update(rc.Ctx, dc)
 		if err != nil {
 			rc.ReqLogger.Error(err, "Failed to update CassandraDatacenter with finalizer")
 			return err
@@ -289,6 +322,39 @@ func (rc *ReconciliationContext) addFinalizer() error {
 	return nil
 }
 
+// checkConfigSupported reports the settings of the config that the config
+// builder does not support through the UnsupportedConfig condition. The
+// warning event is only emitted when they change, not on every reconcile.
+func (rc *ReconciliationContext) checkConfigSupported() error {
+	dc := rc.Datacenter
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+
+	var condition *api.DatacenterCondition
+	configErrs := dc.ValidateConfig()
+	if len(configErrs) > 0 {
+		condition = api.NewDatacenterConditionWithReason(api.DatacenterUnsupportedConfig,
+			corev1.ConditionTrue, "UnsupportedSettings", configErrs.ToAggregate().Error())
+	} else if dc.GetConditionStatus(api.DatacenterUnsupportedConfig) == corev1.ConditionTrue {
+		condition = api.NewDatacenterCondition(api.DatacenterUnsupportedConfig, corev1.ConditionFalse)
+	} else {
+		return nil
+	}
+
+	if !rc.updateCondition(condition) {
+		return nil
+	}
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+		rc.ReqLogger.Error(err, "error patching datacenter status for unsupported config")
+		return err
+	}
+
+	if condition.Status == corev1.ConditionTrue {
+		rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.UnsupportedConfig,
+			"Config not supported by %s-%s: %s", dc.Spec.ServerType, dc.Spec.ServerVersion, condition.Message)
+	}
+	return nil
+}
+
 func (rc *ReconciliationContext) isValid(dc *api.CassandraDatacenter) error {
 	var errs []error = []error{}
 
@@ -302,6 +368,14 @@ func (rc *ReconciliationContext) isValid(dc *api.CassandraDatacenter) error {
 
 	// Validate Management API config
 	errs = append(errs, httphelper.ValidateManagementApiConfig(dc, rc.Client, rc.Ctx)...)
+
+	// The server config is only enforced by the validating webhook, so that a
+	// datacenter written without it, or before the schema of its server
+	// version changed, keeps being reconciled
+	if err := rc.checkConfigSupported(); err != nil {
+		errs = append(errs, err)
+	}
+
 	if len(errs) > 0 {
 		return errs[0]
 	}
@@ -330,9 +404,10 @@ func NewReconciler(mgr manager.Manager) reconcile.Reconciler {
 	client := mgr.GetClient()
 	dynamicWatches := dynamicwatch.NewDynamicSecretWatches(client)
 	return &ReconcileCassandraDatacenter{
-		client:        mgr.GetClient(),
-		scheme:        mgr.GetScheme(),
-		recorder:      mgr.GetEventRecorderFor("cass-operator"),
-		SecretWatches: dynamicWatches,
+		client:           mgr.GetClient(),
+		scheme:           mgr.GetScheme(),
+		recorder:         mgr.GetEventRecorderFor("cass-operator"),
+		SecretWatches:    dynamicWatches,
+		NodeMgmtBreakers: httphelper.NewCircuitBreakers(),
 	}
 }
@@ -25,6 +25,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/events"
 	"github.com/datastax/cass-operator/operator/pkg/mocks"
 )
 
@@ -53,6 +54,10 @@ func TestCalculateReconciliationActions_GetServiceError(t *testing.T) {
 	mockClient := &mocks.Client{}
 	rc.Client = mockClient
 
+	// There are no other datacenters in the cluster
+	mockClient.On("List", mock.Anything, mock.AnythingOfType("*v1beta1.CassandraDatacenterList"), mock.Anything).
+		Return(nil).
+		Once()
 	k8sMockClientGet(mockClient, fmt.Errorf(""))
 	k8sMockClientUpdate(mockClient, nil).Times(1)
 	// k8sMockClientCreate(mockClient, nil)
@@ -156,7 +161,7 @@ func TestReconcile(t *testing.T) {
 	}
 
 	s := scheme.Scheme
-	s.AddKnownTypes(api.SchemeGroupVersion, dc)
+	s.AddKnownTypes(api.SchemeGroupVersion, dc, &api.CassandraDatacenterList{})
 
 	fakeClient := fake.NewFakeClient(trackObjects...)
 
@@ -227,7 +232,7 @@ func TestReconcile_NotFound(t *testing.T) {
 	trackObjects := []runtime.Object{}
 
 	s := scheme.Scheme
-	s.AddKnownTypes(api.SchemeGroupVersion, dc)
+	s.AddKnownTypes(api.SchemeGroupVersion, dc, &api.CassandraDatacenterList{})
 
 	fakeClient := fake.NewFakeClient(trackObjects...)
 
@@ -295,7 +300,7 @@ func TestReconcile_Error(t *testing.T) {
 	// Objects to keep track of
 
 	s := scheme.Scheme
-	s.AddKnownTypes(api.SchemeGroupVersion, dc)
+	s.AddKnownTypes(api.SchemeGroupVersion, dc, &api.CassandraDatacenterList{})
 
 	mockClient := &mocks.Client{}
 	k8sMockClientGet(mockClient, fmt.Errorf(""))
@@ -367,7 +372,7 @@ func TestReconcile_CassandraDatacenterToBeDeleted(t *testing.T) {
 	}
 
 	s := scheme.Scheme
-	s.AddKnownTypes(api.SchemeGroupVersion, dc)
+	s.AddKnownTypes(api.SchemeGroupVersion, dc, &api.CassandraDatacenterList{})
 
 	fakeClient := fake.NewFakeClient(trackObjects...)
 
@@ -392,3 +397,32 @@ func TestReconcile_CassandraDatacenterToBeDeleted(t *testing.T) {
 		t.Error("Reconcile did not return an empty result.")
 	}
 }
+
+func TestIsValid_UnsupportedConfig(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.ServerType = "cassandra"
+	dc.Spec.ServerVersion = "3.11.7"
+	dc.Spec.Config = []byte(`{"cassandra-yaml":{"num_token":8}}`)
+
+	// The datacenter is still reconciled, with a warning
+	assert.NoError(t, rc.isValid(dc))
+	assert.Equal(t, corev1.ConditionTrue, dc.GetConditionStatus(api.DatacenterUnsupportedConfig))
+
+	recorder := rc.Recorder.(*record.FakeRecorder)
+	event := <-recorder.Events
+	assert.Contains(t, event, events.UnsupportedConfig)
+	assert.Contains(t, event, "spec.config.cassandra-yaml.num_token")
+
+	// The warning is not repeated on the next reconciles
+	assert.NoError(t, rc.isValid(dc))
+	assert.Empty(t, recorder.Events)
+
+	// Nor once the config is fixed
+	dc.Spec.Config = []byte(`{"cassandra-yaml":{"num_tokens":8}}`)
+	assert.NoError(t, rc.isValid(dc))
+	assert.Equal(t, corev1.ConditionFalse, dc.GetConditionStatus(api.DatacenterUnsupportedConfig))
+	assert.Empty(t, recorder.Events)
+}
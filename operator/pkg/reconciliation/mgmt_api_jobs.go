@@ -0,0 +1,95 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/events"
+	"github.com/datastax/cass-operator/operator/pkg/httphelper"
+)
+
+func (rc *ReconciliationContext) jobTracker() *httphelper.JobTracker {
+	return &httphelper.JobTracker{
+		MgmtClient: &rc.NodeMgmtClient,
+		Client:     rc.Client,
+		Ctx:        rc.Ctx,
+	}
+}
+
+// submitJob starts a long-running operation on the pod through the async
+// Management API and tracks it on the pod. If the Management API predates the
+// v1 endpoints, fallback is called instead and its result is returned with
+// submitted set to false, meaning the operation has already finished.
+func (rc *ReconciliationContext) submitJob(
+	pod *corev1.Pod,
+	jobType httphelper.JobType,
+	submit func(*corev1.Pod) (string, error),
+	fallback func(*corev1.Pod) error) (submitted bool, err error) {
+
+	jobID, err := rc.jobTracker().Submit(pod, jobType, submit)
+	if err == nil {
+		rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.SubmittedJob,
+			"Submitted %s job %s on pod %s", jobType, jobID, pod.Name)
+		return true, nil
+	}
+
+	if !httphelper.IsClientError(err) {
+		return false, err
+	}
+
+	rc.ReqLogger.Info("async Management API endpoint not available, falling back to the synchronous one",
+		"pod", pod.Name,
+		"jobType", jobType)
+	return false, fallback(pod)
+}
+
+// checkJob polls the job of the given type tracked on the pod and returns
+// it, or nil if none is tracked. A failed job is reported through the
+// JobFailed condition and a warning event, and a successful one clears that
+// condition.
+func (rc *ReconciliationContext) checkJob(pod *corev1.Pod, jobType httphelper.JobType) (*httphelper.Job, error) {
+	job, err := rc.jobTracker().Poll(pod, jobType)
+	if err != nil || job == nil || !job.IsDone() {
+		return job, err
+	}
+
+	dc := rc.Datacenter
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	updated := false
+
+	if job.Status == httphelper.JobError {
+		msg := fmt.Sprintf("%s job %s failed on pod %s: %s", jobType, job.ID, pod.Name, job.Error)
+		rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.JobFailed, msg)
+
+		// Every failure should show up with its own message, even when the
+		// condition is already set
+		updated = rc.updateCondition(api.NewDatacenterConditionWithReason(api.DatacenterJobFailed,
+			corev1.ConditionTrue, strings.Title(string(jobType))+"Failed", msg))
+	} else {
+		rc.ReqLogger.Info("Management API job finished",
+			"pod", pod.Name,
+			"jobType", jobType,
+			"jobID", job.ID)
+
+		if dc.GetConditionStatus(api.DatacenterJobFailed) == corev1.ConditionTrue {
+			updated = rc.setCondition(
+				api.NewDatacenterCondition(api.DatacenterJobFailed, corev1.ConditionFalse))
+		}
+	}
+
+	if updated {
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+			rc.ReqLogger.Error(err, "error patching datacenter status for finished job")
+			return nil, err
+		}
+	}
+
+	return job, nil
+}
@@ -0,0 +1,208 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/datastax/cass-operator/operator/internal/result"
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/events"
+	"github.com/datastax/cass-operator/operator/pkg/utils"
+)
+
+// FailedNodeSinceAnnotation records on a server data PVC when the Kubernetes
+// node its volume is local to was first seen deleted or NotReady
+const FailedNodeSinceAnnotation = "cassandra.datastax.com/failed-node-since"
+
+// CheckFailedNodes replaces, when autoReplace is set, the nodes whose data is
+// on a volume local to a Kubernetes node that has been deleted or NotReady
+// for longer than the failed node timeout. Nodes whose cassandra container is
+// ready are never replaced, and nodes on volumes that can be attached to
+// another Kubernetes node are left to be rescheduled. Only one node per rack
+// is replaced at a time.
+func (rc *ReconciliationContext) CheckFailedNodes() result.ReconcileResult {
+	dc := rc.Datacenter
+	if dc.Spec.AutoReplace == nil || dc.Spec.Stopped {
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_auto_replace::CheckFailedNodes")
+
+	replacingRacks := utils.StringSet{}
+	replacements := append(append([]string{}, dc.Spec.ReplaceNodes...), dc.Status.NodeReplacements...)
+	for _, podName := range replacements {
+		if pod := rc.getDCPodByName(podName); pod != nil {
+			replacingRacks[pod.Labels[api.RackLabel]] = true
+		}
+	}
+
+	replaced := false
+	for _, pod := range rc.dcPods {
+		pvc, err := rc.getServerDataPVC(pod)
+		if err != nil {
+			return result.Error(err)
+		}
+		if pvc == nil {
+			continue
+		}
+
+		nodeName, failedSince, err := rc.checkPodNodeFailed(pod, pvc)
+		if err != nil {
+			return result.Error(err)
+		}
+		if failedSince.IsZero() || time.Since(failedSince) < dc.GetFailedNodeTimeout() {
+			continue
+		}
+
+		rack := pod.Labels[api.RackLabel]
+		if replacingRacks[rack] {
+			rc.ReqLogger.Info("Waiting for the replacement in progress in the rack",
+				"pod", pod.Name,
+				"rack", rack)
+			continue
+		}
+
+		rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.ReplacingFailedNode,
+			"Replacing the node of pod %s, Kubernetes node %s has failed since %s",
+			pod.Name, nodeName, failedSince.Format(time.RFC3339))
+
+		if err := rc.StartNodeReplace(pod.Name); err != nil {
+			rc.ReqLogger.Error(err, "error replacing failed node", "pod", pod.Name)
+			return result.Error(err)
+		}
+		replacingRacks[rack] = true
+		replaced = true
+	}
+
+	if replaced {
+		return result.RequeueSoon(2)
+	}
+	return result.Continue()
+}
+
+// checkPodNodeFailed returns the Kubernetes node the data of the pod is local
+// to, and since when that node has been deleted or NotReady while the pod is
+// down, or a zero time if the pod does not need replacing. The time is kept in
+// an annotation of the PVC, so that it survives across reconciles.
+func (rc *ReconciliationContext) checkPodNodeFailed(pod *corev1.Pod, pvc *corev1.PersistentVolumeClaim) (string, time.Time, error) {
+	nodeName, err := rc.getLocalVolumeNodeName(pod, pvc)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	// A pod scheduled on another node than the one of its volume is not
+	// stuck on that node
+	failed := false
+	if nodeName != "" && !isServerReady(pod) && (pod.Spec.NodeName == "" || pod.Spec.NodeName == nodeName) {
+		node, err := rc.getNode(nodeName)
+		if errors.IsNotFound(err) {
+			failed = true
+		} else if err != nil {
+			return "", time.Time{}, err
+		} else {
+			failed = !isNodeReady(node)
+		}
+	}
+
+	failedSince, err := rc.updateFailedNodeSince(pvc, failed)
+	return nodeName, failedSince, err
+}
+
+// getLocalVolumeNodeName returns the Kubernetes node the server data volume of
+// a pod is local to, from the node affinity of its PersistentVolume. It is
+// empty for a volume that can be attached to any node.
+func (rc *ReconciliationContext) getLocalVolumeNodeName(pod *corev1.Pod, pvc *corev1.PersistentVolumeClaim) (string, error) {
+	if pvc.Spec.VolumeName == "" {
+		// With the Immediate volume binding mode, the PVC does not carry the
+		// node selected by the scheduler either, there is nothing to go by
+		// until the volume is bound
+		rc.ReqLogger.Info("Not checking the node of a pod whose PVC is not bound yet",
+			"pod", pod.Name,
+			"pvc", pvc.Name)
+		return "", nil
+	}
+
+	pv := &corev1.PersistentVolume{}
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: pvc.Spec.VolumeName}, pv)
+	if errors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		rc.ReqLogger.Error(err, "error retrieving PersistentVolume", "pod", pod.Name)
+		return "", err
+	}
+
+	nodeName := getVolumeNodeName(pv)
+	if nodeName == "" {
+		rc.ReqLogger.Info("Not replacing the node of a pod automatically, its volume is not local to a node",
+			"pod", pod.Name,
+			"pv", pv.Name)
+	}
+	return nodeName, nil
+}
+
+// getVolumeNodeName returns the node a PersistentVolume is restricted to by
+// its node affinity, as set for local volumes, or an empty string
+func getVolumeNodeName(pv *corev1.PersistentVolume) string {
+	if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return ""
+	}
+
+	for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+		for _, expr := range term.MatchExpressions {
+			if expr.Key == corev1.LabelHostname && expr.Operator == corev1.NodeSelectorOpIn && len(expr.Values) == 1 {
+				return expr.Values[0]
+			}
+		}
+	}
+	return ""
+}
+
+// updateFailedNodeSince keeps the failed node annotation of a PVC in line
+// with the state of the node, and returns since when it has failed
+func (rc *ReconciliationContext) updateFailedNodeSince(pvc *corev1.PersistentVolumeClaim, failed bool) (time.Time, error) {
+	since, hasAnnotation := pvc.Annotations[FailedNodeSinceAnnotation]
+	if failed == hasAnnotation {
+		if !failed {
+			return time.Time{}, nil
+		}
+		failedSince, err := time.Parse(time.RFC3339, since)
+		if err == nil {
+			return failedSince, nil
+		}
+		rc.ReqLogger.Info("Resetting invalid failed node annotation", "pvc", pvc.Name, "value", since)
+	}
+
+	patch := client.MergeFrom(pvc.DeepCopy())
+	failedSince := time.Time{}
+	if failed {
+		failedSince = time.Now()
+		if pvc.Annotations == nil {
+			pvc.Annotations = map[string]string{}
+		}
+		pvc.Annotations[FailedNodeSinceAnnotation] = failedSince.Format(time.RFC3339)
+	} else {
+		delete(pvc.Annotations, FailedNodeSinceAnnotation)
+	}
+
+	if err := rc.Client.Patch(rc.Ctx, pvc, patch); err != nil {
+		rc.ReqLogger.Error(err, "error annotating PVC with failed node", "pvc", pvc.Name)
+		return time.Time{}, err
+	}
+	return failedSince, nil
+}
+
+func isNodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
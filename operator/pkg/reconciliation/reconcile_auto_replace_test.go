@@ -0,0 +1,148 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func TestCheckFailedNodes(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.AutoReplace = &api.AutoReplaceConfig{FailedNodeTimeoutSeconds: 60}
+
+	longAgo := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	pvcs := []struct {
+		rack        string
+		node        string
+		failedSince string
+		portable    bool
+		ready       bool
+	}{
+		{"r1", "gone", longAgo, false, false},
+		// Same rack as the node above, it waits for it to be replaced
+		{"r1", "not-ready", longAgo, false, false},
+		// Not failed for long enough yet
+		{"r2", "gone", "", false, false},
+		{"r2", "ready", longAgo, false, false},
+		// The volume can follow the pod to another node
+		{"r3", "gone", longAgo, true, false},
+		// Cassandra is up, whatever the node looks like
+		{"r4", "not-ready", longAgo, false, true},
+	}
+
+	trackObjects := []runtime.Object{
+		dc,
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "not-ready"},
+			Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{{
+				Type:   corev1.NodeReady,
+				Status: corev1.ConditionUnknown,
+			}}},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "ready"},
+			Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{{
+				Type:   corev1.NodeReady,
+				Status: corev1.ConditionTrue,
+			}}},
+		},
+	}
+	for i, p := range pvcs {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("pod-%d", i),
+				Namespace: dc.Namespace,
+				Labels:    dc.GetRackLabels(p.rack),
+			},
+			Spec: corev1.PodSpec{NodeName: p.node},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "cassandra",
+					Ready: p.ready,
+				}},
+			},
+		}
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-" + pod.Name},
+		}
+		if !p.portable {
+			pv.Spec.NodeAffinity = &corev1.VolumeNodeAffinity{
+				Required: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchExpressions: []corev1.NodeSelectorRequirement{{
+							Key:      corev1.LabelHostname,
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   []string{p.node},
+						}},
+					}},
+				},
+			}
+		}
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        PvcName + "-" + pod.Name,
+				Namespace:   dc.Namespace,
+				Annotations: map[string]string{},
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{VolumeName: pv.Name},
+		}
+		if p.failedSince != "" {
+			pvc.Annotations[FailedNodeSinceAnnotation] = p.failedSince
+		}
+		trackObjects = append(trackObjects, pod.DeepCopy(), pv, pvc)
+		rc.dcPods = append(rc.dcPods, pod)
+	}
+	rc.Client = fake.NewFakeClient(trackObjects...)
+
+	assert.True(t, rc.CheckFailedNodes().Completed())
+	assert.Equal(t, []string{"pod-0"}, dc.Spec.ReplaceNodes)
+
+	getPVC := func(podName string) (*corev1.PersistentVolumeClaim, error) {
+		pvc := &corev1.PersistentVolumeClaim{}
+		err := rc.Client.Get(rc.Ctx, types.NamespacedName{
+			Name:      PvcName + "-" + podName,
+			Namespace: dc.Namespace,
+		}, pvc)
+		return pvc, err
+	}
+
+	_, err := getPVC("pod-0")
+	assert.True(t, err != nil, "the PVC of the replaced node should have been deleted")
+
+	pvc, err := getPVC("pod-2")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, pvc.Annotations[FailedNodeSinceAnnotation])
+
+	pvc, err = getPVC("pod-3")
+	assert.NoError(t, err)
+	_, ok := pvc.Annotations[FailedNodeSinceAnnotation]
+	assert.False(t, ok, "the node has recovered")
+
+	for _, podName := range []string{"pod-4", "pod-5"} {
+		pvc, err = getPVC(podName)
+		assert.NoError(t, err)
+		_, ok = pvc.Annotations[FailedNodeSinceAnnotation]
+		assert.False(t, ok, "the node of %s should not be considered failed", podName)
+	}
+
+	// Nothing else is replaced while the rack has a replacement in progress
+	rc.dcPods = rc.dcPods[1:]
+	dc.Spec.ReplaceNodes = nil
+	dc.Status.NodeReplacements = []string{"pod-1"}
+	assert.False(t, rc.CheckFailedNodes().Completed())
+}
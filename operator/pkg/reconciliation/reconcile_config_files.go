@@ -0,0 +1,92 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/datastax/cass-operator/operator/internal/result"
+	"github.com/datastax/cass-operator/operator/pkg/events"
+	"github.com/datastax/cass-operator/operator/pkg/utils"
+)
+
+// CheckRackConfigMaps creates the config map of each rack whose config files
+// are rendered by the operator, and keeps it up to date with the pod template
+// of the rack. It runs once the statefulsets exist and before any pod of a new
+// rack is started.
+func (rc *ReconciliationContext) CheckRackConfigMaps() result.ReconcileResult {
+	rc.ReqLogger.Info("reconcile_config_files::CheckRackConfigMaps")
+
+	for _, statefulSet := range rc.statefulSets {
+		if statefulSet == nil {
+			continue
+		}
+		if err := rc.checkRackConfigMap(statefulSet); err != nil {
+			return result.Error(err)
+		}
+	}
+
+	return result.Continue()
+}
+
+// checkRackConfigMap renders the config files of a rack statefulset into its
+// config map. It is called before the pod template of the statefulset is
+// updated, so that the restarted pods read the files of the new config.
+func (rc *ReconciliationContext) checkRackConfigMap(statefulSet *appsv1.StatefulSet) error {
+	dc := rc.Datacenter
+
+	desiredConfigMap, err := newConfigMapForRack(dc, statefulSet)
+	if err != nil {
+		rc.ReqLogger.Error(err, "Unable to render the config files of statefulset",
+			"statefulSet", statefulSet.Name)
+		return err
+	}
+	if desiredConfigMap == nil {
+		return nil
+	}
+
+	if err := setControllerReference(dc, desiredConfigMap, rc.Scheme); err != nil {
+		return err
+	}
+
+	currentConfigMap := &corev1.ConfigMap{}
+	err = rc.Client.Get(rc.Ctx,
+		types.NamespacedName{Name: desiredConfigMap.Name, Namespace: desiredConfigMap.Namespace},
+		currentConfigMap)
+
+	if err != nil && errors.IsNotFound(err) {
+		rc.ReqLogger.Info("Creating config map with the config files of statefulset",
+			"configMap", desiredConfigMap.Name,
+			"statefulSet", statefulSet.Name)
+
+		if err := rc.Client.Create(rc.Ctx, desiredConfigMap); err != nil {
+			return err
+		}
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedResource,
+			"Created config map %s", desiredConfigMap.Name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if utils.ResourcesHaveSameHash(currentConfigMap, desiredConfigMap) {
+		return nil
+	}
+
+	rc.ReqLogger.Info("Updating config map with the config files of statefulset",
+		"configMap", desiredConfigMap.Name,
+		"statefulSet", statefulSet.Name)
+
+	resourceVersion := currentConfigMap.GetResourceVersion()
+	// preserve any labels and annotations that were added to the config map post-creation
+	desiredConfigMap.Labels = utils.MergeMap(map[string]string{}, currentConfigMap.Labels, desiredConfigMap.Labels)
+	desiredConfigMap.Annotations = utils.MergeMap(map[string]string{}, currentConfigMap.Annotations, desiredConfigMap.Annotations)
+	desiredConfigMap.DeepCopyInto(currentConfigMap)
+	currentConfigMap.SetResourceVersion(resourceVersion)
+
+	return rc.Client.Update(rc.Ctx, currentConfigMap)
+}
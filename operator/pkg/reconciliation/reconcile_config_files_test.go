@@ -0,0 +1,163 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func TestCheckRackConfigMaps(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.ServerType = "cassandra"
+	dc.Spec.ServerVersion = "3.11.7"
+	dc.Spec.UseConfigRenderer = true
+	dc.Spec.Racks = []api.Rack{{Name: "r1"}}
+	dc.Spec.Config = []byte(`{"cassandra-yaml": {"num_tokens": 8}}`)
+
+	statefulSet, err := newStatefulSetForCassandraDatacenter("r1", dc, 0)
+	assert.NoError(t, err)
+	rc.statefulSets = []*appsv1.StatefulSet{statefulSet}
+
+	getConfigMap := func() *corev1.ConfigMap {
+		configMap := &corev1.ConfigMap{}
+		err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: dc.Name + "-r1-config", Namespace: dc.Namespace}, configMap)
+		assert.NoError(t, err)
+		return configMap
+	}
+
+	assert.False(t, rc.CheckRackConfigMaps().Completed())
+	configMap := getConfigMap()
+	assert.Equal(t, dc.GetRackLabels("r1")[api.RackLabel], configMap.Labels[api.RackLabel])
+	assert.Contains(t, configMap.Data["cassandra.yaml"], "num_tokens: 8\n")
+	assert.Equal(t, "dc="+dc.Name+"\nrack=r1\n", configMap.Data["cassandra-rackdc.properties"])
+	assert.Contains(t, configMap.Data, "jvm.options")
+
+	// The config map follows the pod template, not the datacenter config
+	dc.Spec.Config = []byte(`{"cassandra-yaml": {"num_tokens": 8, "concurrent_reads": 64}}`)
+	assert.False(t, rc.CheckRackConfigMaps().Completed())
+	assert.NotContains(t, getConfigMap().Data["cassandra.yaml"], "concurrent_reads")
+
+	statefulSet, err = newStatefulSetForCassandraDatacenter("r1", dc, 0)
+	assert.NoError(t, err)
+	rc.statefulSets = []*appsv1.StatefulSet{statefulSet}
+	assert.False(t, rc.CheckRackConfigMaps().Completed())
+	assert.Contains(t, getConfigMap().Data["cassandra.yaml"], "concurrent_reads: 64\n")
+}
+
+func TestCheckRackConfigMaps_ConfigBuilder(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Racks = []api.Rack{{Name: "r1"}}
+
+	statefulSet, err := newStatefulSetForCassandraDatacenter("r1", dc, 0)
+	assert.NoError(t, err)
+	rc.statefulSets = []*appsv1.StatefulSet{statefulSet}
+
+	assert.False(t, rc.CheckRackConfigMaps().Completed())
+
+	configMap := &corev1.ConfigMap{}
+	err = rc.Client.Get(rc.Ctx, types.NamespacedName{Name: dc.Name + "-r1-config", Namespace: dc.Namespace}, configMap)
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestCheckRackConfigMaps_ServerVersions(t *testing.T) {
+	tests := []struct {
+		serverVersion string
+		config        string
+		image         string
+		jvmFile       string
+		yamlLines     []string
+	}{
+		{
+			serverVersion: "3.11.7",
+			config:        `{"cassandra-yaml": {"read_request_timeout_in_ms": 10000}, "jvm-options": {"max_heap_size": "2G"}}`,
+			image:         "datastax/cassandra-mgmtapi-3_11_7:v0.1.13",
+			jvmFile:       "jvm.options",
+			yamlLines:     []string{"read_request_timeout_in_ms: 10000\n", "commitlog_sync_period_in_ms: 10000\n"},
+		},
+		{
+			serverVersion: "4.0.0",
+			config:        `{"cassandra-yaml": {"read_request_timeout_in_ms": 10000}, "jvm-server-options": {"max_heap_size": "2G"}}`,
+			image:         "datastax/cassandra-mgmtapi-4_0_0:v0.1.12",
+			jvmFile:       "jvm-server.options",
+			yamlLines:     []string{"read_request_timeout_in_ms: 10000\n", "commitlog_sync_period_in_ms: 10000\n"},
+		},
+		{
+			serverVersion: "4.1.3",
+			config:        `{"cassandra-yaml": {"read_request_timeout": "10s"}, "jvm-server-options": {"max_heap_size": "2G"}}`,
+			image:         "datastax/cassandra-mgmtapi:4.1.3",
+			jvmFile:       "jvm-server.options",
+			yamlLines:     []string{"read_request_timeout: 10s\n", "commitlog_sync_period: 10000ms\n"},
+		},
+		{
+			serverVersion: "5.0.0",
+			config:        `{"cassandra-yaml": {"read_request_timeout": "10s"}, "jvm-server-options": {"max_heap_size": "2G"}}`,
+			image:         "datastax/cassandra-mgmtapi:5.0.0",
+			jvmFile:       "jvm-server.options",
+			yamlLines:     []string{"read_request_timeout: 10s\n", "commitlog_sync_period: 10000ms\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.serverVersion, func(t *testing.T) {
+			rc, _, cleanupMockScr := setupTest()
+			defer cleanupMockScr()
+
+			dc := rc.Datacenter
+			dc.Spec.ServerType = "cassandra"
+			dc.Spec.ServerVersion = tt.serverVersion
+			dc.Spec.UseConfigRenderer = true
+			dc.Spec.Racks = []api.Rack{{Name: "r1"}}
+			dc.Spec.Config = []byte(tt.config)
+			assert.NoError(t, api.ValidateSingleDatacenter(*dc))
+
+			statefulSet, err := newStatefulSetForCassandraDatacenter("r1", dc, 0)
+			assert.NoError(t, err)
+			rc.statefulSets = []*appsv1.StatefulSet{statefulSet}
+			for _, container := range statefulSet.Spec.Template.Spec.Containers {
+				if container.Name == CassandraContainerName {
+					assert.Equal(t, tt.image, container.Image)
+				}
+			}
+
+			assert.False(t, rc.CheckRackConfigMaps().Completed())
+			configMap := &corev1.ConfigMap{}
+			err = rc.Client.Get(rc.Ctx, types.NamespacedName{Name: dc.Name + "-r1-config", Namespace: dc.Namespace}, configMap)
+			assert.NoError(t, err)
+			for _, line := range tt.yamlLines {
+				assert.Contains(t, configMap.Data["cassandra.yaml"], line)
+			}
+			assert.Contains(t, configMap.Data[tt.jvmFile], "\n-Xmx2G\n")
+		})
+	}
+}
+
+func TestCheckRackConfigMaps_ConfigBuilderNotSupported(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.ServerType = "cassandra"
+	dc.Spec.ServerVersion = "5.0.0"
+	dc.Spec.Racks = []api.Rack{{Name: "r1"}}
+	dc.Spec.Config = []byte(`{"jvm17-server-options": {"initial_heap_size": "2G"}}`)
+
+	// Only the config builder renders the Java 17 options, which it cannot do
+	// for Cassandra 5.0
+	_, err := newStatefulSetForCassandraDatacenter("r1", dc, 0)
+	assert.Error(t, err)
+}
@@ -13,7 +13,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
-	"github.com/datastax/cass-operator/operator/pkg/utils"
+	"github.com/datastax/cass-operator/operator/pkg/events"
 )
 
 // ProcessDeletion ...
@@ -22,11 +22,23 @@ func (rc *ReconciliationContext) ProcessDeletion() result.ReconcileResult {
 		return result.Continue()
 	}
 
+	// Nothing is torn down until the protection is lifted, which updates the
+	// CassandraDatacenter and brings it back here
+	if rc.Datacenter.Spec.DeletionProtection {
+		rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeWarning, events.DeletionProtected,
+			"Not deleting datacenter %s while deletionProtection is enabled", rc.Datacenter.Name)
+		return result.Done()
+	}
+
 	// set the label here but no need to remove since we're deleting the CassandraDatacenter
 	if err := setOperatorProgressStatus(rc, api.ProgressUpdating); err != nil {
 		return result.Error(err)
 	}
 
+	if recResult := rc.decommissionDatacenter(); recResult.Completed() {
+		return recResult
+	}
+
 	// Clean up annotation litter on the user Secrets
 	err := rc.SecretWatches.RemoveWatcher(types.NamespacedName{
 		Name: rc.Datacenter.GetName(), Namespace: rc.Datacenter.GetNamespace()})
@@ -35,12 +47,17 @@ func (rc *ReconciliationContext) ProcessDeletion() result.ReconcileResult {
 		rc.ReqLogger.Error(err, "Failed to remove dynamic secret watches for CassandraDatacenter")
 	}
 
-	if err := rc.deletePVCs(); err != nil {
+	if rc.Datacenter.Spec.StorageConfig.DeletionPolicy == api.PVCDeletionPolicyRetain {
+		if err := rc.retainPVCs(); err != nil {
+			rc.ReqLogger.Error(err, "Failed to retain PVCs for CassandraDatacenter")
+			return result.Error(err)
+		}
+	} else if err := rc.deletePVCs(); err != nil {
 		rc.ReqLogger.Error(err, "Failed to delete PVCs for CassandraDatacenter")
 		return result.Error(err)
 	}
 
-	if utils.IsPSPEnabled() {
+	if rc.Datacenter.IsNodeMaintenanceEnabled() {
 		rc.RemoveDcFromNodeToDcMap(types.NamespacedName{
 			Name:      rc.Datacenter.GetName(),
 			Namespace: rc.Datacenter.GetNamespace()})
@@ -93,6 +110,99 @@ func (rc *ReconciliationContext) deletePVCs() error {
 	return nil
 }
 
+// retainPVCs labels the PVCs of the datacenter so that a datacenter created
+// again with the same name takes them over
+func (rc *ReconciliationContext) retainPVCs() error {
+	rc.ReqLogger.Info("reconciler::retainPVCs")
+
+	persistentVolumeClaimList, err := rc.listPVCs()
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		rc.ReqLogger.Error(err, "Failed to list PVCs for cassandraDatacenter")
+		return err
+	}
+
+	retained := 0
+	for i := range persistentVolumeClaimList.Items {
+		pvc := &persistentVolumeClaimList.Items[i]
+		if pvc.Labels[api.RetainedPVCLabel] == "true" {
+			continue
+		}
+
+		pvcPatch := client.MergeFrom(pvc.DeepCopy())
+		pvc.Labels[api.RetainedPVCLabel] = "true"
+		if err := rc.Client.Patch(rc.Ctx, pvc, pvcPatch); err != nil {
+			rc.ReqLogger.Error(err, "Failed to label retained PVC", "pvcName", pvc.Name)
+			return err
+		}
+		retained++
+	}
+
+	if retained > 0 {
+		rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.RetainedPersistentVolumeClaims,
+			"Retained %d PVCs of datacenter %s", retained, rc.Datacenter.Name)
+	}
+	return nil
+}
+
+// CheckRetainedPVCs takes over the PVCs retained by a deleted datacenter of
+// the same name. The AdoptedPVCs condition is then set: the nodes start from
+// the data already on the PVCs instead of joining the cluster as new nodes.
+func (rc *ReconciliationContext) CheckRetainedPVCs() result.ReconcileResult {
+	dc := rc.Datacenter
+
+	persistentVolumeClaimList, err := rc.listPVCs()
+	if err != nil {
+		return result.Error(err)
+	}
+
+	retained := []*corev1.PersistentVolumeClaim{}
+	for i := range persistentVolumeClaimList.Items {
+		pvc := &persistentVolumeClaimList.Items[i]
+		if pvc.Labels[api.RetainedPVCLabel] == "true" {
+			retained = append(retained, pvc)
+		}
+	}
+	if len(retained) == 0 {
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_datacenter::CheckRetainedPVCs")
+
+	clusterName := dc.GetClusterLabels()[api.ClusterLabel]
+	for _, pvc := range retained {
+		if pvc.Labels[api.ClusterLabel] != clusterName {
+			rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.AdoptedPersistentVolumeClaims,
+				"Retained PVC %s belongs to cluster %s, delete it to create datacenter %s in cluster %s",
+				pvc.Name, pvc.Labels[api.ClusterLabel], dc.Name, dc.Spec.ClusterName)
+			return result.RequeueSoon(10)
+		}
+	}
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	if rc.setCondition(api.NewDatacenterCondition(api.DatacenterAdoptedPVCs, corev1.ConditionTrue)) {
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+			rc.ReqLogger.Error(err, "error patching datacenter status")
+			return result.Error(err)
+		}
+	}
+
+	for _, pvc := range retained {
+		pvcPatch := client.MergeFrom(pvc.DeepCopy())
+		delete(pvc.Labels, api.RetainedPVCLabel)
+		if err := rc.Client.Patch(rc.Ctx, pvc, pvcPatch); err != nil {
+			rc.ReqLogger.Error(err, "error removing retained label from PVC", "pvcName", pvc.Name)
+			return result.Error(err)
+		}
+	}
+
+	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.AdoptedPersistentVolumeClaims,
+		"Adopted %d PVCs retained by a previous datacenter %s", len(retained), dc.Name)
+	return result.Continue()
+}
+
 func (rc *ReconciliationContext) listPVCs() (*corev1.PersistentVolumeClaimList, error) {
 	rc.ReqLogger.Info("reconciler::listPVCs")
 
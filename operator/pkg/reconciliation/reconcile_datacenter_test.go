@@ -13,7 +13,10 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
 	"github.com/datastax/cass-operator/operator/pkg/mocks"
 )
 
@@ -117,3 +120,76 @@ func TestDeletePVCs_FailedToDelete(t *testing.T) {
 
 	assert.EqualError(t, err, "failed to delete")
 }
+
+func TestRetainPVCs(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.StorageConfig.DeletionPolicy = api.PVCDeletionPolicyRetain
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "server-data-pod-0",
+			Namespace: dc.Namespace,
+			Labels:    dc.GetRackLabels("default"),
+		},
+	}
+	rc.Client = fake.NewFakeClient(dc, pvc)
+
+	assert.NoError(t, rc.retainPVCs())
+
+	pvcKey := types.NamespacedName{Name: pvc.Name, Namespace: pvc.Namespace}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, pvcKey, pvc))
+	assert.Equal(t, "true", pvc.Labels[api.RetainedPVCLabel])
+
+	// A datacenter created again with the same name takes the PVCs over
+	assert.False(t, rc.CheckRetainedPVCs().Completed())
+	assert.Equal(t, v1.ConditionTrue, rc.Datacenter.GetConditionStatus(api.DatacenterAdoptedPVCs))
+	assert.False(t, rc.IsInitialized(), "the datacenter is initialized once it is ready")
+	assert.True(t, rc.HasClusterData())
+
+	adopted := &v1.PersistentVolumeClaim{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, pvcKey, adopted))
+	_, ok := adopted.Labels[api.RetainedPVCLabel]
+	assert.False(t, ok)
+}
+
+func TestCheckRetainedPVCs_OtherCluster(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	labels := dc.GetRackLabels("default")
+	labels[api.ClusterLabel] = "other-cluster"
+	labels[api.RetainedPVCLabel] = "true"
+	rc.Client = fake.NewFakeClient(dc, &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "server-data-pod-0",
+			Namespace: dc.Namespace,
+			Labels:    labels,
+		},
+	})
+
+	assert.True(t, rc.CheckRetainedPVCs().Completed(), "PVCs of another cluster should not be adopted")
+	assert.False(t, rc.HasClusterData())
+}
+
+func TestProcessDeletion_DeletionProtection(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	now := metav1.Now()
+	dc.SetDeletionTimestamp(&now)
+	dc.SetFinalizers([]string{"finalizer.cassandra.datastax.com"})
+	dc.Spec.DeletionProtection = true
+
+	// No call to the client is expected while the datacenter is protected
+	mockClient := &mocks.Client{}
+	rc.Client = mockClient
+
+	recResult := rc.ProcessDeletion()
+	assert.True(t, recResult.Completed())
+	assert.Equal(t, []string{"finalizer.cassandra.datastax.com"}, dc.GetFinalizers())
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,97 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/datastax/cass-operator/operator/internal/result"
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/oplabels"
+	"github.com/datastax/cass-operator/operator/pkg/utils"
+)
+
+// CheckRackPodDisruptionBudgets keeps a PodDisruptionBudget per rack when the
+// podDisruptionBudgetScope is Rack. A rack allows one of its pods to be
+// disrupted only while no other rack has a bootstrapped node down, so that
+// draining Kubernetes nodes never takes down replicas in two racks at once.
+// It runs early in the reconcile, as the budgets have to be tightened as soon
+// as a node goes down. The budget of a rack is replaced by creating the new
+// one before deleting the previous one, so the rack always has a budget.
+func (rc *ReconciliationContext) CheckRackPodDisruptionBudgets() result.ReconcileResult {
+	dc := rc.Datacenter
+	if dc.Spec.PodDisruptionBudgetScope != api.PodDisruptionBudgetRack {
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_disruption_budgets::CheckRackPodDisruptionBudgets")
+
+	downRacks := getPodsRackNameSet(rc.GetNotReadyPodsBootstrappedInDC())
+
+	desiredNames := utils.StringSet{}
+	for _, rackInfo := range rc.desiredRackInformation {
+		minAvailable := rackInfo.NodeCount
+		otherRacksDown := len(utils.SubtractStringSet(downRacks, utils.StringSet{rackInfo.RackName: true})) > 0
+		if !otherRacksDown && minAvailable > 0 {
+			minAvailable--
+		}
+
+		desiredBudget := newPodDisruptionBudgetForRack(dc, rackInfo.RackName, minAvailable)
+		if err := rc.checkPodDisruptionBudget(desiredBudget); err != nil {
+			return result.Error(err)
+		}
+		desiredNames[desiredBudget.Name] = true
+	}
+
+	if err := rc.deleteStalePodDisruptionBudgets(desiredNames); err != nil {
+		return result.Error(err)
+	}
+
+	return result.Continue()
+}
+
+// deleteStalePodDisruptionBudgets deletes the budgets of the datacenter that
+// are not in the given set, left over by a change of scope or of racks
+func (rc *ReconciliationContext) deleteStalePodDisruptionBudgets(desiredNames utils.StringSet) error {
+	selector := rc.Datacenter.GetDatacenterLabels()
+	oplabels.AddManagedByLabel(selector)
+
+	budgets := &policyv1beta1.PodDisruptionBudgetList{}
+	err := rc.Client.List(rc.Ctx, budgets, &client.ListOptions{
+		Namespace:     rc.Datacenter.Namespace,
+		LabelSelector: labels.SelectorFromSet(selector),
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range budgets.Items {
+		budget := &budgets.Items[i]
+		if desiredNames[budget.Name] {
+			continue
+		}
+
+		rc.ReqLogger.Info("Deleting PodDisruptionBudget",
+			"pdbNamespace", budget.Namespace,
+			"pdbName", budget.Name)
+
+		if err := rc.Client.Delete(rc.Ctx, budget); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getPodsRackNameSet(pods []*corev1.Pod) utils.StringSet {
+	names := utils.StringSet{}
+	for _, pod := range pods {
+		names[pod.Labels[api.RackLabel]] = true
+	}
+	return names
+}
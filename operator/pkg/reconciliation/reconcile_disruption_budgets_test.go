@@ -0,0 +1,144 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/mocks"
+)
+
+func TestCheckRackPodDisruptionBudgets(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.PodDisruptionBudgetScope = api.PodDisruptionBudgetRack
+
+	racks := []string{"r1", "r2", "r3"}
+	for _, rack := range racks {
+		rc.desiredRackInformation = append(rc.desiredRackInformation, &RackInformation{RackName: rack, NodeCount: 2})
+		for i := 0; i < 2; i++ {
+			labels := dc.GetRackLabels(rack)
+			labels[api.CassNodeState] = stateStarted
+			rc.dcPods = append(rc.dcPods, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("%s-pod-%d", rack, i),
+					Namespace: dc.Namespace,
+					Labels:    labels,
+				},
+				Status: corev1.PodStatus{
+					ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: true}},
+				},
+			})
+		}
+	}
+
+	dcBudget := newPodDisruptionBudgetForDatacenter(dc)
+	rc.Client = fake.NewFakeClient([]runtime.Object{dc, dcBudget}...)
+
+	getMinAvailable := func(name string) (int, error) {
+		budget := &policyv1beta1.PodDisruptionBudget{}
+		err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: name, Namespace: dc.Namespace}, budget)
+		if err != nil {
+			return 0, err
+		}
+		return budget.Spec.MinAvailable.IntValue(), nil
+	}
+
+	// The budgets of a rack, by name
+	getRackBudgets := func(rack string) []string {
+		budgets := &policyv1beta1.PodDisruptionBudgetList{}
+		assert.NoError(t, rc.Client.List(rc.Ctx, budgets, client.MatchingLabels(dc.GetRackLabels(rack))))
+		names := []string{}
+		for _, budget := range budgets.Items {
+			names = append(names, budget.Name)
+		}
+		return names
+	}
+
+	assert.False(t, rc.CheckRackPodDisruptionBudgets().Completed())
+
+	_, err := getMinAvailable(dcBudget.Name)
+	assert.Error(t, err, "the datacenter budget should have been replaced by the rack budgets")
+	for _, rack := range racks {
+		name := fmt.Sprintf("%s-%s-pdb-1", dc.Name, rack)
+		assert.Equal(t, []string{name}, getRackBudgets(rack), "every rack may lose a pod while none is down")
+		minAvailable, err := getMinAvailable(name)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, minAvailable)
+	}
+
+	// A node of r2 is down, only r2 may be disrupted further. The budgets of
+	// the other racks are replaced by new ones.
+	rc.dcPods[2].Status.ContainerStatuses[0].Ready = false
+	assert.False(t, rc.CheckRackPodDisruptionBudgets().Completed())
+
+	for rack, expected := range map[string]int{"r1": 2, "r2": 1, "r3": 2} {
+		name := fmt.Sprintf("%s-%s-pdb-%d", dc.Name, rack, expected)
+		assert.Equal(t, []string{name}, getRackBudgets(rack), rack)
+		minAvailable, err := getMinAvailable(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, minAvailable, rack)
+	}
+
+	// Going back to the datacenter scope removes the rack budgets
+	dc.Spec.PodDisruptionBudgetScope = ""
+	assert.False(t, rc.CheckRackPodDisruptionBudgets().Completed())
+	assert.False(t, rc.CheckDcPodDisruptionBudget().Completed())
+
+	_, err = getMinAvailable(dcBudget.Name)
+	assert.NoError(t, err)
+	assert.Empty(t, getRackBudgets("r1"))
+}
+
+func TestCheckRackPodDisruptionBudgets_CreatesBeforeDeleting(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.PodDisruptionBudgetScope = api.PodDisruptionBudgetRack
+	rc.desiredRackInformation = []*RackInformation{{RackName: "r1", NodeCount: 3}}
+
+	previousBudget := newPodDisruptionBudgetForRack(dc, "r1", 3)
+	mockClient := &mocks.Client{}
+	rc.Client = mockClient
+
+	calls := []string{}
+	k8sMockClientGet(mockClient, errors.NewNotFound(schema.GroupResource{}, "")).
+		Run(func(args mock.Arguments) { calls = append(calls, "get") })
+	k8sMockClientCreate(mockClient, nil).
+		Run(func(args mock.Arguments) {
+			calls = append(calls, "create "+args.Get(1).(*policyv1beta1.PodDisruptionBudget).Name)
+		})
+	k8sMockClientList(mockClient, nil).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*policyv1beta1.PodDisruptionBudgetList).Items = []policyv1beta1.PodDisruptionBudget{*previousBudget}
+		})
+	k8sMockClientDelete(mockClient, nil).
+		Run(func(args mock.Arguments) {
+			calls = append(calls, "delete "+args.Get(1).(*policyv1beta1.PodDisruptionBudget).Name)
+		})
+
+	assert.False(t, rc.CheckRackPodDisruptionBudgets().Completed())
+	assert.Equal(t, []string{
+		"get",
+		"create " + dc.Name + "-r1-pdb-2",
+		"delete " + dc.Name + "-r1-pdb-3",
+	}, calls)
+}
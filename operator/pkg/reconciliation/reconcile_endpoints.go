@@ -47,16 +47,22 @@ func (rc *ReconciliationContext) CheckAdditionalSeedEndpoints() result.Reconcile
 
 	logger.Info("reconcile_endpoints::CheckAdditionalSeedEndpoints")
 
-	if len(dc.Spec.AdditionalSeeds) == 0 {
+	if !dc.UsesAdditionalSeedService() {
 		return result.Continue()
 	}
 
-	desiredEndpoints, err := newEndpointsForAdditionalSeeds(dc)
+	desiredEndpoints, err := newEndpointsForAdditionalSeeds(dc, rc.peerSeeds)
 	if err != nil {
 		logger.Error(err, "Could not set additional seeds for endpoints for additional seed service")
 		return result.Error(err)
 	}
 
+	if len(desiredEndpoints.Subsets[0].Addresses) == 0 {
+		// An Endpoints without any address is not valid, there is nothing to
+		// publish until the other datacenters have labelled their seeds
+		return rc.deleteAdditionalSeedEndpoints(desiredEndpoints)
+	}
+
 	createNeeded := false
 
 	// Set CassandraDatacenter dc as the owner and controller
@@ -113,3 +119,27 @@ func (rc *ReconciliationContext) CheckAdditionalSeedEndpoints() result.Reconcile
 
 	return result.Continue()
 }
+
+func (rc *ReconciliationContext) deleteAdditionalSeedEndpoints(endpoints *corev1.Endpoints) result.ReconcileResult {
+	currentEndpoints := &corev1.Endpoints{}
+	nsName := types.NamespacedName{Name: endpoints.Name, Namespace: endpoints.Namespace}
+	if err := rc.Client.Get(rc.Ctx, nsName, currentEndpoints); err != nil {
+		if errors.IsNotFound(err) {
+			return result.Continue()
+		}
+		rc.ReqLogger.Error(err, "Could not get endpoints for additional seed service",
+			"name", nsName)
+		return result.Error(err)
+	}
+
+	rc.ReqLogger.Info("Deleting endpoints for additional seed service",
+		"endpointsNamespace", endpoints.Namespace,
+		"endpointsName", endpoints.Name)
+
+	if err := rc.Client.Delete(rc.Ctx, currentEndpoints); err != nil && !errors.IsNotFound(err) {
+		rc.ReqLogger.Error(err, "Could not delete endpoints for additional seed service")
+		return result.Error(err)
+	}
+
+	return result.Continue()
+}
@@ -0,0 +1,153 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/httphelper"
+	"github.com/datastax/cass-operator/operator/pkg/utils"
+)
+
+// recordHibernationState saves the host ID, the tokens and the PVC of every
+// node in the status of the datacenter before it is stopped
+func (rc *ReconciliationContext) recordHibernationState() error {
+	rc.ReqLogger.Info("reconcile_hibernation::recordHibernationState")
+	dc := rc.Datacenter
+
+	endpoints := []httphelper.EndpointState{}
+	for _, pod := range rc.dcPods {
+		if !isServerReady(pod) {
+			continue
+		}
+		endpointsResponse, err := rc.NodeMgmtClient.CallMetadataEndpointsEndpoint(pod)
+		if err == nil {
+			endpoints = endpointsResponse.Entity
+			break
+		}
+		rc.ReqLogger.Error(err, "Could not get endpoints data", "pod", pod.Name)
+	}
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	if dc.Status.NodeStatuses == nil {
+		dc.Status.NodeStatuses = map[string]api.CassandraNodeStatus{}
+	}
+
+	for _, pod := range rc.dcPods {
+		nodeStatus := dc.Status.NodeStatuses[pod.Name]
+
+		if ep := findEndpointForPod(dc, pod, endpoints); ep != nil {
+			nodeStatus.HostID = ep.HostID
+			nodeStatus.Tokens = ep.GetTokens()
+		}
+
+		pvc, err := rc.getServerDataPVC(pod)
+		if err != nil {
+			return err
+		}
+		if pvc != nil {
+			nodeStatus.PVCUID = pvc.UID
+		}
+
+		dc.Status.NodeStatuses[pod.Name] = nodeStatus
+	}
+
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+		rc.ReqLogger.Error(err, "error patching datacenter status with hibernation state")
+		return err
+	}
+	return nil
+}
+
+// getServerDataPVC returns the server data PVC of the pod, or nil if it does
+// not exist
+func (rc *ReconciliationContext) getServerDataPVC(pod *corev1.Pod) (*corev1.PersistentVolumeClaim, error) {
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{
+		Namespace: pod.Namespace,
+		Name:      PvcName + "-" + pod.Name,
+	}, pvc)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		rc.ReqLogger.Error(err, "error retrieving PersistentVolumeClaim", "pod", pod.Name)
+		return nil, err
+	}
+	return pvc, nil
+}
+
+// hasIntactData tells if the pod came back from hibernation with the PVC it
+// had when the datacenter was stopped
+func (rc *ReconciliationContext) hasIntactData(pod *corev1.Pod) (bool, error) {
+	dc := rc.Datacenter
+
+	nodeStatus, ok := dc.Status.NodeStatuses[pod.Name]
+	if !ok || nodeStatus.HostID == "" || nodeStatus.PVCUID == "" {
+		return false, nil
+	}
+	if utils.IndexOfString(dc.Status.NodeReplacements, pod.Name) > -1 {
+		return false, nil
+	}
+
+	pvc, err := rc.getServerDataPVC(pod)
+	if err != nil || pvc == nil {
+		return false, err
+	}
+	return pvc.UID == nodeStatus.PVCUID, nil
+}
+
+// startHibernatedRack starts together all the nodes of one rack that are
+// resuming with their data intact. Those nodes rejoin the ring with the
+// tokens they already own, so they do not need to be started one at a time.
+// Returns the name of the rack, or an empty string if there was nothing to
+// start.
+func (rc *ReconciliationContext) startHibernatedRack(endpointData httphelper.CassMetadataEndpoints, readySeeds int) (string, error) {
+	rc.ReqLogger.Info("reconcile_hibernation::startHibernatedRack")
+
+	labelSeedBeforeStart := readySeeds == 0 && len(rc.Datacenter.Spec.AdditionalSeeds) == 0 && len(rc.peerSeeds) == 0
+
+	for _, rackInfo := range rc.desiredRackInformation {
+		rackPods := FilterPodListByLabels(rc.dcPods, rc.Datacenter.GetRackLabels(rackInfo.RackName))
+
+		podsToStart := []*corev1.Pod{}
+		for _, pod := range rackPods {
+			if !isServerReadyToStart(pod) || !isMgmtApiRunning(pod) {
+				continue
+			}
+			intact, err := rc.hasIntactData(pod)
+			if err != nil {
+				return "", err
+			}
+			if intact {
+				podsToStart = append(podsToStart, pod)
+			}
+		}
+		if len(podsToStart) == 0 {
+			continue
+		}
+
+		rc.ReqLogger.Info("Resuming rack from hibernation",
+			"rack", rackInfo.RackName,
+			"nodes", len(podsToStart))
+
+		if labelSeedBeforeStart {
+			if err := rc.labelPodAsSeed(podsToStart[0]); err != nil {
+				return "", err
+			}
+		}
+		for _, pod := range podsToStart {
+			if err := rc.startCassandra(endpointData, pod); err != nil {
+				return "", err
+			}
+		}
+		return rackInfo.RackName, nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,147 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/httphelper"
+	"github.com/datastax/cass-operator/operator/pkg/httphelper/fakemgmtapi"
+)
+
+// setupHibernationTest gives the datacenter a pod with a running Management
+// API and a server data PVC for each of the given racks
+func setupHibernationTest(rc *ReconciliationContext, racks ...string) *fakemgmtapi.Server {
+	dc := rc.Datacenter
+
+	server := fakemgmtapi.NewServer()
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{
+		Client:   server,
+		Log:      rc.ReqLogger,
+		Protocol: "http",
+	}
+
+	trackObjects := []runtime.Object{dc}
+	for i, rack := range racks {
+		labels := dc.GetRackLabels(rack)
+		labels[api.CassNodeState] = stateReadyToStart
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("pod-%d", i),
+				Namespace: dc.Namespace,
+				Labels:    labels,
+			},
+			Status: corev1.PodStatus{
+				PodIP: fmt.Sprintf("10.0.0.%d", i+1),
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name: "cassandra",
+					State: corev1.ContainerState{
+						Running: &corev1.ContainerStateRunning{
+							StartedAt: metav1.NewTime(time.Now().Add(-time.Minute)),
+						},
+					},
+				}},
+			},
+		}
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      PvcName + "-" + pod.Name,
+				Namespace: dc.Namespace,
+				UID:       types.UID(fmt.Sprintf("uid-%d", i)),
+			},
+		}
+		trackObjects = append(trackObjects, pod.DeepCopy(), pvc)
+		rc.dcPods = append(rc.dcPods, pod)
+		server.AddNode(pod.Status.PodIP)
+	}
+	rc.Client = fake.NewFakeClient(trackObjects...)
+
+	return server
+}
+
+func TestCheckRackStoppedState_Hibernation(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	server := setupHibernationTest(rc, "default", "default")
+	for _, pod := range rc.dcPods {
+		server.AddStartedNode(pod.Status.PodIP)
+		pod.Status.ContainerStatuses[0].Ready = true
+	}
+
+	statefulSet, err := newStatefulSetForCassandraDatacenter("default", dc, 2)
+	assert.NoError(t, err)
+	assert.NoError(t, rc.Client.Create(rc.Ctx, statefulSet))
+	rc.statefulSets = []*appsv1.StatefulSet{statefulSet}
+	rc.desiredRackInformation = []*RackInformation{{RackName: "default", NodeCount: 2}}
+
+	dc.Spec.Stopped = true
+	assert.True(t, rc.CheckRackStoppedState().Completed())
+	assert.Equal(t, corev1.ConditionTrue, dc.GetConditionStatus(api.DatacenterStopped))
+
+	for i, pod := range rc.dcPods {
+		node, _ := server.Node(pod.Status.PodIP)
+		assert.True(t, node.Drained)
+
+		nodeStatus := dc.Status.NodeStatuses[pod.Name]
+		assert.Equal(t, node.HostID, nodeStatus.HostID)
+		assert.Equal(t, node.Tokens, nodeStatus.Tokens)
+		assert.Equal(t, types.UID(fmt.Sprintf("uid-%d", i)), nodeStatus.PVCUID)
+	}
+
+	assert.Equal(t, int32(0), *statefulSet.Spec.Replicas)
+}
+
+func TestStartHibernatedRack(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Status.NodeStatuses = api.CassandraStatusMap{
+		"pod-0": {HostID: "host-0", PVCUID: "uid-0"},
+		// The PVC of this node was lost while the datacenter was stopped
+		"pod-1": {HostID: "host-1", PVCUID: "uid-lost"},
+		"pod-2": {HostID: "host-2", PVCUID: "uid-2"},
+		"pod-3": {HostID: "host-3", PVCUID: "uid-3"},
+	}
+	server := setupHibernationTest(rc, "r1", "r1", "r2", "r2")
+	rc.desiredRackInformation = []*RackInformation{
+		{RackName: "r1", NodeCount: 2},
+		{RackName: "r2", NodeCount: 2},
+	}
+
+	rack, err := rc.startHibernatedRack(httphelper.CassMetadataEndpoints{}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "r1", rack)
+	assert.True(t, isServerStarting(rc.dcPods[0]))
+	assert.True(t, isServerReadyToStart(rc.dcPods[1]), "a node without its data is started on its own")
+
+	// The whole next rack is started at once
+	rack, err = rc.startHibernatedRack(httphelper.CassMetadataEndpoints{}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "r2", rack)
+	assert.True(t, isServerStarting(rc.dcPods[2]))
+	assert.True(t, isServerStarting(rc.dcPods[3]))
+
+	rack, err = rc.startHibernatedRack(httphelper.CassMetadataEndpoints{}, 1)
+	assert.NoError(t, err)
+	assert.Empty(t, rack)
+
+	for _, i := range []int{0, 2, 3} {
+		node, _ := server.Node(rc.dcPods[i].Status.PodIP)
+		assert.True(t, node.Started)
+	}
+}
@@ -0,0 +1,291 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/datastax/cass-operator/operator/pkg/events"
+	"github.com/datastax/cass-operator/operator/pkg/httphelper"
+	"github.com/datastax/cass-operator/operator/pkg/utils"
+)
+
+// HotConfigHashAnnotation records on a pod the hash of the config whose
+// runtime settings were applied to its running node, and of the restart count
+// of its cassandra container at that time
+const HotConfigHashAnnotation = "cassandra.datastax.com/hot-config-hash"
+
+const runtimeSettingsSection = "cassandra-yaml"
+
+// runtimeSettings are the cassandra-yaml settings that can be changed on a
+// running node through the Management API
+var runtimeSettings = map[string]func(*httphelper.NodeMgmtClient, *corev1.Pod, int) error{
+	"compaction_throughput_mb_per_sec":                     (*httphelper.NodeMgmtClient).CallSetCompactionThroughputEndpoint,
+	"concurrent_compactors":                                (*httphelper.NodeMgmtClient).CallSetConcurrentCompactorsEndpoint,
+	"hinted_handoff_throttle_in_kb":                        (*httphelper.NodeMgmtClient).CallSetHintedHandoffThrottleEndpoint,
+	"inter_dc_stream_throughput_outbound_megabits_per_sec": (*httphelper.NodeMgmtClient).CallSetInterDCStreamThroughputEndpoint,
+	"stream_throughput_outbound_megabits_per_sec":          (*httphelper.NodeMgmtClient).CallSetStreamThroughputEndpoint,
+}
+
+// getServerConfigEnv returns the env var of the server-config-init container
+// of the statefulset with the given name, nil if there is none
+func getServerConfigEnv(sts *appsv1.StatefulSet, name string) *corev1.EnvVar {
+	for i, container := range sts.Spec.Template.Spec.InitContainers {
+		if container.Name != ServerConfigContainerName {
+			continue
+		}
+		for j, env := range container.Env {
+			if env.Name == name {
+				return &sts.Spec.Template.Spec.InitContainers[i].Env[j]
+			}
+		}
+	}
+	return nil
+}
+
+func getConfigFileDataEnv(sts *appsv1.StatefulSet) *corev1.EnvVar {
+	return getServerConfigEnv(sts, "CONFIG_FILE_DATA")
+}
+
+// getRuntimeConfigChanges returns the names of the cassandra-yaml settings
+// that differ between the current and the desired config. The second value is
+// false if anything else differs, or if a setting cannot be changed at
+// runtime. A setting that is added or removed cannot, as its value on the
+// other side is the default of the server.
+func getRuntimeConfigChanges(currentConfig, desiredConfig map[string]interface{}) ([]string, bool) {
+	changes := []string{}
+	for _, section := range unionOfKeys(currentConfig, desiredConfig) {
+		if reflect.DeepEqual(currentConfig[section], desiredConfig[section]) {
+			continue
+		}
+		if section != runtimeSettingsSection {
+			return nil, false
+		}
+
+		current, currentOk := currentConfig[section].(map[string]interface{})
+		desired, desiredOk := desiredConfig[section].(map[string]interface{})
+		if !currentOk || !desiredOk {
+			return nil, false
+		}
+
+		for _, key := range unionOfKeys(current, desired) {
+			if reflect.DeepEqual(current[key], desired[key]) {
+				continue
+			}
+			_, isRuntimeSetting := runtimeSettings[key]
+			_, currentIsInt := getIntValue(current[key])
+			_, desiredIsInt := getIntValue(desired[key])
+			if !isRuntimeSetting || !currentIsInt || !desiredIsInt {
+				return nil, false
+			}
+			changes = append(changes, key)
+		}
+	}
+	return changes, true
+}
+
+func unionOfKeys(maps ...map[string]interface{}) []string {
+	keys := utils.StringSet{}
+	for _, m := range maps {
+		for k := range m {
+			keys[k] = true
+		}
+	}
+	names := []string{}
+	for k := range keys {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func getIntValue(value interface{}) (int, bool) {
+	n, ok := value.(float64)
+	if !ok || n != math.Trunc(n) {
+		return 0, false
+	}
+	return int(n), true
+}
+
+func hashConfig(configData string) string {
+	hash := sha256.Sum256([]byte(configData))
+	return base64.RawURLEncoding.EncodeToString(hash[:16])
+}
+
+// getPodHotConfigHash returns the hash recorded on a pod once the runtime
+// settings of a config are applied to its node. The settings are lost when the
+// cassandra container restarts in place, so its restart count is part of the
+// hash and the settings are applied again.
+func getPodHotConfigHash(pod *corev1.Pod, configData string) string {
+	restartCount := int32(0)
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == "cassandra" {
+			restartCount = status.RestartCount
+		}
+	}
+	return hashConfig(fmt.Sprintf("%s/%d", configData, restartCount))
+}
+
+// getStatefulSetRuntimeChanges returns the config settings that differ
+// between the current and the desired statefulset of a rack. The second value
+// is false unless these settings can be changed at runtime and nothing else
+// differs.
+func getStatefulSetRuntimeChanges(statefulSet, desiredSts *appsv1.StatefulSet) ([]string, bool) {
+	currentEnv := getConfigFileDataEnv(statefulSet)
+	desiredEnv := getConfigFileDataEnv(desiredSts)
+	if currentEnv == nil || desiredEnv == nil {
+		return nil, false
+	}
+
+	if !utils.ResourcesHaveSameHash(statefulSet, desiredSts) {
+		// Only the config may differ for the changes to be applied at runtime
+		withCurrentConfig := desiredSts.DeepCopy()
+		getConfigFileDataEnv(withCurrentConfig).Value = currentEnv.Value
+		utils.RemoveHashAnnotation(withCurrentConfig)
+		utils.AddHashAnnotation(withCurrentConfig)
+		if !utils.ResourcesHaveSameHash(statefulSet, withCurrentConfig) {
+			return nil, false
+		}
+	}
+
+	var currentConfig, desiredConfig map[string]interface{}
+	if err := json.Unmarshal([]byte(currentEnv.Value), &currentConfig); err != nil {
+		return nil, false
+	}
+	if err := json.Unmarshal([]byte(desiredEnv.Value), &desiredConfig); err != nil {
+		return nil, false
+	}
+
+	return getRuntimeConfigChanges(currentConfig, desiredConfig)
+}
+
+// applyRuntimeConfig applies to the running nodes of a rack, through the
+// Management API, the changes of the config that do not need a restart.
+// Returns true if the existing statefulset of the rack can be kept as it is,
+// either because nothing changed or because every change was applied at
+// runtime. The settings applied at runtime are applied again to the nodes
+// that restart with the pod template, until the next rolling restart of the
+// rack brings the pod template up to date.
+func (rc *ReconciliationContext) applyRuntimeConfig(statefulSet, desiredSts *appsv1.StatefulSet, rackName string) (bool, error) {
+	dc := rc.Datacenter
+	hotApplied := dc.Status.HotAppliedConfig[rackName]
+
+	sameTemplate := utils.ResourcesHaveSameHash(statefulSet, desiredSts)
+	if sameTemplate && len(hotApplied) == 0 {
+		return true, nil
+	}
+
+	changes, ok := getStatefulSetRuntimeChanges(statefulSet, desiredSts)
+	if !ok {
+		return sameTemplate, nil
+	}
+
+	desiredEnv := getConfigFileDataEnv(desiredSts)
+	var desiredConfig map[string]interface{}
+	if err := json.Unmarshal([]byte(desiredEnv.Value), &desiredConfig); err != nil {
+		return false, err
+	}
+
+	// Settings applied at runtime before and since reverted in the config
+	// are set back to the value of the pod template
+	settings := utils.StringSet{}
+	for _, key := range append(append([]string{}, changes...), hotApplied...) {
+		settings[key] = true
+	}
+	desiredSettings, _ := desiredConfig[runtimeSettingsSection].(map[string]interface{})
+
+	pending := false
+	rackPods := FilterPodListByLabels(rc.dcPods, dc.GetRackLabels(rackName))
+	for _, pod := range rackPods {
+		desiredHash := getPodHotConfigHash(pod, desiredEnv.Value)
+		if pod.Annotations[HotConfigHashAnnotation] == desiredHash {
+			continue
+		}
+		if !isServerReady(pod) {
+			pending = true
+			continue
+		}
+
+		for _, key := range sortedSetKeys(settings) {
+			value, ok := getIntValue(desiredSettings[key])
+			if !ok {
+				continue
+			}
+			err := runtimeSettings[key](&rc.NodeMgmtClient, pod, value)
+			if httphelper.IsClientError(err) && !sameTemplate {
+				// The Management API of the node cannot change the setting,
+				// the pods need to restart with the new config instead
+				rc.ReqLogger.Info("Unable to apply config at runtime, restarting the rack instead",
+					"pod", pod.Name,
+					"setting", key,
+					"error", err.Error())
+				return false, nil
+			}
+			if err != nil {
+				rc.ReqLogger.Error(err, "error applying config at runtime", "pod", pod.Name, "setting", key)
+				return false, err
+			}
+		}
+
+		patch := client.MergeFrom(pod.DeepCopy())
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[HotConfigHashAnnotation] = desiredHash
+		if err := rc.Client.Patch(rc.Ctx, pod, patch); err != nil {
+			rc.ReqLogger.Error(err, "error annotating pod with applied config", "pod", pod.Name)
+			return false, err
+		}
+	}
+
+	if sameTemplate && pending {
+		// Keep the settings to revert in the status until every node is done
+		return true, nil
+	}
+	if reflect.DeepEqual(hotApplied, changes) || (len(hotApplied) == 0 && len(changes) == 0) {
+		return true, nil
+	}
+
+	if len(changes) > 0 {
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.HotAppliedConfig,
+			"Applied config to the running nodes of rack %s without restarting them: %s",
+			rackName, strings.Join(changes, ", "))
+	}
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	if len(changes) > 0 {
+		if dc.Status.HotAppliedConfig == nil {
+			dc.Status.HotAppliedConfig = map[string][]string{}
+		}
+		dc.Status.HotAppliedConfig[rackName] = changes
+	} else {
+		delete(dc.Status.HotAppliedConfig, rackName)
+	}
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+		rc.ReqLogger.Error(err, "error patching datacenter status with hot applied config")
+		return false, err
+	}
+
+	return true, nil
+}
+
+func sortedSetKeys(set utils.StringSet) []string {
+	keys := []string{}
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,193 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/utils"
+)
+
+func TestGetRuntimeConfigChanges(t *testing.T) {
+	current := map[string]interface{}{
+		"cassandra-yaml": map[string]interface{}{
+			"compaction_throughput_mb_per_sec": float64(16),
+			"num_tokens":                       float64(8),
+		},
+		"jvm-options": map[string]interface{}{"max_heap_size": "2G"},
+	}
+
+	tests := []struct {
+		name    string
+		desired map[string]interface{}
+		changes []string
+		ok      bool
+	}{
+		{
+			name:    "Same config",
+			desired: current,
+			changes: []string{},
+			ok:      true,
+		},
+		{
+			name: "Runtime setting changed",
+			desired: map[string]interface{}{
+				"cassandra-yaml": map[string]interface{}{
+					"compaction_throughput_mb_per_sec": float64(64),
+					"num_tokens":                       float64(8),
+				},
+				"jvm-options": map[string]interface{}{"max_heap_size": "2G"},
+			},
+			changes: []string{"compaction_throughput_mb_per_sec"},
+			ok:      true,
+		},
+		{
+			name: "Runtime setting added",
+			desired: map[string]interface{}{
+				"cassandra-yaml": map[string]interface{}{
+					"compaction_throughput_mb_per_sec": float64(16),
+					"concurrent_compactors":            float64(4),
+					"num_tokens":                       float64(8),
+				},
+				"jvm-options": map[string]interface{}{"max_heap_size": "2G"},
+			},
+			ok: false,
+		},
+		{
+			name: "Other setting changed",
+			desired: map[string]interface{}{
+				"cassandra-yaml": map[string]interface{}{
+					"compaction_throughput_mb_per_sec": float64(64),
+					"num_tokens":                       float64(16),
+				},
+				"jvm-options": map[string]interface{}{"max_heap_size": "2G"},
+			},
+			ok: false,
+		},
+		{
+			name: "Other section changed",
+			desired: map[string]interface{}{
+				"cassandra-yaml": current["cassandra-yaml"],
+				"jvm-options":    map[string]interface{}{"max_heap_size": "4G"},
+			},
+			ok: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, ok := getRuntimeConfigChanges(current, tt.desired)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.changes, changes)
+			}
+		})
+	}
+}
+
+func TestCheckRackPodTemplate_HotConfig(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Racks = []api.Rack{{Name: "r1"}}
+	dc.Spec.Config = []byte(`{"cassandra-yaml":{"compaction_throughput_mb_per_sec":16}}`)
+	server := setupHibernationTest(rc, "r1", "r1")
+	for _, pod := range rc.dcPods {
+		server.AddStartedNode(pod.Status.PodIP)
+		pod.Status.ContainerStatuses[0].Ready = true
+		assert.NoError(t, rc.Client.Status().Update(rc.Ctx, pod))
+	}
+
+	statefulSet, err := newStatefulSetForCassandraDatacenter("r1", dc, 0)
+	assert.NoError(t, err)
+	statefulSet.Spec.Replicas = &[]int32{2}[0]
+	assert.NoError(t, rc.Client.Create(rc.Ctx, statefulSet))
+	rc.statefulSets = []*appsv1.StatefulSet{statefulSet}
+	rc.desiredRackInformation = []*RackInformation{{RackName: "r1", NodeCount: 2}}
+	originalSts := statefulSet.DeepCopy()
+
+	setConfig := func(config string) {
+		dc.Spec.Config = []byte(config)
+		assert.NoError(t, rc.Client.Update(rc.Ctx, dc))
+	}
+
+	assertSettings := func(value int) {
+		for _, pod := range rc.dcPods {
+			node, _ := server.Node(pod.Status.PodIP)
+			assert.Equal(t, value, node.Settings["/api/v0/ops/tables/compactionthroughput"])
+		}
+	}
+
+	// The change is applied to the running nodes, the pods are not restarted
+	setConfig(`{"cassandra-yaml":{"compaction_throughput_mb_per_sec":64}}`)
+	assert.False(t, rc.CheckRackPodTemplate().Completed())
+	assertSettings(64)
+	assert.Equal(t, []string{"compaction_throughput_mb_per_sec"}, dc.Status.HotAppliedConfig["r1"])
+	assert.True(t, utils.ResourcesHaveSameHash(originalSts, statefulSet))
+	for _, pod := range rc.dcPods {
+		assert.NotEmpty(t, pod.Annotations[HotConfigHashAnnotation])
+	}
+
+	// A node whose container restarted gets the change again
+	server.StopNode(rc.dcPods[1].Status.PodIP)
+	server.AddStartedNode(rc.dcPods[1].Status.PodIP)
+	rc.dcPods[1].Status.ContainerStatuses[0].RestartCount++
+	assert.NoError(t, rc.Client.Status().Update(rc.Ctx, rc.dcPods[1]))
+	assert.False(t, rc.CheckRackPodTemplate().Completed())
+	assertSettings(64)
+
+	// Reverting the config sets the nodes back to the value of the pod template
+	setConfig(`{"cassandra-yaml":{"compaction_throughput_mb_per_sec":16}}`)
+	assert.False(t, rc.CheckRackPodTemplate().Completed())
+	assertSettings(16)
+	_, ok := dc.Status.HotAppliedConfig["r1"]
+	assert.False(t, ok)
+
+	// Any other change restarts the rack with the whole config
+	setConfig(`{"cassandra-yaml":{"compaction_throughput_mb_per_sec":64}}`)
+	assert.False(t, rc.CheckRackPodTemplate().Completed())
+	setConfig(`{"cassandra-yaml":{"compaction_throughput_mb_per_sec":64,"num_tokens":16}}`)
+	assert.True(t, rc.CheckRackPodTemplate().Completed())
+	_, ok = dc.Status.HotAppliedConfig["r1"]
+	assert.False(t, ok)
+
+	updatedSts := &appsv1.StatefulSet{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: statefulSet.Name, Namespace: statefulSet.Namespace}, updatedSts))
+	assert.Contains(t, getConfigFileDataEnv(updatedSts).Value, `"compaction_throughput_mb_per_sec":64`)
+}
+
+func TestCheckRackPodTemplate_HotConfigUnsupported(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Racks = []api.Rack{{Name: "r1"}}
+	dc.Spec.Config = []byte(`{"cassandra-yaml":{"compaction_throughput_mb_per_sec":16}}`)
+	server := setupHibernationTest(rc, "r1")
+	pod := rc.dcPods[0]
+	server.AddStartedNode(pod.Status.PodIP)
+	pod.Status.ContainerStatuses[0].Ready = true
+	assert.NoError(t, rc.Client.Status().Update(rc.Ctx, pod))
+	server.FailNext(pod.Status.PodIP, "/api/v0/ops/tables/compactionthroughput", 404)
+
+	statefulSet, err := newStatefulSetForCassandraDatacenter("r1", dc, 0)
+	assert.NoError(t, err)
+	statefulSet.Spec.Replicas = &[]int32{1}[0]
+	assert.NoError(t, rc.Client.Create(rc.Ctx, statefulSet))
+	rc.statefulSets = []*appsv1.StatefulSet{statefulSet}
+	rc.desiredRackInformation = []*RackInformation{{RackName: "r1", NodeCount: 1}}
+
+	// The Management API of the node cannot change the setting, the rack
+	// is restarted instead
+	dc.Spec.Config = []byte(`{"cassandra-yaml":{"compaction_throughput_mb_per_sec":64}}`)
+	assert.NoError(t, rc.Client.Update(rc.Ctx, dc))
+	assert.True(t, rc.CheckRackPodTemplate().Completed())
+	assert.Contains(t, getConfigFileDataEnv(statefulSet).Value, `"compaction_throughput_mb_per_sec":64`)
+}
@@ -0,0 +1,69 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"reflect"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/datastax/cass-operator/operator/internal/result"
+	"github.com/datastax/cass-operator/operator/pkg/images"
+)
+
+// CheckImageDigests records in the status of the datacenter the digests that
+// the images of the rack statefulsets are pinned to. An image lists the
+// digests of all the racks, so while the racks move to a new digest of the
+// image both the old and the new one are listed.
+func (rc *ReconciliationContext) CheckImageDigests() result.ReconcileResult {
+	rc.ReqLogger.Info("reconcile_image_digests::CheckImageDigests")
+
+	dc := rc.Datacenter
+
+	imageDigests := map[string]map[string]bool{}
+	for _, statefulSet := range rc.statefulSets {
+		if statefulSet == nil {
+			continue
+		}
+		podSpec := statefulSet.Spec.Template.Spec
+		for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+			for _, container := range containers {
+				image, digest := images.SplitDigest(container.Image)
+				if digest == "" {
+					continue
+				}
+				if imageDigests[image] == nil {
+					imageDigests[image] = map[string]bool{}
+				}
+				imageDigests[image][digest] = true
+			}
+		}
+	}
+
+	var digests map[string][]string
+	for image, imageDigest := range imageDigests {
+		if digests == nil {
+			digests = map[string][]string{}
+		}
+		for digest := range imageDigest {
+			digests[image] = append(digests[image], digest)
+		}
+		sort.Strings(digests[image])
+	}
+
+	if reflect.DeepEqual(dc.Status.ImageDigests, digests) {
+		return result.Continue()
+	}
+
+	patch := client.MergeFrom(dc.DeepCopy())
+	dc.Status.ImageDigests = digests
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, patch); err != nil {
+		rc.ReqLogger.Error(err, "error patching datacenter status with image digests")
+		return result.Error(err)
+	}
+
+	return result.Continue()
+}
@@ -0,0 +1,75 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/images"
+)
+
+const (
+	testDigest      = "sha256:2b8fd9751c6d1d6e1b4e2d1b0f7b1d1e9b6a9c0b5a1f7c1e4d1a8f2c3b4d5e6f"
+	otherTestDigest = "sha256:0a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f9"
+)
+
+func TestCheckImageDigests(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Racks = []api.Rack{{Name: "r1"}}
+	serverImage, err := dc.GetServerImage()
+	assert.NoError(t, err)
+
+	assert.NoError(t, images.SetCatalog(&images.Catalog{
+		Digests: map[string]string{serverImage: testDigest},
+	}))
+	defer images.SetCatalog(nil)
+
+	statefulSet, err := newStatefulSetForCassandraDatacenter("r1", dc, 0)
+	assert.NoError(t, err)
+	rc.statefulSets = []*appsv1.StatefulSet{statefulSet}
+
+	var cassContainer corev1.Container
+	for _, container := range statefulSet.Spec.Template.Spec.Containers {
+		if container.Name == CassandraContainerName {
+			cassContainer = container
+		}
+	}
+	assert.Equal(t, serverImage+"@"+testDigest, cassContainer.Image)
+	assert.Equal(t, corev1.PullIfNotPresent, cassContainer.ImagePullPolicy)
+	for _, container := range statefulSet.Spec.Template.Spec.InitContainers {
+		assert.Empty(t, container.ImagePullPolicy, "container %s", container.Name)
+	}
+
+	assert.False(t, rc.CheckImageDigests().Completed())
+	assert.Equal(t, map[string][]string{serverImage: {testDigest}}, dc.Status.ImageDigests)
+
+	// A digest pushed again for the same reference is listed until all the
+	// racks use it
+	assert.NoError(t, images.SetCatalog(&images.Catalog{
+		Digests: map[string]string{serverImage: otherTestDigest},
+	}))
+	otherStatefulSet, err := newStatefulSetForCassandraDatacenter("r2", dc, 0)
+	assert.NoError(t, err)
+	rc.statefulSets = []*appsv1.StatefulSet{statefulSet, otherStatefulSet}
+
+	assert.False(t, rc.CheckImageDigests().Completed())
+	assert.Equal(t, map[string][]string{serverImage: {otherTestDigest, testDigest}}, dc.Status.ImageDigests)
+
+	// Without pinned images
+	assert.NoError(t, images.SetCatalog(nil))
+	statefulSet, err = newStatefulSetForCassandraDatacenter("r1", dc, 0)
+	assert.NoError(t, err)
+	rc.statefulSets = []*appsv1.StatefulSet{statefulSet}
+
+	assert.False(t, rc.CheckImageDigests().Completed())
+	assert.Nil(t, dc.Status.ImageDigests)
+}
@@ -0,0 +1,385 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/datastax/cass-operator/operator/internal/result"
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/events"
+	"github.com/datastax/cass-operator/operator/pkg/httphelper"
+)
+
+// RebuiltFromAnnotation is set on a pod once its node has streamed its data
+// from another datacenter, with the name of that datacenter
+const RebuiltFromAnnotation = "cassandra.datastax.com/rebuilt-from"
+
+// CheckPeerDatacenters finds the other datacenters of the same cluster, in
+// any namespace the operator watches, by their cluster label, and the seed
+// nodes they have labelled. A datacenter that finds older peers before it is
+// initialized joins the cluster through them: their seeds are published
+// through its additional seed service, which is part of the seeds of its
+// nodes.
+func (rc *ReconciliationContext) CheckPeerDatacenters() result.ReconcileResult {
+	rc.ReqLogger.Info("reconcile_multidc::CheckPeerDatacenters")
+
+	dc := rc.Datacenter
+
+	dcList := &api.CassandraDatacenterList{}
+	if err := rc.Client.List(rc.Ctx, dcList, client.MatchingLabels(dc.GetClusterLabels())); err != nil {
+		rc.ReqLogger.Error(err, "error listing datacenters")
+		return result.Error(err)
+	}
+
+	peers := []*api.CassandraDatacenter{}
+	for i := range dcList.Items {
+		peer := &dcList.Items[i]
+		if peer.Namespace == dc.Namespace && peer.Name == dc.Name {
+			continue
+		}
+		if peer.GetDeletionTimestamp() != nil {
+			continue
+		}
+		peers = append(peers, peer)
+	}
+	sort.SliceStable(peers, func(i, j int) bool {
+		return isOlderDatacenter(peers[i], peers[j])
+	})
+
+	seeds := map[string]bool{}
+	for _, peer := range peers {
+		selector := peer.GetDatacenterLabels()
+		selector[api.SeedNodeLabel] = "true"
+
+		podList := &corev1.PodList{}
+		err := rc.Client.List(rc.Ctx, podList,
+			client.InNamespace(peer.Namespace),
+			client.MatchingLabels(selector))
+		if err != nil {
+			rc.ReqLogger.Error(err, "error listing seed pods of peer datacenter",
+				"datacenter", peer.Name)
+			return result.Error(err)
+		}
+
+		for _, pod := range podList.Items {
+			if pod.Status.PodIP != "" {
+				seeds[pod.Status.PodIP] = true
+			}
+		}
+	}
+
+	peerSeeds := make([]string, 0, len(seeds))
+	for ip := range seeds {
+		peerSeeds = append(peerSeeds, ip)
+	}
+	sort.Strings(peerSeeds)
+
+	rc.peerDatacenters = peers
+	rc.peerSeeds = peerSeeds
+
+	if !dc.Status.SeededFromPeers && !rc.HasClusterData() && len(rc.olderPeerDatacenters()) > 0 {
+
+		rc.ReqLogger.Info("Seeding from the other datacenters of the cluster")
+		dcPatch := client.MergeFrom(dc.DeepCopy())
+		dc.Status.SeededFromPeers = true
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+			rc.ReqLogger.Error(err, "error patching datacenter status")
+			return result.Error(err)
+		}
+	}
+
+	return result.Continue()
+}
+
+// isOlderDatacenter orders datacenters by creation, falling back on the name
+// for datacenters created within the same second
+func isOlderDatacenter(a, b *api.CassandraDatacenter) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
+
+// olderPeerDatacenters returns the peer datacenters that were created before
+// this one, oldest first. This datacenter joins the cluster through them.
+func (rc *ReconciliationContext) olderPeerDatacenters() []*api.CassandraDatacenter {
+	older := []*api.CassandraDatacenter{}
+	for _, peer := range rc.peerDatacenters {
+		if isOlderDatacenter(peer, rc.Datacenter) {
+			older = append(older, peer)
+		}
+	}
+	return older
+}
+
+// CheckDatacenterBootstrapOrder holds back the first start of a datacenter
+// until every datacenter of the cluster created before it is ready and has
+// published its seeds, so datacenters join the cluster one at a time.
+func (rc *ReconciliationContext) CheckDatacenterBootstrapOrder() result.ReconcileResult {
+	rc.ReqLogger.Info("reconcile_multidc::CheckDatacenterBootstrapOrder")
+
+	dc := rc.Datacenter
+	if dc.Spec.Stopped || rc.HasClusterData() {
+		return result.Continue()
+	}
+
+	// Once a node is up the datacenter has joined, holding it back now would
+	// only keep the rest of its nodes down
+	for _, pod := range rc.dcPods {
+		if isServerStarting(pod) || isServerStarted(pod) {
+			return result.Continue()
+		}
+	}
+
+	older := rc.olderPeerDatacenters()
+	for _, peer := range older {
+		if peer.GetConditionStatus(api.DatacenterReady) != corev1.ConditionTrue {
+			rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.WaitingForDatacenter,
+				"Waiting for datacenter %s to be ready before starting Cassandra", peer.Name)
+			return result.RequeueSoon(10)
+		}
+	}
+
+	if len(older) > 0 && len(rc.peerSeeds) == 0 {
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.WaitingForDatacenter,
+			"Waiting for datacenter %s to label its seed nodes before starting Cassandra", older[0].Name)
+		return result.RequeueSoon(10)
+	}
+
+	return result.Continue()
+}
+
+// CheckRebuild streams the data of a datacenter that is joining an existing
+// cluster, with addDatacenter in its spec, from the source datacenter before
+// the datacenter reports itself as ready. The replication of the keyspaces is
+// first extended to this datacenter. Nodes are then rebuilt a few at a time,
+// and annotated once they are done so the rebuild is not repeated. Progress
+// is reported by the AddingDatacenter condition.
+func (rc *ReconciliationContext) CheckRebuild() result.ReconcileResult {
+	rc.ReqLogger.Info("reconcile_multidc::CheckRebuild")
+
+	dc := rc.Datacenter
+	if dc.Spec.AddDatacenter == nil || dc.Spec.Stopped || rc.HasClusterData() {
+		return result.Continue()
+	}
+
+	source := dc.Spec.AddDatacenter.SourceDatacenter
+
+	if recResult := rc.updateReplicationForNewDatacenter(); recResult.Completed() {
+		return recResult
+	}
+
+	rebuilt := 0
+	rebuiltNow := 0
+	running := 0
+	pending := []*corev1.Pod{}
+	for _, pod := range rc.dcPods {
+		if _, ok := pod.Annotations[RebuiltFromAnnotation]; ok {
+			rebuilt++
+			continue
+		}
+
+		if httphelper.GetPodJobID(pod, httphelper.JobTypeRebuild) == "" {
+			pending = append(pending, pod)
+			continue
+		}
+
+		job, err := rc.checkJob(pod, httphelper.JobTypeRebuild)
+		if err != nil {
+			return result.Error(err)
+		}
+		if job != nil && !job.IsDone() {
+			running++
+			continue
+		}
+		if job != nil && job.Status == httphelper.JobError {
+			// The job is forgotten, so the rebuild is submitted again
+			pending = append(pending, pod)
+			continue
+		}
+
+		if err := rc.annotateRebuiltPod(pod, source); err != nil {
+			return result.Error(err)
+		}
+		rebuilt++
+		rebuiltNow++
+	}
+
+	for _, pod := range pending {
+		if running >= dc.GetRebuildConcurrency() {
+			break
+		}
+
+		submitted, err := rc.submitJob(pod, httphelper.JobTypeRebuild,
+			func(pod *corev1.Pod) (string, error) {
+				return rc.NodeMgmtClient.CallRebuildAsyncEndpoint(pod, source)
+			},
+			func(pod *corev1.Pod) error {
+				return rc.NodeMgmtClient.CallRebuildEndpoint(pod, source)
+			})
+		if err != nil {
+			rc.ReqLogger.Error(err, "error rebuilding node", "pod", pod.Name)
+			return result.Error(err)
+		}
+
+		if submitted {
+			running++
+			continue
+		}
+
+		if err := rc.annotateRebuiltPod(pod, source); err != nil {
+			return result.Error(err)
+		}
+		rebuilt++
+		rebuiltNow++
+	}
+
+	if rebuilt < len(rc.dcPods) {
+		err := rc.setAddingDatacenterCondition(corev1.ConditionTrue, "Rebuilding",
+			fmt.Sprintf("Rebuilt %d of %d nodes from datacenter %s", rebuilt, len(rc.dcPods), source))
+		if err != nil {
+			return result.Error(err)
+		}
+		return result.RequeueSoon(10)
+	}
+
+	if rebuiltNow > 0 {
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.RebuiltDatacenter,
+			"Rebuilt datacenter from datacenter %s", source)
+	}
+
+	err := rc.setAddingDatacenterCondition(corev1.ConditionFalse, "Completed",
+		fmt.Sprintf("Rebuilt %d nodes from datacenter %s", rebuilt, source))
+	if err != nil {
+		return result.Error(err)
+	}
+
+	return result.Continue()
+}
+
+func (rc *ReconciliationContext) setAddingDatacenterCondition(status corev1.ConditionStatus, reason, message string) error {
+	dc := rc.Datacenter
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+
+	updated := rc.updateCondition(
+		api.NewDatacenterConditionWithReason(api.DatacenterAddingDatacenter, status, reason, message))
+	if !updated {
+		return nil
+	}
+
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+		rc.ReqLogger.Error(err, "error patching datacenter status")
+		return err
+	}
+	return nil
+}
+
+// systemReplicatedKeyspaces are the system keyspaces that every datacenter
+// needs a replica of
+var systemReplicatedKeyspaces = []string{"system_auth", "system_distributed", "system_traces"}
+
+// updateReplicationForNewDatacenter extends the replication of the system
+// keyspaces and of the keyspaces listed in addDatacenter to this datacenter.
+// Keyspaces already replicated to it are left alone, so it is safe to run
+// again after a failure.
+func (rc *ReconciliationContext) updateReplicationForNewDatacenter() result.ReconcileResult {
+	dc := rc.Datacenter
+	source := dc.Spec.AddDatacenter.SourceDatacenter
+
+	var pod *corev1.Pod
+	for _, dcPod := range rc.dcPods {
+		if isServerReady(dcPod) {
+			pod = dcPod
+			break
+		}
+	}
+	if pod == nil {
+		return result.RequeueSoon(10)
+	}
+
+	keyspaces := append(append([]string{}, systemReplicatedKeyspaces...), dc.Spec.AddDatacenter.Keyspaces...)
+	for _, keyspace := range keyspaces {
+		replication, err := rc.NodeMgmtClient.CallGetKeyspaceReplicationEndpoint(pod, keyspace)
+		if err != nil {
+			rc.ReqLogger.Error(err, "error getting keyspace replication", "keyspace", keyspace)
+			return result.Error(err)
+		}
+		if _, ok := replication[dc.Name]; ok {
+			continue
+		}
+
+		err = rc.setAddingDatacenterCondition(corev1.ConditionTrue, "UpdatingReplication",
+			fmt.Sprintf("Updating the replication of keyspace %s", keyspace))
+		if err != nil {
+			return result.Error(err)
+		}
+
+		settings := replicationSettings(replication, source)
+		settings = append(settings, httphelper.ReplicationSetting{
+			DcName:            dc.Name,
+			ReplicationFactor: dc.GetAddDatacenterReplicationFactor(),
+		})
+
+		err = rc.NodeMgmtClient.CallAlterKeyspaceEndpoint(pod, httphelper.AlterKeyspaceRequest{
+			KeyspaceName:        keyspace,
+			ReplicationSettings: settings,
+		})
+		if err != nil {
+			rc.ReqLogger.Error(err, "error altering keyspace replication", "keyspace", keyspace)
+			return result.Error(err)
+		}
+
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.UpdatedReplication,
+			"Replicated keyspace %s to datacenter %s", keyspace, dc.Name)
+	}
+
+	return result.Continue()
+}
+
+// replicationSettings turns the replication options of a keyspace into the
+// settings of every datacenter it is replicated to. A keyspace that does not
+// use the NetworkTopologyStrategy yet keeps its replication factor in the
+// source datacenter.
+func replicationSettings(replication map[string]string, source string) []httphelper.ReplicationSetting {
+	settings := []httphelper.ReplicationSetting{}
+
+	if !strings.HasSuffix(replication["class"], "NetworkTopologyStrategy") {
+		rf, err := strconv.Atoi(replication["replication_factor"])
+		if err != nil || rf < 1 {
+			rf = 1
+		}
+		return append(settings, httphelper.ReplicationSetting{DcName: source, ReplicationFactor: rf})
+	}
+
+	for dcName, value := range replication {
+		if dcName == "class" {
+			continue
+		}
+		rf, err := strconv.Atoi(value)
+		if err != nil {
+			continue
+		}
+		settings = append(settings, httphelper.ReplicationSetting{DcName: dcName, ReplicationFactor: rf})
+	}
+	sort.Slice(settings, func(i, j int) bool {
+		return settings[i].DcName < settings[j].DcName
+	})
+	return settings
+}
+
+func (rc *ReconciliationContext) annotateRebuiltPod(pod *corev1.Pod, source string) error {
+	patch := client.MergeFrom(pod.DeepCopy())
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Annotations[RebuiltFromAnnotation] = source
+	return rc.Client.Patch(rc.Ctx, pod, patch)
+}
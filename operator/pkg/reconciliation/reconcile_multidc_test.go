@@ -0,0 +1,295 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/httphelper"
+	"github.com/datastax/cass-operator/operator/pkg/httphelper/fakemgmtapi"
+)
+
+func makePeerDatacenter(dc *api.CassandraDatacenter, name, namespace string, age time.Duration, ready bool) *api.CassandraDatacenter {
+	peer := dc.DeepCopy()
+	peer.ObjectMeta = metav1.ObjectMeta{
+		Name:              name,
+		Namespace:         namespace,
+		Labels:            dc.GetClusterLabels(),
+		CreationTimestamp: metav1.NewTime(dc.CreationTimestamp.Add(-age)),
+	}
+	peer.Status = api.CassandraDatacenterStatus{}
+	if ready {
+		peer.SetCondition(*api.NewDatacenterCondition(api.DatacenterReady, corev1.ConditionTrue))
+	}
+	return peer
+}
+
+func makePeerSeedPod(peer *api.CassandraDatacenter, name, ip string) *corev1.Pod {
+	labels := peer.GetDatacenterLabels()
+	labels[api.SeedNodeLabel] = "true"
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: peer.Namespace,
+			Labels:    labels,
+		},
+		Status: corev1.PodStatus{PodIP: ip},
+	}
+}
+
+func TestCheckPeerDatacenters(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.CreationTimestamp = metav1.Now()
+
+	peer := makePeerDatacenter(dc, "dc2", "other", time.Hour, true)
+	otherCluster := makePeerDatacenter(dc, "dc3", "default", 2*time.Hour, true)
+	otherCluster.Spec.ClusterName = "other-cluster"
+	otherCluster.Labels = otherCluster.GetClusterLabels()
+
+	rc.Client = fake.NewFakeClient(
+		dc, peer, otherCluster,
+		makePeerSeedPod(peer, "dc2-pod-0", "10.1.0.2"),
+		makePeerSeedPod(peer, "dc2-pod-1", "10.1.0.1"),
+		makePeerSeedPod(otherCluster, "dc3-pod-0", "10.2.0.1"),
+	)
+
+	assert.False(t, rc.CheckPeerDatacenters().Completed())
+	assert.Equal(t, 1, len(rc.peerDatacenters))
+	assert.Equal(t, "dc2", rc.peerDatacenters[0].Name)
+	assert.Equal(t, []string{"10.1.0.1", "10.1.0.2"}, rc.peerSeeds)
+	assert.True(t, dc.Status.SeededFromPeers, "the datacenter should seed from the older one")
+	assert.True(t, dc.UsesAdditionalSeedService())
+
+	assert.False(t, rc.CheckAdditionalSeedEndpoints().Completed())
+
+	endpoints := &corev1.Endpoints{}
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{
+		Name:      dc.GetAdditionalSeedsServiceName(),
+		Namespace: dc.Namespace,
+	}, endpoints)
+	assert.NoError(t, err)
+	assert.Equal(t, []corev1.EndpointAddress{{IP: "10.1.0.1"}, {IP: "10.1.0.2"}}, endpoints.Subsets[0].Addresses)
+
+	// Once the peer is gone there is nothing left to publish
+	rc.peerSeeds = nil
+	assert.False(t, rc.CheckAdditionalSeedEndpoints().Completed())
+	err = rc.Client.Get(rc.Ctx, types.NamespacedName{
+		Name:      dc.GetAdditionalSeedsServiceName(),
+		Namespace: dc.Namespace,
+	}, endpoints)
+	assert.True(t, err != nil, "endpoints without addresses should have been deleted")
+}
+
+func TestCheckPeerDatacenters_OldestDatacenter(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.CreationTimestamp = metav1.Now()
+	dc.Labels = dc.GetClusterLabels()
+
+	newer := makePeerDatacenter(dc, "dc2", "default", -time.Hour, true)
+	rc.Client = fake.NewFakeClient(dc, newer, makePeerSeedPod(newer, "dc2-pod-0", "10.1.0.1"))
+
+	assert.False(t, rc.CheckPeerDatacenters().Completed())
+	assert.Equal(t, 1, len(rc.peerDatacenters))
+	assert.Equal(t, []string{"10.1.0.1"}, rc.peerSeeds)
+
+	// The seeds of the oldest datacenter are left as they are
+	assert.False(t, dc.Status.SeededFromPeers)
+	assert.False(t, dc.UsesAdditionalSeedService())
+	config, err := dc.GetConfigAsJSON()
+	assert.NoError(t, err)
+	assert.NotContains(t, config, dc.GetAdditionalSeedsServiceName())
+}
+
+func TestCheckDatacenterBootstrapOrder(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.CreationTimestamp = metav1.Now()
+
+	older := makePeerDatacenter(dc, "dc0", "default", time.Hour, false)
+	newer := makePeerDatacenter(dc, "dc2", "default", -time.Hour, false)
+	rc.peerDatacenters = []*api.CassandraDatacenter{older, newer}
+
+	recResult := rc.CheckDatacenterBootstrapOrder()
+	assert.True(t, recResult.Completed(), "should wait for the older datacenter to be ready")
+
+	older.SetCondition(*api.NewDatacenterCondition(api.DatacenterReady, corev1.ConditionTrue))
+	recResult = rc.CheckDatacenterBootstrapOrder()
+	assert.True(t, recResult.Completed(), "should wait for the older datacenter to publish its seeds")
+
+	// A newer datacenter that is not ready does not hold this one back
+	rc.peerSeeds = []string{"10.1.0.1"}
+	assert.False(t, rc.CheckDatacenterBootstrapOrder().Completed())
+
+	// Nor does anything once the datacenter is initialized
+	older.SetCondition(*api.NewDatacenterCondition(api.DatacenterReady, corev1.ConditionFalse))
+	dc.SetCondition(*api.NewDatacenterCondition(api.DatacenterInitialized, corev1.ConditionTrue))
+	assert.False(t, rc.CheckDatacenterBootstrapOrder().Completed())
+}
+
+// setupRebuildTest gives the datacenter ready pods backed by a fake
+// Management API
+func setupRebuildTest(rc *ReconciliationContext, size int) *fakemgmtapi.Server {
+	dc := rc.Datacenter
+
+	server := fakemgmtapi.NewServer()
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{
+		Client:   server,
+		Log:      rc.ReqLogger,
+		Protocol: "http",
+	}
+
+	trackObjects := []runtime.Object{dc}
+	for i := 0; i < size; i++ {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("pod-%d", i),
+				Namespace: dc.Namespace,
+				Labels:    dc.GetDatacenterLabels(),
+			},
+			Status: corev1.PodStatus{
+				PodIP: fmt.Sprintf("10.0.0.%d", i+1),
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "cassandra",
+					Ready: true,
+				}},
+			},
+		}
+		trackObjects = append(trackObjects, pod.DeepCopy())
+		rc.dcPods = append(rc.dcPods, pod)
+		server.AddStartedNode(pod.Status.PodIP)
+	}
+	rc.Client = fake.NewFakeClient(trackObjects...)
+
+	return server
+}
+
+func TestCheckRebuild(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.CreationTimestamp = metav1.Now()
+	rc.peerDatacenters = []*api.CassandraDatacenter{
+		makePeerDatacenter(dc, "dc0", "default", time.Hour, true),
+	}
+	server := setupRebuildTest(rc, 2)
+
+	// Nothing is streamed into a datacenter without addDatacenter
+	assert.False(t, rc.CheckRebuild().Completed())
+	for _, pod := range rc.dcPods {
+		node, _ := server.Node(pod.Status.PodIP)
+		assert.Empty(t, node.Rebuilds)
+	}
+
+	dc.Spec.AddDatacenter = &api.AddDatacenterConfig{SourceDatacenter: "dc0"}
+
+	// One node at a time, each job is checked on the next reconcile
+	for i := 0; i < 2; i++ {
+		assert.True(t, rc.CheckRebuild().Completed())
+		assert.NotEmpty(t, httphelper.GetPodJobID(rc.dcPods[i], httphelper.JobTypeRebuild))
+	}
+	assert.False(t, rc.CheckRebuild().Completed())
+
+	for _, pod := range rc.dcPods {
+		assert.Equal(t, "dc0", pod.Annotations[RebuiltFromAnnotation])
+		assert.Empty(t, httphelper.GetPodJobID(pod, httphelper.JobTypeRebuild))
+
+		node, _ := server.Node(pod.Status.PodIP)
+		assert.Equal(t, []string{"dc0"}, node.Rebuilds)
+	}
+
+	condition, _ := dc.GetCondition(api.DatacenterAddingDatacenter)
+	assert.Equal(t, corev1.ConditionFalse, condition.Status)
+	assert.Equal(t, "Completed", condition.Reason)
+
+	// Rebuilt nodes are not rebuilt again
+	assert.False(t, rc.CheckRebuild().Completed())
+	node, _ := server.Node(rc.dcPods[0].Status.PodIP)
+	assert.Equal(t, 1, len(node.Rebuilds))
+}
+
+func TestCheckRebuild_AddDatacenter(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Size = 3
+	dc.Spec.AddDatacenter = &api.AddDatacenterConfig{
+		SourceDatacenter:   "dc1",
+		Keyspaces:          []string{"ks1"},
+		RebuildConcurrency: 2,
+	}
+	server := setupRebuildTest(rc, 3)
+	server.SetKeyspace("system_auth", map[string]int{"dc1": 3})
+	server.SetKeyspace("ks1", map[string]int{"dc1": 3, "other": 1})
+	server.SetKeyspace("ks2", map[string]int{"dc1": 3})
+
+	assert.True(t, rc.CheckRebuild().Completed())
+
+	replication, _ := server.Keyspace("system_auth")
+	assert.Equal(t, map[string]int{"dc1": 3, dc.Name: 3}, replication)
+	replication, _ = server.Keyspace("system_traces")
+	assert.Equal(t, map[string]int{dc.Name: 3}, replication)
+	replication, _ = server.Keyspace("ks1")
+	assert.Equal(t, map[string]int{"dc1": 3, "other": 1, dc.Name: 3}, replication)
+	replication, _ = server.Keyspace("ks2")
+	assert.Equal(t, map[string]int{"dc1": 3}, replication, "keyspaces that are not listed are left alone")
+
+	// Two nodes are rebuilt at the same time
+	assert.NotEmpty(t, httphelper.GetPodJobID(rc.dcPods[0], httphelper.JobTypeRebuild))
+	assert.NotEmpty(t, httphelper.GetPodJobID(rc.dcPods[1], httphelper.JobTypeRebuild))
+	assert.Empty(t, httphelper.GetPodJobID(rc.dcPods[2], httphelper.JobTypeRebuild))
+
+	condition, _ := dc.GetCondition(api.DatacenterAddingDatacenter)
+	assert.Equal(t, corev1.ConditionTrue, condition.Status)
+	assert.Equal(t, "Rebuilding", condition.Reason)
+	assert.Equal(t, "Rebuilt 0 of 3 nodes from datacenter dc1", condition.Message)
+
+	assert.True(t, rc.CheckRebuild().Completed())
+	condition, _ = dc.GetCondition(api.DatacenterAddingDatacenter)
+	assert.Equal(t, "Rebuilt 2 of 3 nodes from datacenter dc1", condition.Message)
+
+	assert.False(t, rc.CheckRebuild().Completed())
+	assert.Equal(t, corev1.ConditionFalse, dc.GetConditionStatus(api.DatacenterAddingDatacenter))
+	for _, pod := range rc.dcPods {
+		node, _ := server.Node(pod.Status.PodIP)
+		assert.Equal(t, []string{"dc1"}, node.Rebuilds)
+	}
+}
+
+func TestReplicationSettings(t *testing.T) {
+	assert.Equal(t, []httphelper.ReplicationSetting{
+		{DcName: "dc1", ReplicationFactor: 3},
+		{DcName: "dc2", ReplicationFactor: 1},
+	}, replicationSettings(map[string]string{
+		"class": "org.apache.cassandra.locator.NetworkTopologyStrategy",
+		"dc2":   "1",
+		"dc1":   "3",
+	}, "dc1"))
+
+	assert.Equal(t, []httphelper.ReplicationSetting{
+		{DcName: "dc1", ReplicationFactor: 2},
+	}, replicationSettings(map[string]string{
+		"class":              "org.apache.cassandra.locator.SimpleStrategy",
+		"replication_factor": "2",
+	}, "dc1"))
+}
@@ -0,0 +1,204 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/datastax/cass-operator/operator/internal/result"
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/datastax/cass-operator/operator/pkg/events"
+	"github.com/datastax/cass-operator/operator/pkg/utils"
+)
+
+// CheckPodServices keeps a LoadBalancer service for each pod of the rack
+// statefulsets when external access is enabled, and annotates each pod with
+// the address of its service. The server-config-init container of the pod
+// waits for the annotation before it fills in the broadcast_rpc_address. The
+// services of pods that are not part of the racks anymore are deleted.
+func (rc *ReconciliationContext) CheckPodServices() result.ReconcileResult {
+	rc.ReqLogger.Info("reconcile_pod_services::CheckPodServices")
+
+	dc := rc.Datacenter
+
+	podNames := []string{}
+	if dc.IsExternalAccessEnabled() {
+		podNames = rc.getRackPodNames()
+	}
+	desiredServices := map[string]*corev1.Service{}
+	for _, podName := range podNames {
+		desiredServices[podName] = newPodServiceForCassandraDatacenter(dc, podName)
+	}
+
+	currentServices := &corev1.ServiceList{}
+	err := rc.Client.List(rc.Ctx, currentServices,
+		client.InNamespace(dc.Namespace),
+		client.MatchingLabels(dc.GetDatacenterLabels()),
+		client.HasLabels{api.PodServiceLabel})
+	if err != nil {
+		return result.Error(err)
+	}
+
+	existing := map[string]*corev1.Service{}
+	for i := range currentServices.Items {
+		service := &currentServices.Items[i]
+		podName := service.Labels[api.PodServiceLabel]
+		if _, ok := desiredServices[podName]; ok {
+			existing[podName] = service
+			continue
+		}
+
+		rc.ReqLogger.Info("Deleting service of pod", "service", service.Name)
+		if err := rc.Client.Delete(rc.Ctx, service); err != nil && !errors.IsNotFound(err) {
+			return result.Error(err)
+		}
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.DeletedResource,
+			"Deleted service %s", service.Name)
+	}
+
+	for _, podName := range podNames {
+		desiredService := desiredServices[podName]
+		if err := setControllerReference(dc, desiredService, rc.Scheme); err != nil {
+			return result.Error(err)
+		}
+
+		currentService, ok := existing[podName]
+		if !ok {
+			rc.ReqLogger.Info("Creating service of pod", "service", desiredService.Name)
+			if err := rc.Client.Create(rc.Ctx, desiredService); err != nil {
+				return result.Error(err)
+			}
+			rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedResource,
+				"Created service %s", desiredService.Name)
+			existing[podName] = desiredService
+			continue
+		}
+
+		if !utils.ResourcesHaveSameHash(currentService, desiredService) {
+			rc.ReqLogger.Info("Updating service of pod", "service", desiredService.Name)
+			if err := rc.Client.Update(rc.Ctx, updatedPodService(currentService, desiredService)); err != nil {
+				return result.Error(err)
+			}
+		}
+	}
+
+	waiting := false
+	for _, pod := range rc.dcPods {
+		currentService, ok := existing[pod.Name]
+		if !ok {
+			continue
+		}
+		address, err := getLoadBalancerAddress(currentService, pod.Annotations[api.ExternalAddressAnnotation])
+		if err != nil {
+			rc.ReqLogger.Error(err, "Could not resolve the load balancer hostname of the service",
+				"service", currentService.Name)
+		}
+		if address == "" {
+			waiting = true
+			continue
+		}
+		if pod.Annotations[api.ExternalAddressAnnotation] == address {
+			continue
+		}
+
+		rc.ReqLogger.Info("Annotating pod with the address of its service",
+			"pod", pod.Name, "address", address)
+		patch := client.MergeFrom(pod.DeepCopy())
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[api.ExternalAddressAnnotation] = address
+		if err := rc.Client.Patch(rc.Ctx, pod, patch); err != nil {
+			return result.Error(err)
+		}
+	}
+
+	// The pods without an address wait in their init container, the other
+	// pods are reconciled meanwhile. The update of the service status
+	// triggers a new reconcile.
+	if waiting {
+		rc.ReqLogger.Info("Waiting for the load balancers of the pods to get an address")
+	}
+
+	return result.Continue()
+}
+
+// getRackPodNames returns the names of the pods of the rack statefulsets, up to
+// the number of nodes of the rack or the replicas of its statefulset,
+// whichever is more
+func (rc *ReconciliationContext) getRackPodNames() []string {
+	nodeCounts := map[string]int{}
+	for _, rackInfo := range rc.desiredRackInformation {
+		nodeCounts[newNamespacedNameForStatefulSet(rc.Datacenter, rackInfo.RackName).Name] = rackInfo.NodeCount
+	}
+
+	podNames := []string{}
+	for _, statefulSet := range rc.statefulSets {
+		if statefulSet == nil {
+			continue
+		}
+		count := nodeCounts[statefulSet.Name]
+		if statefulSet.Spec.Replicas != nil && int(*statefulSet.Spec.Replicas) > count {
+			count = int(*statefulSet.Spec.Replicas)
+		}
+		for i := 0; i < count; i++ {
+			podNames = append(podNames, fmt.Sprintf("%s-%d", statefulSet.Name, i))
+		}
+	}
+	return podNames
+}
+
+// updatedPodService returns the current service of a pod with the desired
+// spec, keeping the addresses and ports allocated to it
+func updatedPodService(currentService, desiredService *corev1.Service) *corev1.Service {
+	service := currentService.DeepCopy()
+	service.Labels = utils.MergeMap(map[string]string{}, currentService.Labels, desiredService.Labels)
+	service.Annotations = utils.MergeMap(map[string]string{}, currentService.Annotations, desiredService.Annotations)
+
+	nodePorts := map[string]int32{}
+	for _, port := range currentService.Spec.Ports {
+		nodePorts[port.Name] = port.NodePort
+	}
+
+	service.Spec = *desiredService.Spec.DeepCopy()
+	service.Spec.ClusterIP = currentService.Spec.ClusterIP
+	for i := range service.Spec.Ports {
+		service.Spec.Ports[i].NodePort = nodePorts[service.Spec.Ports[i].Name]
+	}
+	return service
+}
+
+// getLoadBalancerAddress returns the IP of the load balancer of a service,
+// empty until it is provisioned. The broadcast_rpc_address must be an IP, so
+// the hostname of a load balancer that has no IP is resolved, keeping the
+// current address while the hostname still resolves to it.
+func getLoadBalancerAddress(service *corev1.Service, currentAddress string) (string, error) {
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			return ingress.IP, nil
+		}
+	}
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.Hostname == "" {
+			continue
+		}
+		ips, err := resolveAddress(ingress.Hostname)
+		if err != nil {
+			return "", err
+		}
+		for _, ip := range ips {
+			if ip == currentAddress {
+				return ip, nil
+			}
+		}
+		if len(ips) > 0 {
+			return ips[0], nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,153 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/datastax/cass-operator/operator/internal/result"
+	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func TestCheckPodServices(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.ServerType = "cassandra"
+	dc.Spec.ServerVersion = "4.0.0"
+	dc.Spec.UseConfigRenderer = true
+	dc.Spec.Racks = []api.Rack{{Name: "r1"}}
+	dc.Spec.Networking = &api.NetworkingConfig{
+		ExternalAccess: &api.ExternalAccessConfig{
+			LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+		},
+	}
+	rc.desiredRackInformation = []*RackInformation{{RackName: "r1", NodeCount: 2}}
+
+	statefulSet, err := newStatefulSetForCassandraDatacenter("r1", dc, 1)
+	assert.NoError(t, err)
+	rc.statefulSets = []*appsv1.StatefulSet{statefulSet}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      statefulSet.Name + "-0",
+			Namespace: dc.Namespace,
+			Labels:    dc.GetDatacenterLabels(),
+		},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+	rc.dcPods = []*corev1.Pod{pod}
+
+	// The services are created, the pod waits for the address of its service
+	// without holding up the reconcile
+	assert.Equal(t, result.Continue(), rc.CheckPodServices())
+
+	services := &corev1.ServiceList{}
+	assert.NoError(t, rc.Client.List(rc.Ctx, services))
+	serviceNames := []string{}
+	for _, service := range services.Items {
+		serviceNames = append(serviceNames, service.Name)
+		assert.Equal(t, corev1.ServiceTypeLoadBalancer, service.Spec.Type)
+		assert.Equal(t, []string{"10.0.0.0/8"}, service.Spec.LoadBalancerSourceRanges)
+		assert.Equal(t, service.Labels[api.PodServiceLabel], service.Spec.Selector["statefulset.kubernetes.io/pod-name"])
+	}
+	assert.ElementsMatch(t, []string{pod.Name + "-external", statefulSet.Name + "-1-external"}, serviceNames)
+	assert.Empty(t, pod.Annotations[api.ExternalAddressAnnotation])
+
+	// The pod is annotated with the address of its load balancer
+	service := &corev1.Service{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: pod.Name + "-external", Namespace: dc.Namespace}, service))
+	service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "203.0.113.10"}}
+	assert.NoError(t, rc.Client.Update(rc.Ctx, service))
+
+	assert.Equal(t, result.Continue(), rc.CheckPodServices())
+
+	currentPod := &corev1.Pod{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: pod.Name, Namespace: dc.Namespace}, currentPod))
+	assert.Equal(t, "203.0.113.10", currentPod.Annotations[api.ExternalAddressAnnotation])
+
+	// The services of the pods removed from the rack are deleted
+	rc.desiredRackInformation[0].NodeCount = 1
+	assert.Equal(t, result.Continue(), rc.CheckPodServices())
+
+	services = &corev1.ServiceList{}
+	assert.NoError(t, rc.Client.List(rc.Ctx, services))
+	assert.Len(t, services.Items, 1)
+	assert.Equal(t, pod.Name+"-external", services.Items[0].Name)
+
+	// Disabling external access deletes all of them
+	dc.Spec.Networking = nil
+	assert.Equal(t, result.Continue(), rc.CheckPodServices())
+
+	services = &corev1.ServiceList{}
+	assert.NoError(t, rc.Client.List(rc.Ctx, services))
+	assert.Empty(t, services.Items)
+}
+
+func TestGetLoadBalancerAddress(t *testing.T) {
+	service := &corev1.Service{}
+
+	// Not provisioned yet
+	address, err := getLoadBalancerAddress(service, "")
+	assert.NoError(t, err)
+	assert.Empty(t, address)
+
+	// A hostname is resolved to an IP
+	service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "localhost"}}
+	address, err = getLoadBalancerAddress(service, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", address)
+
+	// The IP is preferred to the hostname
+	service.Status.LoadBalancer.Ingress = append(service.Status.LoadBalancer.Ingress,
+		corev1.LoadBalancerIngress{IP: "203.0.113.10"})
+	address, err = getLoadBalancerAddress(service, "127.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "203.0.113.10", address)
+
+	// A hostname that does not resolve has no address
+	service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "lb.invalid"}}
+	address, err = getLoadBalancerAddress(service, "")
+	assert.Error(t, err)
+	assert.Empty(t, address)
+}
+
+func TestUpdatedPodService(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dc1",
+			Namespace: "test",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "bob",
+			Networking: &api.NetworkingConfig{
+				ExternalAccess: &api.ExternalAccessConfig{},
+			},
+		},
+	}
+
+	currentService := newPodServiceForCassandraDatacenter(dc, "bob-dc1-r1-sts-0")
+	currentService.Spec.ClusterIP = "10.96.0.12"
+	for i := range currentService.Spec.Ports {
+		currentService.Spec.Ports[i].NodePort = int32(30000 + i)
+	}
+
+	dc.Spec.Networking.ExternalAccess.LoadBalancerSourceRanges = []string{"192.168.0.0/16"}
+	desiredService := newPodServiceForCassandraDatacenter(dc, "bob-dc1-r1-sts-0")
+
+	service := updatedPodService(currentService, desiredService)
+	assert.Equal(t, "10.96.0.12", service.Spec.ClusterIP)
+	assert.Equal(t, []string{"192.168.0.0/16"}, service.Spec.LoadBalancerSourceRanges)
+	for i, port := range service.Spec.Ports {
+		assert.Equal(t, int32(30000+i), port.NodePort)
+	}
+	assert.Equal(t, desiredService.Annotations, service.Annotations)
+}
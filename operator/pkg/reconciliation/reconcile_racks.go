@@ -205,6 +205,18 @@ func (rc *ReconciliationContext) CheckRackPodTemplate() result.ReconcileResult {
 			return result.Error(err)
 		}
 
+		// Config changes that do not need a restart are applied to the
+		// running nodes instead of rolling the pods
+		if !dc.Spec.CanaryUpgrade {
+			upToDate, err := rc.applyRuntimeConfig(statefulSet, desiredSts, rackName)
+			if err != nil {
+				return result.Error(err)
+			}
+			if upToDate && !utils.ResourcesHaveSameHash(statefulSet, desiredSts) {
+				continue
+			}
+		}
+
 		// Set the CassandraDatacenter as the owner and controller
 		err = setControllerReference(
 			rc.Datacenter,
@@ -257,6 +269,12 @@ func (rc *ReconciliationContext) CheckRackPodTemplate() result.ReconcileResult {
 			updated := rc.setCondition(
 				api.NewDatacenterCondition(api.DatacenterUpdating, corev1.ConditionTrue))
 
+			// The restarted pods get every setting from the pod template
+			if _, ok := dc.Status.HotAppliedConfig[rackName]; ok {
+				delete(dc.Status.HotAppliedConfig, rackName)
+				updated = true
+			}
+
 			if updated {
 				err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch)
 				if err != nil {
@@ -273,6 +291,11 @@ func (rc *ReconciliationContext) CheckRackPodTemplate() result.ReconcileResult {
 				"statefulSet", statefulSet,
 			)
 
+			// The restarted pods read their config files from the config map
+			if err := rc.checkRackConfigMap(statefulSet); err != nil {
+				return result.Error(err)
+			}
+
 			err = rc.Client.Update(rc.Ctx, statefulSet)
 			if err != nil {
 				logger.Error(
@@ -448,81 +471,92 @@ func (rc *ReconciliationContext) CheckRackStoppedState() result.ReconcileResult
 	logger := rc.ReqLogger
 	dc := rc.Datacenter
 
-	emittedStoppingEvent := false
-	racksUpdated := false
-	for idx := range rc.desiredRackInformation {
-		rackInfo := rc.desiredRackInformation[idx]
-		statefulSet := rc.statefulSets[idx]
+	if !dc.Spec.Stopped {
+		return result.Continue()
+	}
 
-		stopped := rc.Datacenter.Spec.Stopped
-		currentPodCount := *statefulSet.Spec.Replicas
+	racksToStop := []int{}
+	for idx := range rc.desiredRackInformation {
+		if *rc.statefulSets[idx].Spec.Replicas > 0 {
+			racksToStop = append(racksToStop, idx)
+		}
+	}
+	if len(racksToStop) == 0 {
+		return result.Continue()
+	}
 
-		if stopped && currentPodCount > 0 {
-			logger.Info(
-				"CassandraDatacenter is stopped, setting rack to zero replicas",
-				"rack", rackInfo.RackName,
-				"currentSize", currentPodCount,
-			)
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	updated := rc.setCondition(
+		api.NewDatacenterCondition(api.DatacenterStopped, corev1.ConditionTrue))
+	updated = rc.setCondition(
+		api.NewDatacenterCondition(
+			api.DatacenterReady, corev1.ConditionFalse)) || updated
 
-			if !emittedStoppingEvent {
-				dcPatch := client.MergeFrom(dc.DeepCopy())
-				updated := rc.setCondition(
-					api.NewDatacenterCondition(api.DatacenterStopped, corev1.ConditionTrue))
-				updated = rc.setCondition(
-					api.NewDatacenterCondition(
-						api.DatacenterReady, corev1.ConditionFalse)) || updated
+	if updated {
+		err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch)
+		if err != nil {
+			logger.Error(err, "error patching datacenter status for stopping")
+			return result.Error(err)
+		}
+	}
 
-				if updated {
-					err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch)
-					if err != nil {
-						logger.Error(err, "error patching datacenter status for stopping")
-						return result.Error(err)
-					}
-				}
+	rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.StoppingDatacenter,
+		"Stopping datacenter")
 
-				rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.StoppingDatacenter,
-					"Stopping datacenter")
-				emittedStoppingEvent = true
-			}
+	// Remember where every node stood in the ring, so that the nodes that
+	// come back with their data can be started together on resume
+	if err := rc.recordHibernationState(); err != nil {
+		return result.Error(err)
+	}
 
-			rackPods := FilterPodListByLabels(rc.dcPods, rc.Datacenter.GetRackLabels(rackInfo.RackName))
-
-			nodesDrained := 0
-			nodeDrainErrors := 0
-
-			for _, pod := range rackPods {
-				if isMgmtApiRunning(pod) {
-					nodesDrained++
-					err := rc.NodeMgmtClient.CallDrainEndpoint(pod)
-					// if we got an error during drain, just log it and count it
-					// and then keep going, because we don't want to try restarting
-					// the server just to bring it down
-					if err != nil {
-						logger.Error(err, "error during node drain",
-							"pod", pod.Name)
-						nodeDrainErrors++
-					}
+	// Every node is drained before any rack is scaled down, so that none of
+	// them is left to take writes for nodes that are already gone
+	for _, idx := range racksToStop {
+		rackInfo := rc.desiredRackInformation[idx]
+		rackPods := FilterPodListByLabels(rc.dcPods, rc.Datacenter.GetRackLabels(rackInfo.RackName))
+
+		nodesDrained := 0
+		nodeDrainErrors := 0
+
+		for _, pod := range rackPods {
+			if isMgmtApiRunning(pod) {
+				nodesDrained++
+				err := rc.NodeMgmtClient.CallDrainEndpoint(pod)
+				// if we got an error during drain, just log it and count it
+				// and then keep going, because we don't want to try restarting
+				// the server just to bring it down
+				if err != nil {
+					logger.Error(err, "error during node drain",
+						"pod", pod.Name)
+					nodeDrainErrors++
 				}
 			}
+		}
 
-			logger.Info("rack drains done",
-				"rack", rackInfo.RackName,
-				"nodesDrained", nodesDrained,
-				"nodeDrainErrors", nodeDrainErrors,
-			)
+		logger.Info("rack drains done",
+			"rack", rackInfo.RackName,
+			"nodesDrained", nodesDrained,
+			"nodeDrainErrors", nodeDrainErrors,
+		)
+	}
 
-			err := rc.UpdateRackNodeCount(statefulSet, 0)
-			if err != nil {
-				return result.Error(err)
-			}
-			racksUpdated = true
+	for _, idx := range racksToStop {
+		rackInfo := rc.desiredRackInformation[idx]
+		statefulSet := rc.statefulSets[idx]
+
+		logger.Info(
+			"CassandraDatacenter is stopped, setting rack to zero replicas",
+			"rack", rackInfo.RackName,
+			"currentSize", *statefulSet.Spec.Replicas,
+		)
+
+		err := rc.UpdateRackNodeCount(statefulSet, 0)
+		if err != nil {
+			return result.Error(err)
 		}
 	}
 
-	if racksUpdated {
-		return result.Done()
-	}
-	return result.Continue()
+	return result.Done()
 }
 
 // checkSeedLabels loops over all racks and makes sure that the proper pods are labelled as seeds.
@@ -597,7 +631,20 @@ func (rc *ReconciliationContext) CheckPodsReady(endpointData httphelper.CassMeta
 		return result.RequeueSoon(2)
 	}
 
-	// step 2 - get one node up per rack
+	// step 2 - when resuming from hibernation, start the nodes that kept
+	// their data a whole rack at a time
+
+	if rc.Datacenter.GetConditionStatus(api.DatacenterResuming) == corev1.ConditionTrue {
+		resumedRack, err := rc.startHibernatedRack(endpointData, seedCount)
+		if err != nil {
+			return result.Error(err)
+		}
+		if resumedRack != "" {
+			return result.RequeueSoon(2)
+		}
+	}
+
+	// step 3 - get one node up per rack
 
 	rackWaitingForANode, err := rc.startOneNodePerRack(endpointData, seedCount)
 
@@ -608,7 +655,7 @@ func (rc *ReconciliationContext) CheckPodsReady(endpointData httphelper.CassMeta
 		return result.RequeueSoon(2)
 	}
 
-	// step 3 - get all nodes up
+	// step 4 - get all nodes up
 	// if the cluster isn't healthy, that's ok, but go back to step 1
 	if !rc.isClusterHealthy() {
 		rc.ReqLogger.Info(
@@ -938,16 +985,34 @@ func (rc *ReconciliationContext) CreateUsers() result.ReconcileResult {
 	return result.Continue()
 }
 
-func findHostIdForIpFromEndpointsData(endpointsData []httphelper.EndpointState, ip string) string {
-	for _, data := range endpointsData {
-		if data.GetRpcAddress() == ip {
-			return data.HostID
+// findEndpointForPod returns the gossip state of the node of a pod, found by
+// the RPC address of the pod or else by the host ID recorded for it
+func findEndpointForPod(dc *api.CassandraDatacenter, pod *corev1.Pod, endpointsData []httphelper.EndpointState) *httphelper.EndpointState {
+	if ip := getRpcAddress(dc, pod); ip != "" {
+		for i := range endpointsData {
+			if endpointsData[i].GetRpcAddress() == ip {
+				return &endpointsData[i]
+			}
 		}
 	}
-	return ""
+
+	if hostID := dc.Status.NodeStatuses[pod.Name].HostID; hostID != "" {
+		for i := range endpointsData {
+			if endpointsData[i].HostID == hostID {
+				return &endpointsData[i]
+			}
+		}
+	}
+	return nil
 }
 
+// getRpcAddress returns the address the node of a pod advertises to clients.
+// With external access, it is the address of the load balancer of the pod.
 func getRpcAddress(dc *api.CassandraDatacenter, pod *corev1.Pod) string {
+	if dc.IsExternalAccessEnabled() {
+		return pod.Annotations[api.ExternalAddressAnnotation]
+	}
+
 	nc := dc.Spec.Networking
 	if nc != nil {
 		if nc.HostNetwork {
@@ -984,9 +1049,9 @@ func (rc *ReconciliationContext) UpdateCassandraNodeStatus() error {
 			if nodeStatus.HostID == "" {
 				endpointsResponse, err := rc.NodeMgmtClient.CallMetadataEndpointsEndpoint(pod)
 				if err == nil {
-					ip := getRpcAddress(dc, pod)
-					nodeStatus.HostID = findHostIdForIpFromEndpointsData(
-						endpointsResponse.Entity, ip)
+					if ep := findEndpointForPod(dc, pod, endpointsResponse.Entity); ep != nil {
+						nodeStatus.HostID = ep.HostID
+					}
 					if nodeStatus.HostID == "" {
 						logger.Info("Failed to find host ID", "pod", pod.Name)
 					}
@@ -1417,12 +1482,32 @@ func (rc *ReconciliationContext) ReconcileNextRack(statefulSet *appsv1.StatefulS
 func (rc *ReconciliationContext) CheckDcPodDisruptionBudget() result.ReconcileResult {
 	// Create a PodDisruptionBudget for the CassandraDatacenter
 	dc := rc.Datacenter
-	ctx := rc.Ctx
+	if dc.Spec.PodDisruptionBudgetScope == api.PodDisruptionBudgetRack {
+		// Handled by CheckRackPodDisruptionBudgets
+		return result.Continue()
+	}
+
 	desiredBudget := newPodDisruptionBudgetForDatacenter(dc)
+	if err := rc.checkPodDisruptionBudget(desiredBudget); err != nil {
+		return result.Error(err)
+	}
+
+	if err := rc.deleteStalePodDisruptionBudgets(utils.StringSet{desiredBudget.Name: true}); err != nil {
+		return result.Error(err)
+	}
+
+	return result.Continue()
+}
+
+// checkPodDisruptionBudget creates the budget, or re-creates it if it differs
+// from the existing one
+func (rc *ReconciliationContext) checkPodDisruptionBudget(desiredBudget *policyv1beta1.PodDisruptionBudget) error {
+	dc := rc.Datacenter
+	ctx := rc.Ctx
 
 	// Set CassandraDatacenter as the owner and controller
 	if err := setControllerReference(dc, desiredBudget, rc.Scheme); err != nil {
-		return result.Error(err)
+		return err
 	}
 
 	// Check if the budget already exists
@@ -1435,13 +1520,13 @@ func (rc *ReconciliationContext) CheckDcPodDisruptionBudget() result.ReconcileRe
 		currentBudget)
 
 	if err != nil && !errors.IsNotFound(err) {
-		return result.Error(err)
+		return err
 	}
 
 	found := err == nil
 
 	if found && utils.ResourcesHaveSameHash(currentBudget, desiredBudget) {
-		return result.Continue()
+		return nil
 	}
 
 	// it's not possible to update a PodDisruptionBudget, so we need to delete this one and remake it
@@ -1455,7 +1540,7 @@ func (rc *ReconciliationContext) CheckDcPodDisruptionBudget() result.ReconcileRe
 		)
 		err = rc.Client.Delete(ctx, currentBudget)
 		if err != nil {
-			return result.Error(err)
+			return err
 		}
 	}
 
@@ -1467,13 +1552,13 @@ func (rc *ReconciliationContext) CheckDcPodDisruptionBudget() result.ReconcileRe
 
 	err = rc.Client.Create(ctx, desiredBudget)
 	if err != nil {
-		return result.Error(err)
+		return err
 	}
 
 	rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.CreatedResource,
 		"Created PodDisruptionBudget %s", desiredBudget.Name)
 
-	return result.Continue()
+	return nil
 }
 
 // Updates the node count on a rack (statefulset)
@@ -1787,6 +1872,22 @@ func (rc *ReconciliationContext) startCassandra(endpointData httphelper.CassMeta
 	return rc.labelServerPodStarting(pod)
 }
 
+// labelPodAsSeed labels a pod as a seed before Cassandra is started on it
+func (rc *ReconciliationContext) labelPodAsSeed(pod *corev1.Pod) error {
+	patch := client.MergeFrom(pod.DeepCopy())
+	pod.Labels[api.SeedNodeLabel] = "true"
+	if err := rc.Client.Patch(rc.Ctx, pod, patch); err != nil {
+		return err
+	}
+
+	rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.LabeledPodAsSeed,
+		"Labeled pod a seed node %s", pod.Name)
+
+	// sleeping five seconds for DNS paranoia
+	time.Sleep(5 * time.Second)
+	return nil
+}
+
 // returns the name of one rack without any ready node
 func (rc *ReconciliationContext) startOneNodePerRack(endpointData httphelper.CassMetadataEndpoints, readySeeds int) (string, error) {
 
@@ -1805,8 +1906,8 @@ func (rc *ReconciliationContext) startOneNodePerRack(endpointData httphelper.Cas
 	}
 
 	// if the DC has no ready seeds, label a pod as a seed before we start Cassandra on it
-	// and also consider additional seeds
-	labelSeedBeforeStart := readySeeds == 0 && len(rc.Datacenter.Spec.AdditionalSeeds) == 0
+	// and also consider additional seeds and the seeds of other datacenters
+	labelSeedBeforeStart := readySeeds == 0 && len(rc.Datacenter.Spec.AdditionalSeeds) == 0 && len(rc.peerSeeds) == 0
 
 	rackThatNeedsNode := ""
 	for rackName, readyCount := range rackReadyCount {
@@ -1823,17 +1924,9 @@ func (rc *ReconciliationContext) startOneNodePerRack(endpointData httphelper.Cas
 			if podRack == rackName {
 				// this is the one exception to all seed labelling happening in labelSeedPods()
 				if labelSeedBeforeStart {
-					patch := client.MergeFrom(pod.DeepCopy())
-					pod.Labels[api.SeedNodeLabel] = "true"
-					if err := rc.Client.Patch(rc.Ctx, pod, patch); err != nil {
+					if err := rc.labelPodAsSeed(pod); err != nil {
 						return "", err
 					}
-
-					rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.LabeledPodAsSeed,
-						"Labeled pod a seed node %s", pod.Name)
-
-					// sleeping five seconds for DNS paranoia
-					time.Sleep(5 * time.Second)
 				}
 				if err := rc.startCassandra(endpointData, pod); err != nil {
 					return "", err
@@ -1968,6 +2061,14 @@ func (rc *ReconciliationContext) refreshSeeds() error {
 
 	for _, pod := range startedPods {
 		if err := rc.NodeMgmtClient.CallReloadSeedsEndpoint(pod); err != nil {
+			// One unreachable node should not hold up the rest of the
+			// datacenter. It will pick up the seeds on a later reconcile.
+			if httphelper.IsUnreachable(err) {
+				rc.ReqLogger.Info("skipping seed reload for unreachable pod",
+					"pod", pod.Name,
+					"error", err.Error())
+				continue
+			}
 			return err
 		}
 	}
@@ -2054,6 +2155,23 @@ func (rc *ReconciliationContext) setCondition(condition *api.DatacenterCondition
 	return false
 }
 
+// updateCondition is like setCondition, but also records a new reason or
+// message for a condition whose status does not change
+func (rc *ReconciliationContext) updateCondition(condition *api.DatacenterCondition) bool {
+	if rc.setCondition(condition) {
+		return true
+	}
+
+	dc := rc.Datacenter
+	existing, _ := dc.GetCondition(condition.Type)
+	if existing.Reason == condition.Reason && existing.Message == condition.Message {
+		return false
+	}
+	condition.LastTransitionTime = existing.LastTransitionTime
+	dc.SetCondition(*condition)
+	return true
+}
+
 func (rc *ReconciliationContext) CheckConditionInitializedAndReady() result.ReconcileResult {
 	dc := rc.Datacenter
 	dcPatch := client.MergeFrom(dc.DeepCopy())
@@ -2083,16 +2201,53 @@ func (rc *ReconciliationContext) CheckConditionInitializedAndReady() result.Reco
 	return result.Continue()
 }
 
-func (rc *ReconciliationContext) cleanupAfterScaling() error {
+// cleanupAfterScaling runs a cleanup on the first node that accepts it and
+// reports whether it has finished. A failed cleanup job is reported but does
+// not hold up the datacenter.
+func (rc *ReconciliationContext) cleanupAfterScaling() (bool, error) {
+	unreachablePod := ""
+	for _, pod := range rc.dcPods {
+		if httphelper.GetPodJobID(pod, httphelper.JobTypeCleanup) != "" {
+			job, err := rc.checkJob(pod, httphelper.JobTypeCleanup)
+			if err == nil {
+				return job.IsDone(), nil
+			}
+			if !httphelper.IsUnreachable(err) {
+				return false, err
+			}
+
+			// The job cannot be followed on a node that is down, the cleanup
+			// is submitted again to a node that is up
+			rc.ReqLogger.Info("Could not check on cleanup job, submitting it again",
+				"pod", pod.Name,
+				"error", err.Error())
+			if err := rc.jobTracker().Forget(pod, httphelper.JobTypeCleanup); err != nil {
+				return false, err
+			}
+			unreachablePod = pod.Name
+			break
+		}
+	}
+
 	var err error
 
 	for idx := range rc.dcPods {
-		err = rc.NodeMgmtClient.CallKeyspaceCleanupEndpoint(rc.dcPods[idx], -1, "", nil)
+		if rc.dcPods[idx].Name == unreachablePod {
+			continue
+		}
+		var submitted bool
+		submitted, err = rc.submitJob(rc.dcPods[idx], httphelper.JobTypeCleanup,
+			func(pod *corev1.Pod) (string, error) {
+				return rc.NodeMgmtClient.CallKeyspaceCleanupAsyncEndpoint(pod, httphelper.KeyspaceRequest{})
+			},
+			func(pod *corev1.Pod) error {
+				return rc.NodeMgmtClient.CallKeyspaceCleanupEndpoint(pod, -1, "", nil)
+			})
 		if err == nil {
-			break
+			return !submitted, nil
 		}
 	}
-	return err
+	return err == nil, err
 }
 
 func (rc *ReconciliationContext) CheckCassandraNodeStatuses() result.ReconcileResult {
@@ -2132,11 +2287,15 @@ func (rc *ReconciliationContext) CheckClearActionConditions() result.ReconcileRe
 
 	// Explicitly handle scaling up here because we want to run a cleanup afterwards
 	if dc.GetConditionStatus(api.DatacenterScalingUp) == corev1.ConditionTrue {
-		err := rc.cleanupAfterScaling()
+		done, err := rc.cleanupAfterScaling()
 		if err != nil {
 			logger.Error(err, "error cleaning up after scaling datacenter")
 			return result.Error(err)
 		}
+		if !done {
+			logger.Info("Waiting for cleanup after scaling datacenter")
+			return result.RequeueSoon(10)
+		}
 
 		updated = rc.setCondition(
 			api.NewDatacenterCondition(api.DatacenterScalingUp, corev1.ConditionFalse)) || updated
@@ -2276,14 +2435,30 @@ func (rc *ReconciliationContext) ReconcileAllRacks() (reconcile.Result, error) {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckRetainedPVCs(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckRackCreation(); recResult.Completed() {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckRackConfigMaps(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckPodServices(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckRackLabels(); recResult.Completed() {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckRackPodDisruptionBudgets(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckDecommissioningNodes(endpointData); recResult.Completed() {
 		return recResult.Output()
 	}
@@ -2304,12 +2479,24 @@ func (rc *ReconciliationContext) ReconcileAllRacks() (reconcile.Result, error) {
 		// if recResult := psp.CheckPVCHealth(rc); recResult.Completed() {
 		// 	return recResult.Output()
 		// }
+	} else if rc.Datacenter.Spec.NodeMaintenance != nil {
+		if recResult := psp.CheckNodeMaintenance(rc, rc.Datacenter.Spec.NodeMaintenance); recResult.Completed() {
+			return recResult.Output()
+		}
 	}
 
 	if recResult := rc.CheckRackScale(); recResult.Completed() {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckFailedNodes(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckDatacenterBootstrapOrder(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckPodsReady(endpointData); recResult.Completed() {
 		return recResult.Output()
 	}
@@ -2342,6 +2529,10 @@ func (rc *ReconciliationContext) ReconcileAllRacks() (reconcile.Result, error) {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckImageDigests(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckRackPodLabels(); recResult.Completed() {
 		return recResult.Output()
 	}
@@ -2350,6 +2541,10 @@ func (rc *ReconciliationContext) ReconcileAllRacks() (reconcile.Result, error) {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckRebuild(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckClearActionConditions(); recResult.Completed() {
 		return recResult.Output()
 	}
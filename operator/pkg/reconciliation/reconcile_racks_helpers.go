@@ -61,9 +61,11 @@ func FindIpForHostId(endpointData httphelper.CassMetadataEndpoints, hostId strin
 	}
 
 	// Search for a cassandra node that knows about the given hostId
+	// The node is replaced by its gossip address, which is not the one it
+	// advertises to clients with external access
 	for _, ep := range endpointData.Entity {
-		if ep.HostID == hostId && len(ep.GetRpcAddress()) > 0 {
-			return ep.GetRpcAddress(), nil
+		if ep.HostID == hostId && len(ep.GetEndpointAddress()) > 0 {
+			return ep.GetEndpointAddress(), nil
 		}
 	}
 
@@ -81,15 +83,12 @@ func PodPtrsFromPodList(podList *corev1.PodList) []*corev1.Pod {
 	return pods
 }
 
-func MapPodsToEndpointDataByName(pods []*v1.Pod, epData httphelper.CassMetadataEndpoints) map[string]httphelper.EndpointState {
+func MapPodsToEndpointDataByName(dc *api.CassandraDatacenter, pods []*v1.Pod, epData httphelper.CassMetadataEndpoints) map[string]httphelper.EndpointState {
 	result := make(map[string]httphelper.EndpointState)
 	for idx := range pods {
 		pod := pods[idx]
-		for _, data := range epData.Entity {
-			if data.GetRpcAddress() == pod.Status.PodIP {
-				result[pod.Name] = data
-				continue
-			}
+		if data := findEndpointForPod(dc, pod, epData.Entity); data != nil {
+			result[pod.Name] = *data
 		}
 	}
 
@@ -13,8 +13,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/datastax/cass-operator/operator/internal/result"
 	api "github.com/datastax/cass-operator/operator/pkg/apis/cassandra/v1beta1"
 	"github.com/datastax/cass-operator/operator/pkg/httphelper"
+	"github.com/datastax/cass-operator/operator/pkg/httphelper/fakemgmtapi"
 	"github.com/datastax/cass-operator/operator/pkg/mocks"
 	"github.com/datastax/cass-operator/operator/pkg/oplabels"
 	"github.com/datastax/cass-operator/operator/pkg/utils"
@@ -1382,3 +1384,399 @@ func Test_callPodEndpoint_RequestFail(t *testing.T) {
 		assert.Fail(t, "Should have returned error")
 	}
 }
+
+func TestRefreshSeeds_SkipsUnreachablePods(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	res := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader("OK")),
+	}
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req.URL.Host == "1.1.1.1:8080"
+			})).
+		Return(nil, fmt.Errorf("connection refused")).
+		Once()
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req.URL.Host == "2.2.2.2:8080"
+			})).
+		Return(res, nil).
+		Once()
+
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{
+		Client:      mockHttpClient,
+		Log:         rc.ReqLogger,
+		Protocol:    "http",
+		RetryPolicy: &httphelper.RetryPolicy{MaxAttempts: 1},
+	}
+
+	unreachablePod := makeReloadTestPod()
+	unreachablePod.Name = "unreachable"
+	unreachablePod.Labels[api.CassNodeState] = stateStarted
+	unreachablePod.Status.PodIP = "1.1.1.1"
+
+	healthyPod := makeReloadTestPod()
+	healthyPod.Name = "healthy"
+	healthyPod.Labels[api.CassNodeState] = stateStarted
+	healthyPod.Status.PodIP = "2.2.2.2"
+
+	rc.clusterPods = []*corev1.Pod{unreachablePod, healthyPod}
+
+	err := rc.refreshSeeds()
+	assert.NoError(t, err)
+	mockHttpClient.AssertExpectations(t)
+}
+
+func TestCheckClearActionConditions_WaitsForCleanupJob(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	jobStatus := "WAITING"
+	respond := func(body string) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}
+	}
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req.URL.Path == "/api/v1/ops/keyspace/cleanup"
+			})).
+		Return(respond("cleanup-job"), nil).
+		Once()
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req.URL.Path == "/api/v0/ops/executor/job"
+			})).
+		Return(func(*http.Request) *http.Response {
+			return respond(`{"id": "cleanup-job", "status": "` + jobStatus + `"}`)
+		}, nil)
+
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{
+		Client:   mockHttpClient,
+		Log:      rc.ReqLogger,
+		Protocol: "http",
+	}
+
+	pod := makeReloadTestPod()
+	pod.Status.PodIP = "1.1.1.1"
+	rc.Client = fake.NewFakeClient(rc.Datacenter, pod.DeepCopy())
+	rc.dcPods = []*corev1.Pod{pod}
+
+	rc.Datacenter.SetCondition(api.DatacenterCondition{
+		Status: corev1.ConditionTrue,
+		Type:   api.DatacenterScalingUp,
+	})
+
+	// The cleanup is submitted, then polled until it has finished
+	r := rc.CheckClearActionConditions()
+	assert.Equal(t, result.RequeueSoon(10), r)
+	assert.Equal(t, "cleanup-job", httphelper.GetPodJobID(pod, httphelper.JobTypeCleanup))
+
+	r = rc.CheckClearActionConditions()
+	assert.Equal(t, result.RequeueSoon(10), r)
+	assert.Equal(t, corev1.ConditionTrue, rc.Datacenter.GetConditionStatus(api.DatacenterScalingUp))
+
+	jobStatus = "COMPLETED"
+	rc.CheckClearActionConditions()
+	assert.Equal(t, corev1.ConditionFalse, rc.Datacenter.GetConditionStatus(api.DatacenterScalingUp))
+	assert.Equal(t, "", httphelper.GetPodJobID(pod, httphelper.JobTypeCleanup))
+	mockHttpClient.AssertExpectations(t)
+}
+
+func TestCheckClearActionConditions_CleanupJobOnUnreachablePod(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req.URL.Host == "1.1.1.1:8080"
+			})).
+		Return(nil, fmt.Errorf("connection refused")).
+		Once()
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req.URL.Host == "2.2.2.2:8080" && req.URL.Path == "/api/v1/ops/keyspace/cleanup"
+			})).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader("cleanup-job-2")),
+		}, nil).
+		Once()
+
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{
+		Client:      mockHttpClient,
+		Log:         rc.ReqLogger,
+		Protocol:    "http",
+		RetryPolicy: &httphelper.RetryPolicy{MaxAttempts: 1},
+	}
+
+	unreachablePod := makeReloadTestPod()
+	unreachablePod.Name = "unreachable"
+	unreachablePod.Status.PodIP = "1.1.1.1"
+	unreachablePod.Annotations = map[string]string{
+		httphelper.JobAnnotation(httphelper.JobTypeCleanup): "cleanup-job",
+	}
+
+	healthyPod := makeReloadTestPod()
+	healthyPod.Name = "healthy"
+	healthyPod.Status.PodIP = "2.2.2.2"
+
+	rc.Client = fake.NewFakeClient(rc.Datacenter, unreachablePod.DeepCopy(), healthyPod.DeepCopy())
+	rc.dcPods = []*corev1.Pod{unreachablePod, healthyPod}
+
+	rc.Datacenter.SetCondition(api.DatacenterCondition{
+		Status: corev1.ConditionTrue,
+		Type:   api.DatacenterScalingUp,
+	})
+
+	// The cleanup job that cannot be followed is submitted again to the
+	// node that is up
+	r := rc.CheckClearActionConditions()
+	assert.Equal(t, result.RequeueSoon(10), r)
+	assert.Equal(t, "", httphelper.GetPodJobID(unreachablePod, httphelper.JobTypeCleanup))
+	assert.Equal(t, "cleanup-job-2", httphelper.GetPodJobID(healthyPod, httphelper.JobTypeCleanup))
+	mockHttpClient.AssertExpectations(t)
+}
+
+// newFakeRingPod returns a pod of the rack whose Management API has been up
+// for a minute, with Cassandra not started yet
+func newFakeRingPod(dc *api.CassandraDatacenter, sts *appsv1.StatefulSet, rackName string, ordinal int, ip string) *corev1.Pod {
+	labels := dc.GetRackLabels(rackName)
+	labels[api.CassNodeState] = stateReadyToStart
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              fmt.Sprintf("%s-%d", sts.Name, ordinal),
+			Namespace:         dc.Namespace,
+			Labels:            labels,
+			CreationTimestamp: metav1.NewTime(time.Now()),
+		},
+		Status: corev1.PodStatus{
+			PodIP: ip,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "cassandra",
+				State: corev1.ContainerState{
+					Running: &corev1.ContainerStateRunning{
+						StartedAt: metav1.NewTime(time.Now().Add(-time.Minute)),
+					},
+				},
+			}},
+		},
+	}
+}
+
+// syncFakeRingReadiness does the work of the readiness probe of the pods,
+// which passes once the node has joined the ring
+func syncFakeRingReadiness(t *testing.T, rc *ReconciliationContext, server *fakemgmtapi.Server) {
+	for _, pod := range rc.dcPods {
+		node, ok := server.Node(pod.Status.PodIP)
+		pod.Status.ContainerStatuses[0].Ready = ok && node.Started && node.Status == fakemgmtapi.StatusNormal
+		assert.NoError(t, rc.Client.Update(rc.Ctx, pod))
+	}
+}
+
+// reconcilePodsReady runs CheckPodsReady against the fake ring until it lets
+// the reconcile continue
+func reconcilePodsReady(t *testing.T, rc *ReconciliationContext, server *fakemgmtapi.Server) {
+	for i := 0; i < 10; i++ {
+		syncFakeRingReadiness(t, rc, server)
+		assert.NoError(t, rc.UpdateStatusForUserActions())
+
+		epData, err := rc.NodeMgmtClient.CallMetadataEndpointsEndpoint(rc.dcPods[0])
+		assert.NoError(t, err)
+		recResult := rc.CheckPodsReady(epData)
+		if recResult == result.Continue() {
+			return
+		}
+		_, err = recResult.Output()
+		assert.NoError(t, err)
+	}
+	assert.Fail(t, "pods did not get ready")
+}
+
+func TestScaleUp_WithFakeMgmtApi(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Size = 3
+	rc.desiredRackInformation = []*RackInformation{{RackName: "default", NodeCount: 3, SeedCount: 1}}
+
+	sts, err := newStatefulSetForCassandraDatacenter("default", dc, 2)
+	assert.NoError(t, err)
+
+	server := fakemgmtapi.NewServer()
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{
+		Client:   server,
+		Log:      rc.ReqLogger,
+		Protocol: "http",
+	}
+
+	trackObjects := []runtime.Object{dc, sts}
+	for i := 0; i < 2; i++ {
+		pod := newFakeRingPod(dc, sts, "default", i, fmt.Sprintf("10.0.0.%d", i+1))
+		pod.Labels[api.CassNodeState] = stateStarted
+		server.AddStartedNode(pod.Status.PodIP)
+		trackObjects = append(trackObjects, pod.DeepCopy())
+		rc.dcPods = append(rc.dcPods, pod)
+	}
+	rc.Client = fake.NewFakeClient(trackObjects...)
+	rc.statefulSets = []*appsv1.StatefulSet{sts}
+	syncFakeRingReadiness(t, rc, server)
+
+	assert.Equal(t, result.Continue(), rc.CheckRackScale())
+	assert.Equal(t, int32(3), *sts.Spec.Replicas)
+	assert.Equal(t, corev1.ConditionTrue, dc.GetConditionStatus(api.DatacenterScalingUp))
+
+	// The StatefulSet controller creates the new pod
+	newPod := newFakeRingPod(dc, sts, "default", 2, "10.0.0.3")
+	assert.NoError(t, rc.Client.Create(rc.Ctx, newPod.DeepCopy()))
+	server.AddNode(newPod.Status.PodIP)
+	rc.dcPods = append(rc.dcPods, newPod)
+	rc.clusterPods = rc.dcPods
+
+	reconcilePodsReady(t, rc, server)
+	assert.Equal(t, stateStarted, newPod.Labels[api.CassNodeState])
+
+	node, _ := server.Node(newPod.Status.PodIP)
+	assert.Equal(t, fakemgmtapi.StatusNormal, node.Status)
+
+	// A cleanup runs on the ring before scaling up is over
+	assert.Equal(t, result.RequeueSoon(10), rc.CheckClearActionConditions())
+	assert.Equal(t, corev1.ConditionTrue, dc.GetConditionStatus(api.DatacenterScalingUp))
+
+	rc.CheckClearActionConditions()
+	assert.Equal(t, corev1.ConditionFalse, dc.GetConditionStatus(api.DatacenterScalingUp))
+
+	cleanups := 0
+	for _, pod := range rc.dcPods {
+		node, _ := server.Node(pod.Status.PodIP)
+		for _, call := range node.Calls {
+			if call == "POST /api/v1/ops/keyspace/cleanup" {
+				cleanups++
+			}
+		}
+	}
+	assert.Equal(t, 1, cleanups)
+}
+
+func TestReplaceNode_WithFakeMgmtApi(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Size = 3
+	dc.Spec.Racks = []api.Rack{{Name: "r1"}, {Name: "r2"}, {Name: "r3"}}
+	dc.Status.NodeStatuses = api.CassandraStatusMap{}
+
+	server := fakemgmtapi.NewServer()
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{
+		Client:   server,
+		Log:      rc.ReqLogger,
+		Protocol: "http",
+	}
+
+	trackObjects := []runtime.Object{dc}
+	for i, rack := range dc.Spec.Racks {
+		sts, err := newStatefulSetForCassandraDatacenter(rack.Name, dc, 1)
+		assert.NoError(t, err)
+		rc.statefulSets = append(rc.statefulSets, sts)
+		rc.desiredRackInformation = append(rc.desiredRackInformation,
+			&RackInformation{RackName: rack.Name, NodeCount: 1, SeedCount: 1})
+
+		pod := newFakeRingPod(dc, sts, rack.Name, 0, fmt.Sprintf("10.0.0.%d", i+1))
+		pod.Labels[api.CassNodeState] = stateStarted
+		pod.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+		server.AddStartedNode(pod.Status.PodIP)
+		node, _ := server.Node(pod.Status.PodIP)
+		dc.Status.NodeStatuses[pod.Name] = api.CassandraNodeStatus{HostID: node.HostID}
+
+		trackObjects = append(trackObjects, sts, pod.DeepCopy())
+		rc.dcPods = append(rc.dcPods, pod)
+	}
+	rc.Client = fake.NewFakeClient(trackObjects...)
+	syncFakeRingReadiness(t, rc, server)
+
+	// The node of the second rack is lost, and its pod is created again
+	// with an empty volume and a new IP
+	replaced := rc.dcPods[1]
+	replacedNode, _ := server.Node(replaced.Status.PodIP)
+	server.StopNode(replaced.Status.PodIP)
+
+	dc.Spec.ReplaceNodes = []string{replaced.Name}
+	assert.NoError(t, rc.UpdateStatusForUserActions())
+	assert.Equal(t, []string{replaced.Name}, dc.Status.NodeReplacements)
+
+	replacement := newFakeRingPod(dc, rc.statefulSets[1], "r2", 0, "10.0.0.12")
+	replacement.CreationTimestamp = metav1.NewTime(time.Now().Add(time.Minute))
+	assert.NoError(t, rc.Client.Delete(rc.Ctx, replaced))
+	assert.NoError(t, rc.Client.Create(rc.Ctx, replacement.DeepCopy()))
+	server.AddNode(replacement.Status.PodIP)
+	rc.dcPods[1] = replacement
+	rc.clusterPods = rc.dcPods
+
+	reconcilePodsReady(t, rc, server)
+	assert.Empty(t, dc.Status.NodeReplacements)
+	assert.Equal(t, stateStarted, replacement.Labels[api.CassNodeState])
+
+	// The new node took over the ring position of the lost one
+	node, _ := server.Node(replacement.Status.PodIP)
+	assert.Equal(t, replacedNode.HostID, node.HostID)
+	assert.Equal(t, replacedNode.Tokens, node.Tokens)
+	_, ok := server.Node(replaced.Status.PodIP)
+	assert.False(t, ok)
+}
+
+func TestUpdateCassandraNodeStatus_ExternalAccess(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Networking = &api.NetworkingConfig{
+		ExternalAccess: &api.ExternalAccessConfig{},
+	}
+	server := setupHibernationTest(rc, "default", "default")
+	for i, pod := range rc.dcPods {
+		// The nodes advertise the address of their load balancer
+		address := fmt.Sprintf("203.0.113.%d", i+1)
+		server.AddStartedNode(pod.Status.PodIP)
+		server.SetRpcAddress(pod.Status.PodIP, address)
+		pod.Annotations = map[string]string{api.ExternalAddressAnnotation: address}
+	}
+
+	assert.NoError(t, rc.UpdateCassandraNodeStatus())
+	for _, pod := range rc.dcPods {
+		node, _ := server.Node(pod.Status.PodIP)
+		assert.NotEmpty(t, node.HostID)
+		assert.Equal(t, node.HostID, dc.Status.NodeStatuses[pod.Name].HostID, "pod %s", pod.Name)
+	}
+
+	endpoints, err := rc.NodeMgmtClient.CallMetadataEndpointsEndpoint(rc.dcPods[0])
+	assert.NoError(t, err)
+	assert.Len(t, MapPodsToEndpointDataByName(dc, rc.dcPods, endpoints), 2)
+	assert.False(t, HasStartedDecommissioning(dc, rc.dcPods[0], endpoints))
+	assert.False(t, IsDoneDecommissioning(dc, rc.dcPods[0], endpoints))
+
+	// A node is replaced by its gossip address, not the one of its load balancer
+	replaceAddress, err := FindIpForHostId(endpoints, dc.Status.NodeStatuses[rc.dcPods[1].Name].HostID)
+	assert.NoError(t, err)
+	assert.Equal(t, rc.dcPods[1].Status.PodIP, replaceAddress)
+
+	// Without the annotation, the node is found by its recorded host ID
+	delete(rc.dcPods[1].Annotations, api.ExternalAddressAnnotation)
+	assert.Len(t, MapPodsToEndpointDataByName(dc, rc.dcPods, endpoints), 2)
+}
@@ -77,9 +77,9 @@ func buildReaperContainer(dc *api.CassandraDatacenter, container *corev1.Contain
 
 func getReaperImage(dc *api.CassandraDatacenter) string {
 	if len(dc.Spec.Reaper.Image) == 0 {
-		return images.GetReaperImage()
+		return dc.ApplyImageConfig(images.GetReaperImage(), dc.GetImageRepositories().Reaper)
 	}
-	return dc.Spec.Reaper.Image
+	return images.PinDigest(dc.Spec.Reaper.Image)
 }
 
 func getReaperPullPolicy(dc *api.CassandraDatacenter) corev1.PullPolicy {
@@ -34,6 +34,23 @@ func TestReconcileReaper_buildInitReaperSchemaJob(t *testing.T) {
 	assert.ElementsMatch(t, expectedEnvVars, container.Env)
 }
 
+func TestReconcileReaper_buildInitReaperSchemaJob_imageConfig(t *testing.T) {
+	dc := newCassandraDatacenter()
+	dc.Spec.ImageConfig = &api.ImageConfig{
+		Registry: "registry.example.com",
+		Repositories: api.ImageRepositories{
+			Reaper:           "team/reaper",
+			ReaperSchemaInit: "team/reaper-init-keyspace",
+		},
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "team-registry"}},
+	}
+	job := buildInitReaperSchemaJob(dc)
+
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, "registry.example.com/team/reaper-init-keyspace:latest", podSpec.Containers[0].Image)
+	assert.Equal(t, []corev1.LocalObjectReference{{Name: "team-registry"}}, podSpec.ImagePullSecrets)
+}
+
 func TestReconcileReaper_newReaperService(t *testing.T) {
 	dc := newCassandraDatacenter()
 	service := newReaperService(dc)
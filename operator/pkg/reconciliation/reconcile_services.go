@@ -61,7 +61,7 @@ func (rc *ReconciliationContext) CheckHeadlessServices() result.ReconcileResult
 
 	services := []*corev1.Service{cqlService, seedService, allPodsService}
 
-	if len(dc.Spec.AdditionalSeeds) > 0 {
+	if dc.UsesAdditionalSeedService() {
 		additionalSeedService := newAdditionalSeedServiceForCassandraDatacenter(dc)
 		services = append(services, additionalSeedService)
 	}
@@ -96,7 +96,7 @@ func CreateMockReconciliationContext(
 	}
 
 	s := scheme.Scheme
-	s.AddKnownTypes(api.SchemeGroupVersion, cassandraDatacenter)
+	s.AddKnownTypes(api.SchemeGroupVersion, cassandraDatacenter, &api.CassandraDatacenterList{})
 
 	fakeClient := fake.NewFakeClient(trackObjects...)
 
@@ -21,7 +21,8 @@ func GetModelValues(
 	nativePort int,
 	nativeSSLPort int,
 	internodePort int,
-	internodeSSLPort int) NodeConfig {
+	internodeSSLPort int,
+	broadcastRPCAddress string) NodeConfig {
 
 	seedsString := strings.Join(seeds, ",")
 
@@ -52,5 +53,9 @@ func GetModelValues(
 		modelValues["cassandra-yaml"].(NodeConfig)["storage_port"] = internodePort
 	}
 
+	if broadcastRPCAddress != "" {
+		modelValues["cassandra-yaml"].(NodeConfig)["broadcast_rpc_address"] = broadcastRPCAddress
+	}
+
 	return modelValues
 }
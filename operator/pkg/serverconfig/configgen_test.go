@@ -10,16 +10,17 @@ import (
 
 func TestGetModelValues(t *testing.T) {
 	type args struct {
-		seeds            []string
-		clusterName      string
-		dcName           string
-		graphEnabled     int
-		solrEnabled      int
-		sparkEnabled     int
-		nativePort       int
-		nativeSSLPort    int
-		internodePort    int
-		internodeSSLPort int
+		seeds               []string
+		clusterName         string
+		dcName              string
+		graphEnabled        int
+		solrEnabled         int
+		sparkEnabled        int
+		nativePort          int
+		nativeSSLPort       int
+		internodePort       int
+		internodeSSLPort    int
+		broadcastRPCAddress string
 	}
 	tests := []struct {
 		name string
@@ -150,6 +151,30 @@ func TestGetModelValues(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Broadcast RPC address",
+			args: args{
+				seeds:               []string{"seed0"},
+				clusterName:         "cluster-name",
+				dcName:              "dc-name",
+				broadcastRPCAddress: ExternalAddressPlaceholder,
+			},
+			want: NodeConfig{
+				"cluster-info": NodeConfig{
+					"name":  "cluster-name",
+					"seeds": "seed0",
+				},
+				"datacenter-info": NodeConfig{
+					"graph-enabled": 0,
+					"name":          "dc-name",
+					"solr-enabled":  0,
+					"spark-enabled": 0,
+				},
+				"cassandra-yaml": NodeConfig{
+					"broadcast_rpc_address": ExternalAddressPlaceholder,
+				},
+			},
+		},
 		{
 			name: "Empty args",
 			args: args{
@@ -191,7 +216,8 @@ func TestGetModelValues(t *testing.T) {
 				tt.args.nativePort,
 				tt.args.nativeSSLPort,
 				tt.args.internodePort,
-				tt.args.internodeSSLPort); !reflect.DeepEqual(got, tt.want) {
+				tt.args.internodeSSLPort,
+				tt.args.broadcastRPCAddress); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("GetModelValues() = %v, want %v", got, tt.want)
 			}
 		})
@@ -0,0 +1,300 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package serverconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Placeholders for the addresses of the pod in the rendered files. The
+// server-config-init container replaces them when the pod starts, as they are
+// not known before.
+const (
+	PodIPPlaceholder       = "@POD_IP@"
+	BroadcastIPPlaceholder = "@BROADCAST_IP@"
+	// Address of the service of the pod for clients outside the Kubernetes
+	// cluster, when external access is enabled
+	ExternalAddressPlaceholder = "@EXTERNAL_ADDRESS@"
+)
+
+// NotSupportedError is returned by RenderConfigFiles for a config that the
+// operator cannot render, the config builder has to render it instead
+type NotSupportedError struct {
+	Reason string
+}
+
+func (e *NotSupportedError) Error() string {
+	return "config files cannot be rendered by the operator: " + e.Reason
+}
+
+// IsNotSupported tells if the error is a NotSupportedError
+func IsNotSupported(err error) bool {
+	_, ok := err.(*NotSupportedError)
+	return ok
+}
+
+func notSupported(format string, args ...interface{}) error {
+	return &NotSupportedError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// renderer describes how the config files of one major.minor version of
+// Cassandra are rendered
+type renderer struct {
+	version string
+	// The section of the config with the JVM options, and the file it is
+	// rendered to
+	jvmOptionsSection string
+	jvmOptionsFile    string
+	jvmOptions        []jvmOption
+	// Settings of cassandra.yaml used when they are not in the config
+	cassandraYaml map[string]interface{}
+	// Settings of cassandra.yaml replaced by another one, by old name. Only
+	// one of the two is written.
+	cassandraYamlRenamed map[string]string
+}
+
+// jvmOption maps a setting of the JVM options section to a line of the JVM
+// options file
+type jvmOption struct {
+	key string
+	// The line written for a boolean setting that is true. For any other
+	// setting, or if it ends with "=", the value is appended to it.
+	option string
+	// Value used when the setting is not in the config, nil if the line is
+	// only written when the setting is
+	defaultValue interface{}
+	// Garbage collector the option can be used with, empty if any
+	gc string
+}
+
+// RenderConfigFiles renders the config files of the nodes of a rack from the
+// config of their datacenter, as given to the config builder in
+// CONFIG_FILE_DATA. Returns the content of each file by file name. A
+// NotSupportedError is returned for a server type, version or config section
+// that only the config builder knows about.
+func RenderConfigFiles(configData []byte, serverType, serverVersion, rackName string) (map[string]string, error) {
+	if serverType != "cassandra" {
+		return nil, notSupported("server type %s", serverType)
+	}
+	r := getRenderer(serverVersion)
+	if r == nil {
+		return nil, notSupported("server version %s", serverVersion)
+	}
+
+	var config map[string]interface{}
+	if err := json.Unmarshal(configData, &config); err != nil {
+		return nil, err
+	}
+
+	// The cluster and datacenter info are added by the operator, only the
+	// sections of the datacenter config are checked against the schema
+	clusterInfo, _ := config["cluster-info"].(map[string]interface{})
+	datacenterInfo, _ := config["datacenter-info"].(map[string]interface{})
+	delete(config, "cluster-info")
+	delete(config, "datacenter-info")
+
+	for _, name := range sortedKeys(config) {
+		if name != "cassandra-yaml" && name != r.jvmOptionsSection {
+			return nil, notSupported("config section %s", name)
+		}
+	}
+
+	userConfig, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	if errs := ValidateConfig(userConfig, serverType, serverVersion, field.NewPath("spec", "config")); len(errs) > 0 {
+		return nil, errs.ToAggregate()
+	}
+
+	cassandraYaml, _ := config["cassandra-yaml"].(map[string]interface{})
+	jvmOptions, _ := config[r.jvmOptionsSection].(map[string]interface{})
+
+	yamlFile, err := r.renderCassandraYaml(clusterInfo, cassandraYaml)
+	if err != nil {
+		return nil, err
+	}
+
+	jvmOptionsFile, err := r.renderJvmOptions(jvmOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		"cassandra.yaml":              yamlFile,
+		"cassandra-rackdc.properties": fmt.Sprintf("dc=%v\nrack=%s\n", datacenterInfo["name"], rackName),
+		r.jvmOptionsFile:              jvmOptionsFile,
+	}, nil
+}
+
+func getRenderer(serverVersion string) *renderer {
+	for i := range renderers {
+		if strings.HasPrefix(serverVersion, renderers[i].version+".") {
+			return &renderers[i]
+		}
+	}
+	return nil
+}
+
+func (r *renderer) renderCassandraYaml(clusterInfo, settings map[string]interface{}) (string, error) {
+	values := map[string]interface{}{}
+	for k, v := range r.cassandraYaml {
+		values[k] = v
+	}
+
+	values["cluster_name"] = clusterInfo["name"]
+	values["seed_provider"] = []interface{}{
+		map[string]interface{}{
+			"class_name": "org.apache.cassandra.locator.SimpleSeedProvider",
+			"parameters": []interface{}{
+				map[string]interface{}{"seeds": clusterInfo["seeds"]},
+			},
+		},
+	}
+
+	// A setting replaces the default of its other name
+	for oldKey, newKey := range r.cassandraYamlRenamed {
+		if _, ok := settings[oldKey]; ok {
+			delete(values, newKey)
+		}
+		if _, ok := settings[newKey]; ok {
+			delete(values, oldKey)
+		}
+	}
+
+	// A null value leaves the setting to the default of the server
+	for k, v := range settings {
+		if v == nil {
+			delete(values, k)
+		} else {
+			values[k] = v
+		}
+	}
+
+	out, err := yaml.Marshal(withIntegers(values))
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func (r *renderer) renderJvmOptions(settings map[string]interface{}) (string, error) {
+	lines := []string{}
+
+	known := map[string]bool{"additional-jvm-opts": true}
+	for _, option := range r.jvmOptions {
+		known[option.key] = true
+	}
+	for _, key := range sortedKeys(settings) {
+		if !known[key] {
+			return "", notSupported("%s setting %s", r.jvmOptionsSection, key)
+		}
+	}
+
+	// The garbage collector is only chosen in the JVM options of the
+	// versions that have the garbage_collector setting
+	gc := ""
+	if known["garbage_collector"] {
+		gc = "G1GC"
+		if value, ok := settings["garbage_collector"].(string); ok {
+			gc = value
+		}
+		gcLines, ok := garbageCollectorOptions[gc]
+		if !ok {
+			return "", fmt.Errorf("unknown garbage_collector %s, must be one of %s",
+				gc, strings.Join(sortedGarbageCollectors(), ", "))
+		}
+		lines = append(lines, gcLines...)
+	}
+
+	for _, option := range r.jvmOptions {
+		if option.key == "garbage_collector" {
+			continue
+		}
+		value := settings[option.key]
+		set := value != nil
+		if !set {
+			value = option.defaultValue
+		}
+		if value == nil {
+			continue
+		}
+		if option.gc != "" && option.gc != gc {
+			if set {
+				return "", fmt.Errorf("%s can only be used with the %s garbage collector", option.key, option.gc)
+			}
+			continue
+		}
+
+		if enabled, ok := value.(bool); ok && !strings.HasSuffix(option.option, "=") {
+			if enabled {
+				lines = append(lines, option.option)
+			}
+			continue
+		}
+		lines = append(lines, option.option+formatValue(value))
+	}
+
+	if additional, ok := settings["additional-jvm-opts"].([]interface{}); ok {
+		for _, opt := range additional {
+			lines = append(lines, formatValue(opt))
+		}
+	}
+
+	return "# Rendered by cass-operator from the config of the CassandraDatacenter\n" +
+		strings.Join(lines, "\n") + "\n", nil
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// withIntegers converts the whole numbers decoded from JSON back to integers,
+// so that they are not written to YAML in exponent notation
+func withIntegers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v)
+		}
+		return v
+	case map[string]interface{}:
+		out := map[string]interface{}{}
+		for k, item := range v {
+			out[k] = withIntegers(item)
+		}
+		return out
+	case []interface{}:
+		out := []interface{}{}
+		for _, item := range v {
+			out = append(out, withIntegers(item))
+		}
+		return out
+	}
+	return value
+}
+
+func sortedGarbageCollectors() []string {
+	names := []string{}
+	for name := range garbageCollectorOptions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,198 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package serverconfig
+
+// The JVM options below follow docs/user/jvm_server_configuration.md, with
+// the same defaults as the config builder. When support for a new Cassandra
+// version is added, a renderer can be added for it here, or its config is
+// left to the config builder.
+
+var renderers = []renderer{
+	{
+		version:           "3.11",
+		jvmOptionsSection: "jvm-options",
+		jvmOptionsFile:    "jvm.options",
+		jvmOptions:        append(append([]jvmOption{}, jvmServerOptionLines...), jvmOptionLines3...),
+		cassandraYaml:     cassandraYamlDefaults,
+	},
+	{
+		version:           "4.0",
+		jvmOptionsSection: "jvm-server-options",
+		jvmOptionsFile:    "jvm-server.options",
+		jvmOptions:        append(append([]jvmOption{}, jvmServerOptionLines...), jvmServerOptionLines4...),
+		cassandraYaml:     cassandraYamlDefaults,
+	},
+	{
+		version:              "4.1",
+		jvmOptionsSection:    "jvm-server-options",
+		jvmOptionsFile:       "jvm-server.options",
+		jvmOptions:           append(append([]jvmOption{}, jvmServerOptionLines...), jvmServerOptionLines4...),
+		cassandraYaml:        cassandraYamlDefaults41,
+		cassandraYamlRenamed: cassandraYamlRenamed41,
+	},
+	{
+		version:              "5.0",
+		jvmOptionsSection:    "jvm-server-options",
+		jvmOptionsFile:       "jvm-server.options",
+		jvmOptions:           append(append([]jvmOption{}, jvmServerOptionLines...), withoutOptions(jvmServerOptionLines4, jvmServerOptionsJava8...)...),
+		cassandraYaml:        cassandraYamlDefaults41,
+		cassandraYamlRenamed: cassandraYamlRenamed41,
+	},
+}
+
+func withoutOptions(options []jvmOption, keys ...string) []jvmOption {
+	out := []jvmOption{}
+	for _, option := range options {
+		excluded := false
+		for _, key := range keys {
+			if option.key == key {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			out = append(out, option)
+		}
+	}
+	return out
+}
+
+// cassandraYamlDefaults are the cassandra.yaml settings that have no default
+// in the server, or whose default does not fit the pods of the operator. A
+// node cannot restart with another num_tokens than it joined the ring with,
+// so it must never change here.
+var cassandraYamlDefaults = map[string]interface{}{
+	"authenticator":               "PasswordAuthenticator",
+	"authorizer":                  "CassandraAuthorizer",
+	"role_manager":                "CassandraRoleManager",
+	"broadcast_address":           BroadcastIPPlaceholder,
+	"broadcast_rpc_address":       BroadcastIPPlaceholder,
+	"listen_address":              PodIPPlaceholder,
+	"rpc_address":                 "0.0.0.0",
+	"commitlog_directory":         "/var/lib/cassandra/commitlog",
+	"commitlog_sync":              "periodic",
+	"commitlog_sync_period_in_ms": 10000,
+	"data_file_directories":       []interface{}{"/var/lib/cassandra/data"},
+	"hints_directory":             "/var/lib/cassandra/hints",
+	"saved_caches_directory":      "/var/lib/cassandra/saved_caches",
+	"endpoint_snitch":             "GossipingPropertyFileSnitch",
+	"num_tokens":                  256,
+	"partitioner":                 "org.apache.cassandra.dht.Murmur3Partitioner",
+	"start_native_transport":      true,
+}
+
+// cassandraYamlDefaults41 are the cassandraYamlDefaults of Cassandra 4.1 and
+// later, with the new names and units of the settings
+var cassandraYamlDefaults41 = func() map[string]interface{} {
+	defaults := map[string]interface{}{}
+	for k, v := range cassandraYamlDefaults {
+		defaults[k] = v
+	}
+	delete(defaults, "commitlog_sync_period_in_ms")
+	defaults["commitlog_sync_period"] = "10000ms"
+	return defaults
+}()
+
+// garbageCollectorOptions are the options written for each value of the
+// garbage_collector setting
+var garbageCollectorOptions = map[string][]string{
+	"G1GC": {
+		"-XX:+UseG1GC",
+		"-XX:+ParallelRefProcEnabled",
+	},
+	"CMS": {
+		"-XX:+UseParNewGC",
+		"-XX:+UseConcMarkSweepGC",
+		"-XX:+CMSParallelRemarkEnabled",
+		"-XX:+UseCMSInitiatingOccupancyOnly",
+		"-XX:+CMSParallelInitialMarkEnabled",
+		"-XX:+CMSEdenChunksRecordAlways",
+		"-XX:+CMSClassUnloadingEnabled",
+	},
+}
+
+// jvmServerOptionLines are the JVM options common to all Cassandra versions
+var jvmServerOptionLines = []jvmOption{
+	{key: "enable_assertions", option: "-ea", defaultValue: true},
+	{key: "use_thread_priorities", option: "-XX:+UseThreadPriorities", defaultValue: true},
+	{key: "heap_dump_on_out_of_memory_error", option: "-XX:+HeapDumpOnOutOfMemoryError", defaultValue: true},
+	{key: "per_thread_stack_size", option: "-Xss", defaultValue: "256k"},
+	{key: "string_table_size", option: "-XX:StringTableSize=", defaultValue: "1000003"},
+	{key: "always_pre_touch", option: "-XX:+AlwaysPreTouch", defaultValue: true},
+	{key: "use_tlb", option: "-XX:+UseTLAB", defaultValue: true},
+	{key: "resize_tlb", option: "-XX:+ResizeTLAB", defaultValue: true},
+	{key: "perf_disable_shared_mem", option: "-XX:+PerfDisableSharedMem", defaultValue: true},
+	{key: "java_net_prefer_ipv4_stack", option: "-Djava.net.preferIPv4Stack=true", defaultValue: true},
+	{key: "initial_heap_size", option: "-Xms"},
+	{key: "max_heap_size", option: "-Xmx"},
+	{key: "flight_recorder", option: "-XX:+FlightRecorder"},
+	{key: "agent_lib_jdwp", option: "-agentlib:jdwp=transport=dt_socket,server=y,suspend=n,address=1414"},
+	{key: "cassandra_available_processors", option: "-Dcassandra.available_processors="},
+	{key: "cassandra_config_directory", option: "-Dcassandra.config="},
+	{key: "cassandra_disable_auth_caches_remote_configuration", option: "-Dcassandra.disable_auth_caches_remote_configuration="},
+	{key: "cassandra_force_default_indexing_page_size", option: "-Dcassandra.force_default_indexing_page_size="},
+	{key: "cassandra_initial_token", option: "-Dcassandra.initial_token="},
+	{key: "cassandra_join_ring", option: "-Dcassandra.join_ring="},
+	{key: "cassandra_load_ring_state", option: "-Dcassandra.load_ring_state="},
+	{key: "cassandra_metrics_reporter_config_file", option: "-Dcassandra.metricsReporterConfigFile="},
+	{key: "cassandra_replace_address", option: "-Dcassandra.replace_address="},
+	{key: "cassandra_ring_delay_ms", option: "-Dcassandra.ring_delay_ms="},
+	{key: "cassandra_triggers_dir", option: "-Dcassandra.triggers_dir="},
+	{key: "cassandra_write_survey", option: "-Dcassandra.write_survey="},
+}
+
+// jvmOptionLines3 are the options of jvm.options in Cassandra 3.11, which also
+// holds the garbage collection settings
+var jvmOptionLines3 = []jvmOption{
+	{key: "garbage_collector"},
+	{key: "thread_priority_policy_42", option: "-XX:ThreadPriorityPolicy=42", defaultValue: true},
+	{key: "use_biased_locking", option: "-XX:-UseBiasedLocking"},
+	{key: "heap_size_young_generation", option: "-Xmn"},
+	{key: "max_gc_pause_millis", option: "-XX:MaxGCPauseMillis=", defaultValue: 500, gc: "G1GC"},
+	{key: "g1r_set_updating_pause_time_percent", option: "-XX:G1RSetUpdatingPauseTimePercent=", defaultValue: 5, gc: "G1GC"},
+	{key: "initiating_heap_occupancy_percent", option: "-XX:InitiatingHeapOccupancyPercent=", gc: "G1GC"},
+	{key: "parallel_gc_threads", option: "-XX:ParallelGCThreads=", gc: "G1GC"},
+	{key: "conc_gc_threads", option: "-XX:ConcGCThreads="},
+	{key: "survivor_ratio", option: "-XX:SurvivorRatio=", defaultValue: 8, gc: "CMS"},
+	{key: "max_tenuring_threshold", option: "-XX:MaxTenuringThreshold=", defaultValue: 1, gc: "CMS"},
+	{key: "cms_initiating_occupancy_fraction", option: "-XX:CMSInitiatingOccupancyFraction=", defaultValue: 75, gc: "CMS"},
+	{key: "cms_wait_duration", option: "-XX:CMSWaitDuration=", defaultValue: 10000, gc: "CMS"},
+	{key: "log_gc", option: "-Xloggc:/var/log/cassandra/gc.log"},
+	{key: "print_gc_details", option: "-XX:+PrintGCDetails"},
+	{key: "print_heap_at_gc", option: "-XX:+PrintHeapAtGC"},
+	{key: "print_tenuring_distribution", option: "-XX:+PrintTenuringDistribution"},
+	{key: "print_gc_application_stopped_time", option: "-XX:+PrintGCApplicationStoppedTime"},
+	{key: "print_promotion_failure", option: "-XX:+PrintPromotionFailure"},
+	{key: "print_flss_statistics", option: "-XX:PrintFLSStatistics=1"},
+	{key: "use_gc_log_file_rotation", option: "-XX:+UseGCLogFileRotation"},
+	{key: "number_of_gc_log_files", option: "-XX:NumberOfGCLogFiles="},
+	{key: "gc_log_file_size", option: "-XX:GCLogFileSize="},
+	{key: "unlock_commerical_features", option: "-XX:+UnlockCommercialFeatures"},
+	{key: "cassandra_force_3_0_protocol_version", option: "-Dcassandra.force_3_0_protocol_version=true"},
+	{key: "cassandra_replay_list", option: "-Dcassandra.replayList="},
+}
+
+// jvmServerOptionLines4 are the options of jvm-server.options in Cassandra
+// 4.0 and 4.1. The garbage collection settings are in the jvm8 and jvm11
+// files. Cassandra 5.0 has the same options but those of Java 8, and its
+// garbage collection settings are in the jvm11 and jvm17 files.
+var jvmServerOptionLines4 = []jvmOption{
+	{key: "unlock-diagnostic-vm-options", option: "-XX:+UnlockDiagnosticVMOptions", defaultValue: true},
+	{key: "use_numa", option: "-XX:+UseNUMA", defaultValue: true},
+	{key: "use-biased-locking", option: "-XX:-UseBiasedLocking", defaultValue: true},
+	{key: "restrict-contended", option: "-XX:-RestrictContended", defaultValue: true},
+	{key: "page-align-direct-memory", option: "-Dsun.nio.PageAlignDirectMemory=true", defaultValue: true},
+	{key: "debug-non-safepoints", option: "-XX:+DebugNonSafepoints", defaultValue: true},
+	{key: "preserve-frame-pointer", option: "-XX:+PreserveFramePointer", defaultValue: true},
+	{key: "guaranteed-safepoint-interval", option: "-XX:GuaranteedSafepointInterval=", defaultValue: "300000"},
+	{key: "jdk_nio_maxcachedbuffersize", option: "-Djdk.nio.maxCachedBufferSize=", defaultValue: 1048576},
+	{key: "io_netty_eventloop_maxpendingtasks", option: "-Dio.netty.eventLoop.maxPendingTasks=", defaultValue: 65536},
+	{key: "log_compilation", option: "-XX:+LogCompilation"},
+	{key: "exit_on_out_of_memory_error", option: "-XX:+ExitOnOutOfMemoryError"},
+	{key: "crash_on_out_of_memory_error", option: "-XX:+CrashOnOutOfMemoryError"},
+	{key: "print_heap_histogram_on_out_of_memory_error", option: "-Dcassandra.printHeapHistogramOnOutOfMemoryError="},
+	{key: "unlock_commercial_features", option: "-XX:+UnlockCommercialFeatures"},
+	{key: "cassandra_expiration_date_overflow_policy", option: "-Dcassandra.expiration_date_overflow_policy="},
+	{key: "cassandra_max_hint_ttl", option: "-Dcassandra.maxHintTTL="},
+}
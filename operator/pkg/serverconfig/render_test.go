@@ -0,0 +1,220 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package serverconfig
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const renderInfo = `"cluster-info": {"name": "cluster1", "seeds": "seed-service,additional-seed-service"},
+	"datacenter-info": {"name": "dc1", "graph-enabled": 0, "solr-enabled": 0, "spark-enabled": 0}`
+
+func TestRenderConfigFiles_CassandraYaml(t *testing.T) {
+	files, err := RenderConfigFiles([]byte(`{`+renderInfo+`,
+		"cassandra-yaml": {
+			"num_tokens": 8,
+			"authenticator": "AllowAllAuthenticator",
+			"authorizer": null,
+			"compaction_throughput_mb_per_sec": 1048576,
+			"phi_convict_threshold": 12.5
+		}
+	}`), "cassandra", "3.11.7", "r1")
+	assert.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	yaml := files["cassandra.yaml"]
+	for _, line := range []string{
+		"cluster_name: cluster1\n",
+		"  - seeds: seed-service,additional-seed-service\n",
+		"listen_address: '" + PodIPPlaceholder + "'\n",
+		"broadcast_address: '" + BroadcastIPPlaceholder + "'\n",
+		"endpoint_snitch: GossipingPropertyFileSnitch\n",
+		"num_tokens: 8\n",
+		"authenticator: AllowAllAuthenticator\n",
+		"compaction_throughput_mb_per_sec: 1048576\n",
+		"phi_convict_threshold: 12.5\n",
+	} {
+		assert.Contains(t, yaml, line)
+	}
+	assert.NotContains(t, yaml, "authorizer")
+
+	assert.Equal(t, "dc=dc1\nrack=r1\n", files["cassandra-rackdc.properties"])
+}
+
+func TestRenderConfigFiles_CassandraYamlUnits(t *testing.T) {
+	for _, serverVersion := range []string{"4.1.3", "5.0.0"} {
+		t.Run(serverVersion, func(t *testing.T) {
+			files, err := RenderConfigFiles([]byte(`{`+renderInfo+`}`), "cassandra", serverVersion, "r1")
+			assert.NoError(t, err)
+			assert.Contains(t, files["cassandra.yaml"], "commitlog_sync_period: 10000ms\n")
+			assert.NotContains(t, files["cassandra.yaml"], "commitlog_sync_period_in_ms")
+
+			// The old name of a setting replaces the default of the new one
+			files, err = RenderConfigFiles([]byte(`{`+renderInfo+`,
+				"cassandra-yaml": {"commitlog_sync_period_in_ms": 5000, "read_request_timeout": "10s"}
+			}`), "cassandra", serverVersion, "r1")
+			assert.NoError(t, err)
+			assert.Contains(t, files["cassandra.yaml"], "commitlog_sync_period_in_ms: 5000\n")
+			assert.Contains(t, files["cassandra.yaml"], "read_request_timeout: 10s\n")
+			assert.NotContains(t, files["cassandra.yaml"], "commitlog_sync_period:")
+		})
+	}
+}
+
+func TestRenderConfigFiles_JvmOptions(t *testing.T) {
+	tests := []struct {
+		name          string
+		serverVersion string
+		config        string
+		file          string
+		want          []string
+		notWant       []string
+		err           string
+	}{
+		{
+			name:          "Cassandra 3.11 defaults",
+			serverVersion: "3.11.7",
+			config:        `{` + renderInfo + `}`,
+			file:          "jvm.options",
+			want:          []string{"-XX:+UseG1GC", "-XX:MaxGCPauseMillis=500", "-Xss256k", "-ea"},
+			notWant:       []string{"-Xmx", "-XX:+UseConcMarkSweepGC", "-XX:SurvivorRatio"},
+		},
+		{
+			name:          "Cassandra 3.11 settings",
+			serverVersion: "3.11.7",
+			config: `{` + renderInfo + `, "jvm-options": {
+				"garbage_collector": "CMS",
+				"max_heap_size": "2G",
+				"survivor_ratio": 4,
+				"use_tlb": false,
+				"cassandra_join_ring": false,
+				"additional-jvm-opts": ["-Dcassandra.consistent.rangemovement=false"]
+			}}`,
+			file: "jvm.options",
+			want: []string{
+				"-XX:+UseConcMarkSweepGC",
+				"-XX:CMSWaitDuration=10000",
+				"-XX:SurvivorRatio=4",
+				"-Xmx2G",
+				"-Dcassandra.join_ring=false",
+				"-Dcassandra.consistent.rangemovement=false",
+			},
+			notWant: []string{"-XX:+UseG1GC", "-XX:MaxGCPauseMillis", "-XX:+UseTLAB"},
+		},
+		{
+			name:          "Cassandra 3.11 option of another garbage collector",
+			serverVersion: "3.11.7",
+			config:        `{` + renderInfo + `, "jvm-options": {"max_gc_pause_millis": 200, "garbage_collector": "CMS"}}`,
+			err:           "max_gc_pause_millis can only be used with the G1GC garbage collector",
+		},
+		{
+			name:          "Cassandra 3.11 unknown garbage collector",
+			serverVersion: "3.11.7",
+			config:        `{` + renderInfo + `, "jvm-options": {"garbage_collector": "ZGC"}}`,
+			err:           "unknown garbage_collector ZGC, must be one of CMS, G1GC",
+		},
+		{
+			name:          "Cassandra 4.0 settings",
+			serverVersion: "4.0.0",
+			config:        `{` + renderInfo + `, "jvm-server-options": {"initial_heap_size": "1G", "jdk_nio_maxcachedbuffersize": 2097152}}`,
+			file:          "jvm-server.options",
+			want:          []string{"-Xms1G", "-Djdk.nio.maxCachedBufferSize=2097152", "-XX:+UnlockDiagnosticVMOptions"},
+			notWant:       []string{"-XX:+UseG1GC", "-Djdk.nio.maxCachedBufferSize=1048576"},
+		},
+		{
+			name:          "Cassandra 4.1 settings",
+			serverVersion: "4.1.3",
+			config:        `{` + renderInfo + `, "jvm-server-options": {"max_heap_size": "4G"}}`,
+			file:          "jvm-server.options",
+			want:          []string{"-Xmx4G", "-XX:-UseBiasedLocking", "-XX:+UseNUMA"},
+			notWant:       []string{"-XX:+UseG1GC"},
+		},
+		{
+			name:          "Cassandra 5.0 settings",
+			serverVersion: "5.0.0",
+			config:        `{` + renderInfo + `, "jvm-server-options": {"max_heap_size": "4G", "exit_on_out_of_memory_error": true}}`,
+			file:          "jvm-server.options",
+			want:          []string{"-Xmx4G", "-XX:+ExitOnOutOfMemoryError", "-XX:+UseNUMA"},
+			notWant:       []string{"-XX:+UseG1GC", "-XX:-UseBiasedLocking"},
+		},
+		{
+			name:          "Wrong type",
+			serverVersion: "4.0.0",
+			config:        `{` + renderInfo + `, "jvm-server-options": {"use_numa": "yes"}}`,
+			err:           `spec.config.jvm-server-options.use_numa: Invalid value: "yes": must be of type boolean`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := RenderConfigFiles([]byte(tt.config), "cassandra", tt.serverVersion, "r1")
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				assert.False(t, IsNotSupported(err))
+				return
+			}
+			assert.NoError(t, err)
+
+			lines := strings.Split(files[tt.file], "\n")
+			for _, want := range tt.want {
+				assert.Contains(t, lines, want)
+			}
+			for _, notWant := range tt.notWant {
+				for _, line := range lines {
+					assert.False(t, strings.HasPrefix(line, notWant), "unexpected line %s", line)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderConfigFiles_NotSupported(t *testing.T) {
+	tests := []struct {
+		name          string
+		serverType    string
+		serverVersion string
+		config        string
+	}{
+		{
+			name:          "DSE",
+			serverType:    "dse",
+			serverVersion: "6.8.4",
+			config:        `{` + renderInfo + `}`,
+		},
+		{
+			name:          "Version without renderer",
+			serverType:    "cassandra",
+			serverVersion: "3.0.22",
+			config:        `{` + renderInfo + `}`,
+		},
+		{
+			name:          "Section without renderer",
+			serverType:    "cassandra",
+			serverVersion: "3.11.7",
+			config:        `{` + renderInfo + `, "logback-xml": {"root-log-level": "DEBUG"}}`,
+		},
+		{
+			name:          "Java 17 options",
+			serverType:    "cassandra",
+			serverVersion: "5.0.0",
+			config:        `{` + renderInfo + `, "jvm17-server-options": {"garbage_collector": "G1GC"}}`,
+		},
+		{
+			name:          "JVM option of cassandra-env.sh",
+			serverType:    "cassandra",
+			serverVersion: "4.0.0",
+			config:        `{` + renderInfo + `, "jvm-server-options": {"jmx-connection-type": "remote-no-auth"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := RenderConfigFiles([]byte(tt.config), tt.serverType, tt.serverVersion, "r1")
+			assert.True(t, IsNotSupported(err), "unexpected error %v", err)
+		})
+	}
+}
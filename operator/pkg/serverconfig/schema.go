@@ -0,0 +1,229 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package serverconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValueType is the type of the value of a config setting
+type ValueType string
+
+const (
+	StringValue  ValueType = "string"
+	IntegerValue ValueType = "integer"
+	NumberValue  ValueType = "number"
+	BooleanValue ValueType = "boolean"
+	ListValue    ValueType = "list"
+	MapValue     ValueType = "map"
+	// Units of the cassandra.yaml settings of Cassandra 4.1 and later, such
+	// as 500ms, 64MiB and 24MiB/s
+	DurationValue    ValueType = "duration"
+	DataStorageValue ValueType = "data storage"
+	DataRateValue    ValueType = "data rate"
+)
+
+// unitPatterns are the formats of the values of the types with units
+var unitPatterns = map[ValueType]*regexp.Regexp{
+	DurationValue:    regexp.MustCompile(`^\d+(d|h|m|s|ms|us|µs|ns)$`),
+	DataStorageValue: regexp.MustCompile(`^\d+(B|KiB|MiB|GiB)$`),
+	DataRateValue:    regexp.MustCompile(`^\d+(B/s|KiB/s|MiB/s)$`),
+}
+
+type settings map[string]ValueType
+
+// section describes one of the config files that can be set in the config of
+// a datacenter, such as cassandra-yaml or jvm-options
+type section struct {
+	settings settings
+	// An open section also accepts settings that are not in its schema. The
+	// type of the known settings is still checked.
+	open bool
+	// Settings replaced by another one of the section, by name of the
+	// setting that replaces them. Both are accepted, but not together.
+	renamed map[string]string
+}
+
+// schema describes the config sections allowed for one server type and
+// major.minor version
+type schema struct {
+	serverType string
+	version    string
+	sections   map[string]section
+	// An open schema also accepts sections that are not in it, as long as they
+	// do not belong to another version of the server
+	open bool
+}
+
+func (s *schema) String() string {
+	return fmt.Sprintf("%s %s", s.serverType, s.version)
+}
+
+func (s *schema) matches(serverType, serverVersion string) bool {
+	return s.serverType == serverType && strings.HasPrefix(serverVersion, s.version+".")
+}
+
+func getSchema(serverType, serverVersion string) *schema {
+	for i := range schemas {
+		if schemas[i].matches(serverType, serverVersion) {
+			return &schemas[i]
+		}
+	}
+	return nil
+}
+
+// ValidateConfig checks the sections, the setting names and the value types
+// of a datacenter config against the schema of the server type and version.
+// Nested values of map settings are not checked. A config for a server version
+// without a schema is not checked either.
+func ValidateConfig(config json.RawMessage, serverType, serverVersion string, path *field.Path) field.ErrorList {
+	if len(config) == 0 {
+		return nil
+	}
+
+	var c map[string]interface{}
+	if err := json.Unmarshal(config, &c); err != nil {
+		return field.ErrorList{field.Invalid(path, string(config), err.Error())}
+	}
+
+	s := getSchema(serverType, serverVersion)
+	if s == nil {
+		return nil
+	}
+
+	errs := field.ErrorList{}
+	for _, name := range sortedKeys(c) {
+		sectionPath := path.Child(name)
+		sec, ok := s.sections[name]
+		if !ok {
+			if others := otherSchemasWithSection(s, name); len(others) > 0 {
+				errs = append(errs, field.Forbidden(sectionPath,
+					fmt.Sprintf("not supported by %s, only by %s", s, strings.Join(others, ", "))))
+			} else if !s.open {
+				errs = append(errs, field.NotSupported(sectionPath, name, sortedKeys(s.sections)))
+			}
+			continue
+		}
+
+		if c[name] == nil {
+			continue
+		}
+		values, ok := c[name].(map[string]interface{})
+		if !ok {
+			errs = append(errs, field.Invalid(sectionPath, c[name], "must be a map of settings"))
+			continue
+		}
+
+		for _, key := range sortedKeys(values) {
+			keyPath := sectionPath.Child(key)
+			valueType, ok := sec.settings[key]
+			if !ok {
+				if sec.open {
+					continue
+				}
+				if others := otherSchemasWithSetting(s, name, key); len(others) > 0 {
+					errs = append(errs, field.Forbidden(keyPath,
+						fmt.Sprintf("not supported by %s, only by %s", s, strings.Join(others, ", "))))
+				} else {
+					errs = append(errs, field.Forbidden(keyPath,
+						fmt.Sprintf("unknown %s setting for %s", name, s)))
+				}
+				continue
+			}
+			if !isValueOfType(values[key], valueType) {
+				errs = append(errs, field.Invalid(keyPath, values[key], fmt.Sprintf("must be of type %s", valueType)))
+			}
+		}
+
+		for _, key := range sortedKeys(values) {
+			if newKey, ok := sec.renamed[key]; ok {
+				if _, ok := values[newKey]; ok {
+					errs = append(errs, field.Forbidden(sectionPath.Child(key),
+						fmt.Sprintf("cannot be set with %s, which replaces it in %s", newKey, s)))
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
+// isValueOfType tells if a value decoded from JSON has the given type. A null
+// value leaves the setting to its default, so it is always valid.
+func isValueOfType(value interface{}, valueType ValueType) bool {
+	if value == nil {
+		return true
+	}
+	switch valueType {
+	case StringValue:
+		_, ok := value.(string)
+		return ok
+	case IntegerValue:
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case NumberValue:
+		_, ok := value.(float64)
+		return ok
+	case BooleanValue:
+		_, ok := value.(bool)
+		return ok
+	case ListValue:
+		_, ok := value.([]interface{})
+		return ok
+	case MapValue:
+		_, ok := value.(map[string]interface{})
+		return ok
+	case DurationValue, DataStorageValue, DataRateValue:
+		str, ok := value.(string)
+		return ok && unitPatterns[valueType].MatchString(str)
+	}
+	return false
+}
+
+func otherSchemasWithSection(s *schema, name string) []string {
+	others := []string{}
+	for i := range schemas {
+		if other := &schemas[i]; other != s {
+			if _, ok := other.sections[name]; ok {
+				others = append(others, other.String())
+			}
+		}
+	}
+	return others
+}
+
+func otherSchemasWithSetting(s *schema, sectionName, key string) []string {
+	others := []string{}
+	for i := range schemas {
+		if other := &schemas[i]; other != s && other.serverType == s.serverType {
+			if _, ok := other.sections[sectionName].settings[key]; ok {
+				others = append(others, other.String())
+			}
+		}
+	}
+	return others
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	switch m := m.(type) {
+	case map[string]interface{}:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]section:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
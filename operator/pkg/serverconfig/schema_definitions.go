@@ -0,0 +1,620 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package serverconfig
+
+// The settings below are those the config builder accepts for each version of
+// the server, or the operator for the versions only it renders the config of.
+// When support for a new server version is added, a schema needs to be added
+// for it as well.
+
+var schemas = []schema{
+	{
+		serverType: "cassandra",
+		version:    "3.11",
+		sections: map[string]section{
+			"cassandra-yaml":   {settings: mergeSettings(cassandraYaml, cassandraYaml3)},
+			"jvm-options":      {settings: mergeSettings(jvmServerOptions, jvmOptions3)},
+			"cassandra-env-sh": {open: true},
+			"logback-xml":      {open: true},
+		},
+	},
+	{
+		serverType: "cassandra",
+		version:    "4.0",
+		sections: map[string]section{
+			"cassandra-yaml":       {settings: mergeSettings(cassandraYaml, cassandraYaml4)},
+			"jvm-server-options":   {settings: mergeSettings(jvmServerOptions, jvmServerOptions4)},
+			"jvm8-server-options":  {open: true},
+			"jvm11-server-options": {open: true},
+			"cassandra-env-sh":     {open: true},
+			"logback-xml":          {open: true},
+		},
+	},
+	{
+		serverType: "cassandra",
+		version:    "4.1",
+		sections: map[string]section{
+			"cassandra-yaml": {
+				settings: mergeSettings(cassandraYaml, cassandraYaml4, cassandraYaml41),
+				renamed:  cassandraYamlRenamed41,
+			},
+			"jvm-server-options":   {settings: mergeSettings(jvmServerOptions, jvmServerOptions4)},
+			"jvm8-server-options":  {open: true},
+			"jvm11-server-options": {open: true},
+			"cassandra-env-sh":     {open: true},
+			"logback-xml":          {open: true},
+		},
+	},
+	{
+		// Cassandra 5.0 runs on Java 11 and 17, not on Java 8
+		serverType: "cassandra",
+		version:    "5.0",
+		sections: map[string]section{
+			"cassandra-yaml": {
+				settings: mergeSettings(cassandraYaml, cassandraYaml4, cassandraYaml41, cassandraYaml5),
+				renamed:  cassandraYamlRenamed41,
+			},
+			"jvm-server-options":   {settings: withoutSettings(mergeSettings(jvmServerOptions, jvmServerOptions4), jvmServerOptionsJava8...)},
+			"jvm11-server-options": {open: true},
+			"jvm17-server-options": {open: true},
+			"cassandra-env-sh":     {open: true},
+			"logback-xml":          {open: true},
+		},
+	},
+	{
+		// DSE accepts many more settings and config files than are known
+		// here, so only the types of the known settings are checked
+		serverType: "dse",
+		version:    "6.8",
+		open:       true,
+		sections: map[string]section{
+			"cassandra-yaml":       {settings: mergeSettings(cassandraYaml, cassandraYaml4), open: true},
+			"dse-yaml":             {open: true},
+			"jvm-server-options":   {open: true},
+			"jvm8-server-options":  {open: true},
+			"jvm11-server-options": {open: true},
+			"cassandra-env-sh":     {open: true},
+			"logback-xml":          {open: true},
+		},
+	},
+}
+
+func mergeSettings(all ...settings) settings {
+	merged := settings{}
+	for _, s := range all {
+		for k, v := range s {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+func withoutSettings(s settings, keys ...string) settings {
+	out := mergeSettings(s)
+	for _, key := range keys {
+		delete(out, key)
+	}
+	return out
+}
+
+// cassandraYaml are the cassandra.yaml settings common to all server versions
+var cassandraYaml = settings{
+	"allocate_tokens_for_keyspace":                             StringValue,
+	"authenticator":                                            StringValue,
+	"authorizer":                                               StringValue,
+	"auto_bootstrap":                                           BooleanValue,
+	"auto_snapshot":                                            BooleanValue,
+	"back_pressure_enabled":                                    BooleanValue,
+	"back_pressure_strategy":                                   ListValue,
+	"batch_size_fail_threshold_in_kb":                          IntegerValue,
+	"batch_size_warn_threshold_in_kb":                          IntegerValue,
+	"batchlog_replay_throttle_in_kb":                           IntegerValue,
+	"broadcast_address":                                        StringValue,
+	"broadcast_rpc_address":                                    StringValue,
+	"buffer_pool_use_heap_if_exhausted":                        BooleanValue,
+	"cas_contention_timeout_in_ms":                             IntegerValue,
+	"cdc_enabled":                                              BooleanValue,
+	"cdc_free_space_check_interval_ms":                         IntegerValue,
+	"cdc_raw_directory":                                        StringValue,
+	"cdc_total_space_in_mb":                                    IntegerValue,
+	"check_for_duplicate_rows_during_compaction":               BooleanValue,
+	"check_for_duplicate_rows_during_reads":                    BooleanValue,
+	"client_encryption_options":                                MapValue,
+	"cluster_name":                                             StringValue,
+	"column_index_cache_size_in_kb":                            IntegerValue,
+	"column_index_size_in_kb":                                  IntegerValue,
+	"commit_failure_policy":                                    StringValue,
+	"commitlog_compression":                                    ListValue,
+	"commitlog_directory":                                      StringValue,
+	"commitlog_max_compression_buffers_in_pool":                IntegerValue,
+	"commitlog_segment_size_in_mb":                             IntegerValue,
+	"commitlog_sync":                                           StringValue,
+	"commitlog_sync_batch_window_in_ms":                        NumberValue,
+	"commitlog_sync_period_in_ms":                              IntegerValue,
+	"commitlog_total_space_in_mb":                              IntegerValue,
+	"compaction_large_partition_warning_threshold_mb":          IntegerValue,
+	"compaction_throughput_mb_per_sec":                         IntegerValue,
+	"concurrent_compactors":                                    IntegerValue,
+	"concurrent_counter_writes":                                IntegerValue,
+	"concurrent_materialized_view_writes":                      IntegerValue,
+	"concurrent_reads":                                         IntegerValue,
+	"concurrent_writes":                                        IntegerValue,
+	"counter_cache_keys_to_save":                               IntegerValue,
+	"counter_cache_save_period":                                IntegerValue,
+	"counter_cache_size_in_mb":                                 IntegerValue,
+	"counter_write_request_timeout_in_ms":                      IntegerValue,
+	"credentials_cache_max_entries":                            IntegerValue,
+	"credentials_update_interval_in_ms":                        IntegerValue,
+	"credentials_validity_in_ms":                               IntegerValue,
+	"cross_node_timeout":                                       BooleanValue,
+	"data_file_directories":                                    ListValue,
+	"disk_access_mode":                                         StringValue,
+	"disk_failure_policy":                                      StringValue,
+	"disk_optimization_strategy":                               StringValue,
+	"dynamic_snitch":                                           BooleanValue,
+	"dynamic_snitch_badness_threshold":                         NumberValue,
+	"dynamic_snitch_reset_interval_in_ms":                      IntegerValue,
+	"dynamic_snitch_update_interval_in_ms":                     IntegerValue,
+	"enable_materialized_views":                                BooleanValue,
+	"enable_sasi_indexes":                                      BooleanValue,
+	"enable_scripted_user_defined_functions":                   BooleanValue,
+	"enable_user_defined_functions":                            BooleanValue,
+	"enable_user_defined_functions_threads":                    BooleanValue,
+	"endpoint_snitch":                                          StringValue,
+	"file_cache_size_in_mb":                                    IntegerValue,
+	"gc_log_threshold_in_ms":                                   IntegerValue,
+	"gc_warn_threshold_in_ms":                                  IntegerValue,
+	"hinted_handoff_disabled_datacenters":                      ListValue,
+	"hinted_handoff_enabled":                                   BooleanValue,
+	"hinted_handoff_throttle_in_kb":                            IntegerValue,
+	"hints_compression":                                        ListValue,
+	"hints_directory":                                          StringValue,
+	"hints_flush_period_in_ms":                                 IntegerValue,
+	"incremental_backups":                                      BooleanValue,
+	"index_summary_capacity_in_mb":                             IntegerValue,
+	"index_summary_resize_interval_in_minutes":                 IntegerValue,
+	"initial_token":                                            StringValue,
+	"inter_dc_stream_throughput_outbound_megabits_per_sec":     IntegerValue,
+	"inter_dc_tcp_nodelay":                                     BooleanValue,
+	"internode_authenticator":                                  StringValue,
+	"internode_compression":                                    StringValue,
+	"key_cache_keys_to_save":                                   IntegerValue,
+	"key_cache_save_period":                                    IntegerValue,
+	"key_cache_size_in_mb":                                     IntegerValue,
+	"listen_address":                                           StringValue,
+	"listen_interface":                                         StringValue,
+	"listen_interface_prefer_ipv6":                             BooleanValue,
+	"listen_on_broadcast_address":                              BooleanValue,
+	"max_hint_window_in_ms":                                    IntegerValue,
+	"max_hints_delivery_threads":                               IntegerValue,
+	"max_hints_file_size_in_mb":                                IntegerValue,
+	"max_mutation_size_in_kb":                                  IntegerValue,
+	"max_value_size_in_mb":                                     IntegerValue,
+	"memtable_allocation_type":                                 StringValue,
+	"memtable_cleanup_threshold":                               NumberValue,
+	"memtable_flush_writers":                                   IntegerValue,
+	"memtable_heap_space_in_mb":                                IntegerValue,
+	"memtable_offheap_space_in_mb":                             IntegerValue,
+	"native_transport_flush_in_batches_legacy":                 BooleanValue,
+	"native_transport_max_concurrent_connections":              IntegerValue,
+	"native_transport_max_concurrent_connections_per_ip":       IntegerValue,
+	"native_transport_max_concurrent_requests_in_bytes":        IntegerValue,
+	"native_transport_max_concurrent_requests_in_bytes_per_ip": IntegerValue,
+	"native_transport_max_frame_size_in_mb":                    IntegerValue,
+	"native_transport_max_threads":                             IntegerValue,
+	"native_transport_port":                                    IntegerValue,
+	"native_transport_port_ssl":                                IntegerValue,
+	"native_transport_receive_queue_capacity_in_bytes":         IntegerValue,
+	"num_tokens":                                               IntegerValue,
+	"otc_backlog_expiration_interval_ms":                       IntegerValue,
+	"otc_coalescing_enough_coalesced_messages":                 IntegerValue,
+	"otc_coalescing_strategy":                                  StringValue,
+	"otc_coalescing_window_us":                                 IntegerValue,
+	"partitioner":                                              StringValue,
+	"permissions_cache_max_entries":                            IntegerValue,
+	"permissions_update_interval_in_ms":                        IntegerValue,
+	"permissions_validity_in_ms":                               IntegerValue,
+	"phi_convict_threshold":                                    NumberValue,
+	"prepared_statements_cache_size_mb":                        IntegerValue,
+	"range_request_timeout_in_ms":                              IntegerValue,
+	"read_request_timeout_in_ms":                               IntegerValue,
+	"repair_session_max_tree_depth":                            IntegerValue,
+	"replica_filtering_protection":                             MapValue,
+	"request_timeout_in_ms":                                    IntegerValue,
+	"role_manager":                                             StringValue,
+	"roles_cache_max_entries":                                  IntegerValue,
+	"roles_update_interval_in_ms":                              IntegerValue,
+	"roles_validity_in_ms":                                     IntegerValue,
+	"row_cache_class_name":                                     StringValue,
+	"row_cache_keys_to_save":                                   IntegerValue,
+	"row_cache_save_period":                                    IntegerValue,
+	"row_cache_size_in_mb":                                     IntegerValue,
+	"rpc_address":                                              StringValue,
+	"rpc_interface":                                            StringValue,
+	"rpc_interface_prefer_ipv6":                                BooleanValue,
+	"rpc_keepalive":                                            BooleanValue,
+	"saved_caches_directory":                                   StringValue,
+	"seed_provider":                                            ListValue,
+	"server_encryption_options":                                MapValue,
+	"slow_query_log_timeout_in_ms":                             IntegerValue,
+	"snapshot_before_compaction":                               BooleanValue,
+	"snapshot_on_duplicate_row_detection":                      BooleanValue,
+	"ssl_storage_port":                                         IntegerValue,
+	"sstable_preemptive_open_interval_in_mb":                   IntegerValue,
+	"start_native_transport":                                   BooleanValue,
+	"storage_port":                                             IntegerValue,
+	"stream_throughput_outbound_megabits_per_sec":              IntegerValue,
+	"streaming_keep_alive_period_in_secs":                      IntegerValue,
+	"tombstone_failure_threshold":                              IntegerValue,
+	"tombstone_warn_threshold":                                 IntegerValue,
+	"tracetype_query_ttl":                                      IntegerValue,
+	"tracetype_repair_ttl":                                     IntegerValue,
+	"transparent_data_encryption_options":                      MapValue,
+	"trickle_fsync":                                            BooleanValue,
+	"trickle_fsync_interval_in_kb":                             IntegerValue,
+	"truncate_request_timeout_in_ms":                           IntegerValue,
+	"unlogged_batch_across_partitions_warn_threshold":          IntegerValue,
+	"user_defined_function_fail_timeout":                       IntegerValue,
+	"user_defined_function_warn_timeout":                       IntegerValue,
+	"user_function_timeout_policy":                             StringValue,
+	"windows_timer_interval":                                   IntegerValue,
+	"write_request_timeout_in_ms":                              IntegerValue,
+}
+
+// cassandraYaml3 are the cassandra.yaml settings removed in Cassandra 4.0
+var cassandraYaml3 = settings{
+	"internode_recv_buff_size_in_bytes":                IntegerValue,
+	"internode_send_buff_size_in_bytes":                IntegerValue,
+	"native_transport_max_negotiable_protocol_version": IntegerValue,
+	"request_scheduler":                                StringValue,
+	"request_scheduler_id":                             StringValue,
+	"request_scheduler_options":                        MapValue,
+	"rpc_max_threads":                                  IntegerValue,
+	"rpc_min_threads":                                  IntegerValue,
+	"rpc_port":                                         IntegerValue,
+	"rpc_recv_buff_size_in_bytes":                      IntegerValue,
+	"rpc_send_buff_size_in_bytes":                      IntegerValue,
+	"rpc_server_type":                                  StringValue,
+	"start_rpc":                                        BooleanValue,
+	"streaming_socket_timeout_in_ms":                   IntegerValue,
+	"thrift_framed_transport_size_in_mb":               IntegerValue,
+	"thrift_prepared_statements_cache_size_mb":         IntegerValue,
+}
+
+// cassandraYaml4 are the cassandra.yaml settings added in Cassandra 4.0
+var cassandraYaml4 = settings{
+	"allocate_tokens_for_local_replication_factor":                           IntegerValue,
+	"audit_logging_options":                                                  MapValue,
+	"auto_optimise_full_repair_streams":                                      BooleanValue,
+	"auto_optimise_inc_repair_streams":                                       BooleanValue,
+	"auto_optimise_preview_repair_streams":                                   BooleanValue,
+	"autocompaction_on_startup_enabled":                                      BooleanValue,
+	"automatic_sstable_upgrade":                                              BooleanValue,
+	"block_for_peers_in_remote_dcs":                                          BooleanValue,
+	"block_for_peers_timeout_in_secs":                                        IntegerValue,
+	"commitlog_sync_group_window_in_ms":                                      NumberValue,
+	"concurrent_materialized_view_builders":                                  IntegerValue,
+	"concurrent_validations":                                                 IntegerValue,
+	"consecutive_message_errors_threshold":                                   IntegerValue,
+	"corrupted_tombstone_strategy":                                           StringValue,
+	"diagnostic_events_enabled":                                              BooleanValue,
+	"enable_drop_compact_storage":                                            BooleanValue,
+	"enable_transient_replication":                                           BooleanValue,
+	"file_cache_enabled":                                                     BooleanValue,
+	"file_cache_round_up":                                                    BooleanValue,
+	"flush_compression":                                                      StringValue,
+	"full_query_logging_options":                                             MapValue,
+	"ideal_consistency_level":                                                StringValue,
+	"initial_range_tombstone_list_allocation_size":                           IntegerValue,
+	"internode_application_receive_queue_capacity_in_bytes":                  IntegerValue,
+	"internode_application_receive_queue_reserve_endpoint_capacity_in_bytes": IntegerValue,
+	"internode_application_receive_queue_reserve_global_capacity_in_bytes":   IntegerValue,
+	"internode_application_send_queue_capacity_in_bytes":                     IntegerValue,
+	"internode_application_send_queue_reserve_endpoint_capacity_in_bytes":    IntegerValue,
+	"internode_application_send_queue_reserve_global_capacity_in_bytes":      IntegerValue,
+	"internode_max_message_size_in_bytes":                                    IntegerValue,
+	"internode_socket_receive_buffer_size_in_bytes":                          IntegerValue,
+	"internode_socket_send_buffer_size_in_bytes":                             IntegerValue,
+	"internode_tcp_connect_timeout_in_ms":                                    IntegerValue,
+	"internode_tcp_user_timeout_in_ms":                                       IntegerValue,
+	"keyspace_count_warn_threshold":                                          IntegerValue,
+	"max_concurrent_automatic_sstable_upgrades":                              IntegerValue,
+	"native_transport_allow_older_protocols":                                 BooleanValue,
+	"native_transport_idle_timeout_in_ms":                                    IntegerValue,
+	"network_authorizer":                                                     StringValue,
+	"networking_cache_size_in_mb":                                            IntegerValue,
+	"periodic_commitlog_sync_lag_block_in_ms":                                IntegerValue,
+	"range_tombstone_list_growth_factor":                                     NumberValue,
+	"repair_command_pool_full_strategy":                                      StringValue,
+	"repair_command_pool_size":                                               IntegerValue,
+	"repair_session_space_in_mb":                                             IntegerValue,
+	"repaired_data_tracking_for_partition_reads_enabled":                     BooleanValue,
+	"repaired_data_tracking_for_range_reads_enabled":                         BooleanValue,
+	"report_unconfirmed_repaired_data_mismatches":                            BooleanValue,
+	"snapshot_on_repaired_data_mismatch":                                     BooleanValue,
+	"stream_entire_sstables":                                                 BooleanValue,
+	"streaming_connections_per_host":                                         IntegerValue,
+	"table_count_warn_threshold":                                             IntegerValue,
+	"use_offheap_merkle_trees":                                               BooleanValue,
+	"validation_preview_purge_head_start_in_sec":                             IntegerValue,
+}
+
+// cassandraYamlRenamed41 are the cassandra.yaml settings renamed in Cassandra
+// 4.1, by old name. The new names of the settings of durations, data sizes and
+// data rates take a value with a unit, such as 500ms or 64MiB. The old names
+// are still accepted.
+var cassandraYamlRenamed41 = map[string]string{
+	"batch_size_fail_threshold_in_kb":                                        "batch_size_fail_threshold",
+	"batch_size_warn_threshold_in_kb":                                        "batch_size_warn_threshold",
+	"batchlog_replay_throttle_in_kb":                                         "batchlog_replay_throttle",
+	"cas_contention_timeout_in_ms":                                           "cas_contention_timeout",
+	"cdc_total_space_in_mb":                                                  "cdc_total_space",
+	"column_index_cache_size_in_kb":                                          "column_index_cache_size",
+	"column_index_size_in_kb":                                                "column_index_size",
+	"commitlog_segment_size_in_mb":                                           "commitlog_segment_size",
+	"commitlog_sync_group_window_in_ms":                                      "commitlog_sync_group_window",
+	"commitlog_sync_period_in_ms":                                            "commitlog_sync_period",
+	"commitlog_total_space_in_mb":                                            "commitlog_total_space",
+	"compaction_throughput_mb_per_sec":                                       "compaction_throughput",
+	"counter_cache_size_in_mb":                                               "counter_cache_size",
+	"counter_write_request_timeout_in_ms":                                    "counter_write_request_timeout",
+	"credentials_update_interval_in_ms":                                      "credentials_update_interval",
+	"credentials_validity_in_ms":                                             "credentials_validity",
+	"dynamic_snitch_reset_interval_in_ms":                                    "dynamic_snitch_reset_interval",
+	"dynamic_snitch_update_interval_in_ms":                                   "dynamic_snitch_update_interval",
+	"enable_drop_compact_storage":                                            "drop_compact_storage_enabled",
+	"enable_materialized_views":                                              "materialized_views_enabled",
+	"enable_sasi_indexes":                                                    "sasi_indexes_enabled",
+	"enable_scripted_user_defined_functions":                                 "scripted_user_defined_functions_enabled",
+	"enable_transient_replication":                                           "transient_replication_enabled",
+	"enable_user_defined_functions":                                          "user_defined_functions_enabled",
+	"enable_user_defined_functions_threads":                                  "user_defined_functions_threads_enabled",
+	"file_cache_size_in_mb":                                                  "file_cache_size",
+	"gc_log_threshold_in_ms":                                                 "gc_log_threshold",
+	"gc_warn_threshold_in_ms":                                                "gc_warn_threshold",
+	"hinted_handoff_throttle_in_kb":                                          "hinted_handoff_throttle",
+	"hints_flush_period_in_ms":                                               "hints_flush_period",
+	"index_summary_capacity_in_mb":                                           "index_summary_capacity",
+	"index_summary_resize_interval_in_minutes":                               "index_summary_resize_interval",
+	"inter_dc_stream_throughput_outbound_megabits_per_sec":                   "inter_dc_stream_throughput_outbound",
+	"internode_application_receive_queue_capacity_in_bytes":                  "internode_application_receive_queue_capacity",
+	"internode_application_receive_queue_reserve_endpoint_capacity_in_bytes": "internode_application_receive_queue_reserve_endpoint_capacity",
+	"internode_application_receive_queue_reserve_global_capacity_in_bytes":   "internode_application_receive_queue_reserve_global_capacity",
+	"internode_application_send_queue_capacity_in_bytes":                     "internode_application_send_queue_capacity",
+	"internode_application_send_queue_reserve_endpoint_capacity_in_bytes":    "internode_application_send_queue_reserve_endpoint_capacity",
+	"internode_application_send_queue_reserve_global_capacity_in_bytes":      "internode_application_send_queue_reserve_global_capacity",
+	"internode_max_message_size_in_bytes":                                    "internode_max_message_size",
+	"internode_socket_receive_buffer_size_in_bytes":                          "internode_socket_receive_buffer_size",
+	"internode_socket_send_buffer_size_in_bytes":                             "internode_socket_send_buffer_size",
+	"internode_tcp_connect_timeout_in_ms":                                    "internode_tcp_connect_timeout",
+	"internode_tcp_user_timeout_in_ms":                                       "internode_tcp_user_timeout",
+	"key_cache_size_in_mb":                                                   "key_cache_size",
+	"max_hint_window_in_ms":                                                  "max_hint_window",
+	"max_hints_file_size_in_mb":                                              "max_hints_file_size",
+	"max_mutation_size_in_kb":                                                "max_mutation_size",
+	"max_value_size_in_mb":                                                   "max_value_size",
+	"memtable_heap_space_in_mb":                                              "memtable_heap_space",
+	"memtable_offheap_space_in_mb":                                           "memtable_offheap_space",
+	"native_transport_idle_timeout_in_ms":                                    "native_transport_idle_timeout",
+	"native_transport_max_concurrent_requests_in_bytes":                      "native_transport_max_request_data_in_flight",
+	"native_transport_max_concurrent_requests_in_bytes_per_ip":               "native_transport_max_request_data_in_flight_per_ip",
+	"native_transport_max_frame_size_in_mb":                                  "native_transport_max_frame_size",
+	"native_transport_receive_queue_capacity_in_bytes":                       "native_transport_receive_queue_capacity",
+	"networking_cache_size_in_mb":                                            "networking_cache_size",
+	"periodic_commitlog_sync_lag_block_in_ms":                                "periodic_commitlog_sync_lag_block",
+	"permissions_update_interval_in_ms":                                      "permissions_update_interval",
+	"permissions_validity_in_ms":                                             "permissions_validity",
+	"prepared_statements_cache_size_mb":                                      "prepared_statements_cache_size",
+	"range_request_timeout_in_ms":                                            "range_request_timeout",
+	"read_request_timeout_in_ms":                                             "read_request_timeout",
+	"repair_session_space_in_mb":                                             "repair_session_space",
+	"request_timeout_in_ms":                                                  "request_timeout",
+	"roles_update_interval_in_ms":                                            "roles_update_interval",
+	"roles_validity_in_ms":                                                   "roles_validity",
+	"row_cache_size_in_mb":                                                   "row_cache_size",
+	"slow_query_log_timeout_in_ms":                                           "slow_query_log_timeout",
+	"sstable_preemptive_open_interval_in_mb":                                 "sstable_preemptive_open_interval",
+	"stream_throughput_outbound_megabits_per_sec":                            "stream_throughput_outbound",
+	"streaming_keep_alive_period_in_secs":                                    "streaming_keep_alive_period",
+	"trickle_fsync_interval_in_kb":                                           "trickle_fsync_interval",
+	"truncate_request_timeout_in_ms":                                         "truncate_request_timeout",
+	"validation_preview_purge_head_start_in_sec":                             "validation_preview_purge_head_start",
+	"write_request_timeout_in_ms":                                            "write_request_timeout",
+}
+
+// cassandraYaml41 are the cassandra.yaml settings added in Cassandra 4.1,
+// including the new names of cassandraYamlRenamed41
+var cassandraYaml41 = settings{
+	"auth_cache_warming_enabled":                                    BooleanValue,
+	"batch_size_fail_threshold":                                     DataStorageValue,
+	"batch_size_warn_threshold":                                     DataStorageValue,
+	"batchlog_replay_throttle":                                      DataStorageValue,
+	"cas_contention_timeout":                                        DurationValue,
+	"cdc_total_space":                                               DataStorageValue,
+	"column_index_cache_size":                                       DataStorageValue,
+	"column_index_size":                                             DataStorageValue,
+	"commitlog_segment_size":                                        DataStorageValue,
+	"commitlog_sync_group_window":                                   DurationValue,
+	"commitlog_sync_period":                                         DurationValue,
+	"commitlog_total_space":                                         DataStorageValue,
+	"compaction_throughput":                                         DataRateValue,
+	"counter_cache_size":                                            DataStorageValue,
+	"counter_write_request_timeout":                                 DurationValue,
+	"credentials_update_interval":                                   DurationValue,
+	"credentials_validity":                                          DurationValue,
+	"drop_compact_storage_enabled":                                  BooleanValue,
+	"dynamic_snitch_reset_interval":                                 DurationValue,
+	"dynamic_snitch_update_interval":                                DurationValue,
+	"file_cache_size":                                               DataStorageValue,
+	"gc_log_threshold":                                              DurationValue,
+	"gc_warn_threshold":                                             DurationValue,
+	"hinted_handoff_throttle":                                       DataStorageValue,
+	"hints_flush_period":                                            DurationValue,
+	"index_summary_capacity":                                        DataStorageValue,
+	"index_summary_resize_interval":                                 DurationValue,
+	"inter_dc_stream_throughput_outbound":                           DataRateValue,
+	"internode_application_receive_queue_capacity":                  DataStorageValue,
+	"internode_application_receive_queue_reserve_endpoint_capacity": DataStorageValue,
+	"internode_application_receive_queue_reserve_global_capacity":   DataStorageValue,
+	"internode_application_send_queue_capacity":                     DataStorageValue,
+	"internode_application_send_queue_reserve_endpoint_capacity":    DataStorageValue,
+	"internode_application_send_queue_reserve_global_capacity":      DataStorageValue,
+	"internode_max_message_size":                                    DataStorageValue,
+	"internode_socket_receive_buffer_size":                          DataStorageValue,
+	"internode_socket_send_buffer_size":                             DataStorageValue,
+	"internode_tcp_connect_timeout":                                 DurationValue,
+	"internode_tcp_user_timeout":                                    DurationValue,
+	"key_cache_size":                                                DataStorageValue,
+	"keyspaces_fail_threshold":                                      IntegerValue,
+	"keyspaces_warn_threshold":                                      IntegerValue,
+	"materialized_views_enabled":                                    BooleanValue,
+	"max_hint_window":                                               DurationValue,
+	"max_hints_file_size":                                           DataStorageValue,
+	"max_mutation_size":                                             DataStorageValue,
+	"max_value_size":                                                DataStorageValue,
+	"memtable_heap_space":                                           DataStorageValue,
+	"memtable_offheap_space":                                        DataStorageValue,
+	"minimum_replication_factor_fail_threshold":                     IntegerValue,
+	"native_transport_idle_timeout":                                 DurationValue,
+	"native_transport_max_frame_size":                               DataStorageValue,
+	"native_transport_max_request_data_in_flight":                   DataStorageValue,
+	"native_transport_max_request_data_in_flight_per_ip":            DataStorageValue,
+	"native_transport_max_requests_per_second":                      IntegerValue,
+	"native_transport_rate_limiting_enabled":                        BooleanValue,
+	"native_transport_receive_queue_capacity":                       DataStorageValue,
+	"networking_cache_size":                                         DataStorageValue,
+	"paxos_variant":                                                 StringValue,
+	"periodic_commitlog_sync_lag_block":                             DurationValue,
+	"permissions_update_interval":                                   DurationValue,
+	"permissions_validity":                                          DurationValue,
+	"prepared_statements_cache_size":                                DataStorageValue,
+	"range_request_timeout":                                         DurationValue,
+	"read_before_write_list_operations_enabled":                     BooleanValue,
+	"read_request_timeout":                                          DurationValue,
+	"repair_session_space":                                          DataStorageValue,
+	"request_timeout":                                               DurationValue,
+	"roles_update_interval":                                         DurationValue,
+	"roles_validity":                                                DurationValue,
+	"row_cache_size":                                                DataStorageValue,
+	"sasi_indexes_enabled":                                          BooleanValue,
+	"scripted_user_defined_functions_enabled":                       BooleanValue,
+	"slow_query_log_timeout":                                        DurationValue,
+	"sstable_preemptive_open_interval":                              DataStorageValue,
+	"stream_throughput_outbound":                                    DataRateValue,
+	"streaming_keep_alive_period":                                   DurationValue,
+	"tables_fail_threshold":                                         IntegerValue,
+	"tables_warn_threshold":                                         IntegerValue,
+	"transient_replication_enabled":                                 BooleanValue,
+	"traverse_auth_from_root":                                       BooleanValue,
+	"trickle_fsync_interval":                                        DataStorageValue,
+	"truncate_request_timeout":                                      DurationValue,
+	"user_defined_functions_enabled":                                BooleanValue,
+	"user_defined_functions_threads_enabled":                        BooleanValue,
+	"uuid_sstable_identifiers_enabled":                              BooleanValue,
+	"validation_preview_purge_head_start":                           DurationValue,
+	"write_request_timeout":                                         DurationValue,
+}
+
+// cassandraYaml5 are the cassandra.yaml settings added in Cassandra 5.0
+var cassandraYaml5 = settings{
+	"default_compaction":              MapValue,
+	"dump_heap_on_uncaught_exception": BooleanValue,
+	"dynamic_data_masking_enabled":    BooleanValue,
+	"heap_dump_path":                  StringValue,
+	"memtable":                        MapValue,
+	"sstable":                         MapValue,
+	"storage_compatibility_mode":      StringValue,
+}
+
+// jvmServerOptions are the JVM options common to all server versions, see
+// docs/user/jvm_server_configuration.md
+var jvmServerOptions = settings{
+	"additional-jvm-opts":                                ListValue,
+	"agent_lib_jdwp":                                     BooleanValue,
+	"always_pre_touch":                                   BooleanValue,
+	"cassandra_available_processors":                     IntegerValue,
+	"cassandra_config_directory":                         StringValue,
+	"cassandra_disable_auth_caches_remote_configuration": BooleanValue,
+	"cassandra_force_default_indexing_page_size":         BooleanValue,
+	"cassandra_initial_token":                            StringValue,
+	"cassandra_join_ring":                                BooleanValue,
+	"cassandra_load_ring_state":                          BooleanValue,
+	"cassandra_metrics_reporter_config_file":             StringValue,
+	"cassandra_replace_address":                          StringValue,
+	"cassandra_ring_delay_ms":                            IntegerValue,
+	"cassandra_triggers_dir":                             StringValue,
+	"cassandra_write_survey":                             BooleanValue,
+	"enable_assertions":                                  BooleanValue,
+	"flight_recorder":                                    BooleanValue,
+	"heap_dump_on_out_of_memory_error":                   BooleanValue,
+	"initial_heap_size":                                  StringValue,
+	"java_net_prefer_ipv4_stack":                         BooleanValue,
+	"jmx-connection-type":                                StringValue,
+	"max_heap_size":                                      StringValue,
+	"per_thread_stack_size":                              StringValue,
+	"perf_disable_shared_mem":                            BooleanValue,
+	"resize_tlb":                                         BooleanValue,
+	"string_table_size":                                  StringValue,
+	"use_thread_priorities":                              BooleanValue,
+	"use_tlb":                                            BooleanValue,
+}
+
+// jvmOptions3 are the jvm-options settings of Cassandra 3.11
+var jvmOptions3 = settings{
+	"cassandra_force_3_0_protocol_version": BooleanValue,
+	"cassandra_replay_list":                StringValue,
+	"cms_initiating_occupancy_fraction":    IntegerValue,
+	"cms_wait_duration":                    IntegerValue,
+	"conc_gc_threads":                      IntegerValue,
+	"g1r_set_updating_pause_time_percent":  IntegerValue,
+	"garbage_collector":                    StringValue,
+	"gc_log_file_size":                     StringValue,
+	"heap_size_young_generation":           StringValue,
+	"initiating_heap_occupancy_percent":    IntegerValue,
+	"jmx-remote-ssl-opts":                  StringValue,
+	"log_gc":                               BooleanValue,
+	"max_gc_pause_millis":                  IntegerValue,
+	"max_tenuring_threshold":               IntegerValue,
+	"number_of_gc_log_files":               IntegerValue,
+	"parallel_gc_threads":                  IntegerValue,
+	"print_flss_statistics":                BooleanValue,
+	"print_gc_application_stopped_time":    BooleanValue,
+	"print_gc_details":                     BooleanValue,
+	"print_heap_at_gc":                     BooleanValue,
+	"print_promotion_failure":              BooleanValue,
+	"print_tenuring_distribution":          BooleanValue,
+	"survivor_ratio":                       IntegerValue,
+	"thread_priority_policy_42":            BooleanValue,
+	"unlock_commerical_features":           BooleanValue,
+	"use_biased_locking":                   BooleanValue,
+	"use_gc_log_file_rotation":             BooleanValue,
+}
+
+// jvmServerOptions4 are the jvm-server-options settings of Cassandra 4.0
+var jvmServerOptions4 = settings{
+	"cassandra_expiration_date_overflow_policy":   StringValue,
+	"cassandra_max_hint_ttl":                      StringValue,
+	"crash_on_out_of_memory_error":                BooleanValue,
+	"debug-non-safepoints":                        BooleanValue,
+	"exit_on_out_of_memory_error":                 BooleanValue,
+	"guaranteed-safepoint-interval":               StringValue,
+	"io_netty_eventloop_maxpendingtasks":          IntegerValue,
+	"jdk_nio_maxcachedbuffersize":                 IntegerValue,
+	"log_compilation":                             BooleanValue,
+	"page-align-direct-memory":                    BooleanValue,
+	"preserve-frame-pointer":                      BooleanValue,
+	"print_heap_histogram_on_out_of_memory_error": BooleanValue,
+	"restrict-contended":                          BooleanValue,
+	"unlock-diagnostic-vm-options":                BooleanValue,
+	"unlock_commercial_features":                  BooleanValue,
+	"use-biased-locking":                          BooleanValue,
+	"use_numa":                                    BooleanValue,
+}
+
+// jvmServerOptionsJava8 are the jvm-server-options settings of Cassandra 4.0
+// and 4.1 that only apply to Java 8, and are left out in Cassandra 5.0
+var jvmServerOptionsJava8 = []string{
+	"unlock_commercial_features",
+	"use-biased-locking",
+}
@@ -0,0 +1,219 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package serverconfig
+
+import (
+	"encoding/json"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		serverType    string
+		serverVersion string
+		config        string
+		want          []string
+	}{
+		{
+			name:          "Valid Cassandra 3.11 config",
+			serverType:    "cassandra",
+			serverVersion: "3.11.7",
+			config: `{
+				"cassandra-yaml": {
+					"num_tokens": 8,
+					"authenticator": "PasswordAuthenticator",
+					"phi_convict_threshold": 12.5,
+					"data_file_directories": ["/var/lib/cassandra/data"],
+					"server_encryption_options": {"internode_encryption": "all"},
+					"key_cache_size_in_mb": null
+				},
+				"jvm-options": {
+					"initial_heap_size": "2G",
+					"additional-jvm-opts": ["-Dcassandra.ring_delay_ms=1000"]
+				},
+				"cassandra-env-sh": {"anything": "goes"}
+			}`,
+		},
+		{
+			name:          "Typos and wrong types",
+			serverType:    "cassandra",
+			serverVersion: "3.11.7",
+			config: `{
+				"cassandra-yaml": {
+					"num_token": 16,
+					"num_tokens": "16",
+					"concurrent_reads": 32.5,
+					"auto_snapshot": "true"
+				},
+				"cassandra_yaml": {}
+			}`,
+			want: []string{
+				`spec.config.cassandra-yaml.auto_snapshot: Invalid value: "true": must be of type boolean`,
+				`spec.config.cassandra-yaml.concurrent_reads: Invalid value: 32.5: must be of type integer`,
+				`spec.config.cassandra-yaml.num_token: Forbidden: unknown cassandra-yaml setting for cassandra 3.11`,
+				`spec.config.cassandra-yaml.num_tokens: Invalid value: "16": must be of type integer`,
+				`spec.config.cassandra_yaml: Unsupported value: "cassandra_yaml": supported values: "cassandra-env-sh", "cassandra-yaml", "jvm-options", "logback-xml"`,
+			},
+		},
+		{
+			name:          "Cassandra 4.0 settings with Cassandra 3.11",
+			serverType:    "cassandra",
+			serverVersion: "3.11.7",
+			config: `{
+				"cassandra-yaml": {"allocate_tokens_for_local_replication_factor": 3},
+				"jvm-server-options": {}
+			}`,
+			want: []string{
+				`spec.config.cassandra-yaml.allocate_tokens_for_local_replication_factor: Forbidden: not supported by cassandra 3.11, only by cassandra 4.0, cassandra 4.1, cassandra 5.0`,
+				`spec.config.jvm-server-options: Forbidden: not supported by cassandra 3.11, only by cassandra 4.0, cassandra 4.1, cassandra 5.0, dse 6.8`,
+			},
+		},
+		{
+			name:          "Cassandra 3.11 settings with Cassandra 4.0",
+			serverType:    "cassandra",
+			serverVersion: "4.0.0",
+			config: `{
+				"cassandra-yaml": {"start_rpc": false},
+				"jvm-server-options": {"log_gc": true}
+			}`,
+			want: []string{
+				`spec.config.cassandra-yaml.start_rpc: Forbidden: not supported by cassandra 4.0, only by cassandra 3.11`,
+				`spec.config.jvm-server-options.log_gc: Forbidden: unknown jvm-server-options setting for cassandra 4.0`,
+			},
+		},
+		{
+			name:          "Valid Cassandra 4.1 config",
+			serverType:    "cassandra",
+			serverVersion: "4.1.3",
+			config: `{
+				"cassandra-yaml": {
+					"read_request_timeout": "5000ms",
+					"key_cache_size": "100MiB",
+					"compaction_throughput": "64MiB/s",
+					"write_request_timeout_in_ms": 2000,
+					"materialized_views_enabled": true
+				},
+				"jvm-server-options": {"use-biased-locking": true},
+				"jvm8-server-options": {},
+				"jvm11-server-options": {}
+			}`,
+		},
+		{
+			name:          "Settings with units with Cassandra 4.1",
+			serverType:    "cassandra",
+			serverVersion: "4.1.3",
+			config: `{
+				"cassandra-yaml": {
+					"read_request_timeout": 5000,
+					"key_cache_size": "100MB",
+					"compaction_throughput": "64MiB",
+					"write_request_timeout": "2 s",
+					"commitlog_sync_period_in_ms": 10000,
+					"commitlog_sync_period": "10s"
+				}
+			}`,
+			want: []string{
+				`spec.config.cassandra-yaml.compaction_throughput: Invalid value: "64MiB": must be of type data rate`,
+				`spec.config.cassandra-yaml.key_cache_size: Invalid value: "100MB": must be of type data storage`,
+				`spec.config.cassandra-yaml.read_request_timeout: Invalid value: 5000: must be of type duration`,
+				`spec.config.cassandra-yaml.write_request_timeout: Invalid value: "2 s": must be of type duration`,
+				`spec.config.cassandra-yaml.commitlog_sync_period_in_ms: Forbidden: cannot be set with commitlog_sync_period, which replaces it in cassandra 4.1`,
+			},
+		},
+		{
+			name:          "Cassandra 4.1 settings with Cassandra 4.0",
+			serverType:    "cassandra",
+			serverVersion: "4.0.0",
+			config:        `{"cassandra-yaml": {"read_request_timeout": "5000ms"}}`,
+			want: []string{
+				`spec.config.cassandra-yaml.read_request_timeout: Forbidden: not supported by cassandra 4.0, only by cassandra 4.1, cassandra 5.0`,
+			},
+		},
+		{
+			name:          "Java 8 settings with Cassandra 5.0",
+			serverType:    "cassandra",
+			serverVersion: "5.0.0",
+			config: `{
+				"cassandra-yaml": {"storage_compatibility_mode": "NONE", "read_request_timeout": "5s"},
+				"jvm-server-options": {"use-biased-locking": true, "use_numa": true},
+				"jvm8-server-options": {},
+				"jvm17-server-options": {}
+			}`,
+			want: []string{
+				`spec.config.jvm-server-options.use-biased-locking: Forbidden: not supported by cassandra 5.0, only by cassandra 4.0, cassandra 4.1`,
+				`spec.config.jvm8-server-options: Forbidden: not supported by cassandra 5.0, only by cassandra 4.0, cassandra 4.1, dse 6.8`,
+			},
+		},
+		{
+			name:          "DSE only checks the types of known settings",
+			serverType:    "dse",
+			serverVersion: "6.8.4",
+			config: `{
+				"cassandra-yaml": {"memtable_space_in_mb": 100, "num_tokens": "8"},
+				"dse-yaml": {"authorization_options": {"enabled": true}},
+				"10-write-prom-conf": {"enabled": true},
+				"jvm-options": {}
+			}`,
+			want: []string{
+				`spec.config.cassandra-yaml.num_tokens: Invalid value: "8": must be of type integer`,
+				`spec.config.jvm-options: Forbidden: not supported by dse 6.8, only by cassandra 3.11`,
+			},
+		},
+		{
+			name:          "Section that is not a map",
+			serverType:    "cassandra",
+			serverVersion: "4.0.0",
+			config:        `{"cassandra-yaml": ["num_tokens"]}`,
+			want: []string{
+				`spec.config.cassandra-yaml: Invalid value: []interface {}{"num_tokens"}: must be a map of settings`,
+			},
+		},
+		{
+			name:          "Unknown server version",
+			serverType:    "cassandra",
+			serverVersion: "2.2.0",
+			config:        `{"cassandra-yaml": {"num_token": 16}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateConfig(json.RawMessage(tt.config), tt.serverType, tt.serverVersion,
+				field.NewPath("spec", "config"))
+			if len(errs) != len(tt.want) {
+				t.Fatalf("ValidateConfig() = %v, want %v", errs, tt.want)
+			}
+			for i, err := range errs {
+				if err.Error() != tt.want[i] {
+					t.Errorf("ValidateConfig()[%d] = %v, want %v", i, err, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCassandraYamlRenamed41(t *testing.T) {
+	oldSettings := mergeSettings(cassandraYaml, cassandraYaml4)
+	for oldKey, newKey := range cassandraYamlRenamed41 {
+		if _, ok := oldSettings[oldKey]; !ok {
+			t.Errorf("%s is renamed in Cassandra 4.1 but is not a setting of Cassandra 4.0", oldKey)
+		}
+		valueType, ok := cassandraYaml41[newKey]
+		if !ok {
+			t.Errorf("%s replaces %s but is not a setting of Cassandra 4.1", newKey, oldKey)
+		} else if oldSettings[oldKey] != BooleanValue && unitPatterns[valueType] == nil {
+			t.Errorf("%s replaces %s but is of type %s, without a unit", newKey, oldKey, valueType)
+		}
+	}
+}
+
+func TestValidateConfig_InvalidJSON(t *testing.T) {
+	errs := ValidateConfig(json.RawMessage(`{"cassandra-yaml":`), "cassandra", "3.11.7", field.NewPath("spec", "config"))
+	if len(errs) != 1 || errs[0].Type != field.ErrorTypeInvalid {
+		t.Errorf("ValidateConfig() = %v, want one invalid value error", errs)
+	}
+}
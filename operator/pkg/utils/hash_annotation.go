@@ -36,6 +36,16 @@ func AddHashAnnotation(r Annotated) {
 	r.SetAnnotations(m)
 }
 
+// RemoveHashAnnotation drops the hash annotation of a resource, so that the
+// hash of a modified copy of it can be added again
+func RemoveHashAnnotation(r Annotated) {
+	m := r.GetAnnotations()
+	if m != nil {
+		delete(m, resourceHashAnnotationKey)
+		r.SetAnnotations(m)
+	}
+}
+
 func deepHashString(obj interface{}) string {
 	hasher := sha256.New()
 	hash.DeepHashObject(hasher, obj)